@@ -49,6 +49,15 @@ type File struct {
 
 type ParseOpt struct {
 	FileRelativePaths bool
+	// ReadImport reads the files of a remote import source and returns the
+	// input the contexts of its targets are relative to. Remote imports are
+	// an error if it's not set.
+	ReadImport func(source string) ([]File, *Input, error)
+	// OnImport is called with the files read for each local import source.
+	OnImport func(files []File)
+	// Profiles are the compose profiles to enable. If not set, profiles are
+	// read from COMPOSE_PROFILES.
+	Profiles []string
 }
 
 type Override struct {
@@ -209,7 +218,9 @@ func ReadTargets(ctx context.Context, files []File, targets, overrides []string,
 	}
 
 	for i, t := range targets {
-		targets[i] = sanitizeTargetName(t)
+		if ns, _, ok := strings.Cut(t, "."); !ok || !c.hasNamespace(ns) {
+			targets[i] = sanitizeTargetName(t)
+		}
 	}
 
 	var tms []string
@@ -342,7 +353,11 @@ func (c Config) matchNames(pattern string) ([]string, error) {
 	return names, nil
 }
 
-func ParseFiles(files []File, defaults, vars map[string]string, opts ...ParseOpt) (_ *Config, _ *hclparser.ParseMeta, err error) {
+func ParseFiles(files []File, defaults, vars map[string]string, opts ...ParseOpt) (*Config, *hclparser.ParseMeta, error) {
+	return parseFiles(files, defaults, vars, importState{lookupEnv: envLookupAllowed()}, opts...)
+}
+
+func parseFiles(files []File, defaults, vars map[string]string, st importState, opts ...ParseOpt) (_ *Config, _ *hclparser.ParseMeta, err error) {
 	defer func() {
 		err = formatHCLError(err, files)
 	}()
@@ -376,7 +391,7 @@ func ParseFiles(files []File, defaults, vars map[string]string, opts ...ParseOpt
 	}
 
 	if len(composeFiles) > 0 {
-		cfg, cmperr := ParseComposeFiles(composeFiles, vars)
+		cfg, cmperr := ParseComposeFiles(composeFiles, vars, ParseOpt{Profiles: composeProfiles(opts)})
 		if cmperr != nil {
 			return nil, nil, errors.Wrap(cmperr, "failed to parse compose file")
 		}
@@ -393,7 +408,7 @@ func ParseFiles(files []File, defaults, vars map[string]string, opts ...ParseOpt
 			if v, ok := vars[key]; ok {
 				return v, true
 			}
-			if envLookupAllowed() {
+			if st.lookupEnv {
 				return os.LookupEnv(key)
 			}
 			return "", false
@@ -421,12 +436,22 @@ func ParseFiles(files []File, defaults, vars map[string]string, opts ...ParseOpt
 		}
 		c = dedupeConfig(c)
 		pm = *res
+
+		if err := checkSensitive(c, &pm); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if frel {
 		rebaseContextPaths(&c)
 	}
 
+	if len(c.Imports) > 0 {
+		if err := c.loadImports(defaults, st, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return &c, &pm, nil
 }
 
@@ -524,6 +549,7 @@ func ParseFile(dt []byte, fn string) (*Config, error) {
 type Config struct {
 	Groups  []*Group  `json:"group" hcl:"group,block" cty:"group"`
 	Targets []*Target `json:"target" hcl:"target,block" cty:"target"`
+	Imports []*Import `json:"import,omitempty" hcl:"import,block" cty:"import"`
 }
 
 func mergeConfig(c1, c2 Config) Config {
@@ -652,7 +678,7 @@ func (c Config) newOverrides(v []string) (map[string]map[string]Override, error)
 
 		skey := strings.TrimSuffix(parts[0], "+")
 		appendTo := strings.HasSuffix(parts[0], "+")
-		keys := strings.SplitN(skey, ".", 3)
+		keys := c.splitOverrideKey(skey)
 		if len(keys) < 2 {
 			return nil, errors.Errorf("invalid override key %s, expected target.name", skey)
 		}
@@ -862,10 +888,16 @@ type Target struct {
 	Entitlements     []string                    `json:"entitlements,omitempty" hcl:"entitlements,optional" cty:"entitlements"`
 	ExtraHosts       map[string]*string          `json:"extra-hosts,omitempty" hcl:"extra-hosts,optional" cty:"extra-hosts"`
 	Policy           buildflags.PolicyConfigs    `json:"policy,omitempty" hcl:"policy,optional" cty:"policy"`
+	Retry            *string                     `json:"retry,omitempty" hcl:"retry,optional" cty:"retry"`
 	// IMPORTANT: if you add more fields here, do not forget to update newOverrides/AddOverrides and docs/bake-reference.md.
 
 	// linked is a private field to mark a target used as a linked one
 	linked bool
+	// sensitive holds the attributes containing sensitive values
+	sensitive []string
+	// input is the remote input of a target imported from a remote source,
+	// its contexts are relative to it instead of the input of the definition
+	input *Input
 
 	defaultContextBase    string
 	hasDefaultContextBase bool
@@ -876,6 +908,10 @@ type Target struct {
 	contextsBase          map[string]string
 }
 
+func (t *Target) SetSensitiveAttributes(names []string) {
+	t.sensitive = names
+}
+
 func (t *Target) MarshalJSON() ([]byte, error) {
 	tgt := *t
 	esc := func(s string) string {
@@ -916,7 +952,26 @@ func (t *Target) MarshalJSON() ([]byte, error) {
 		}
 	}
 
-	return json.Marshal(tgt)
+	dt, err := json.Marshal(tgt)
+	if err != nil || len(t.sensitive) == 0 {
+		return dt, err
+	}
+
+	// redact the attributes containing sensitive values
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(dt, &m); err != nil {
+		return nil, err
+	}
+	redacted, err := json.Marshal(hclparser.SensitiveValue)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range t.sensitive {
+		if _, ok := m[name]; ok {
+			m[name] = redacted
+		}
+	}
+	return json.Marshal(m)
 }
 
 var (
@@ -1028,11 +1083,20 @@ func (t *Target) normalize() {
 }
 
 func (t *Target) Merge(t2 *Target) {
+	for _, name := range t2.sensitive {
+		if !slices.Contains(t.sensitive, name) {
+			t.sensitive = append(slices.Clip(t.sensitive), name)
+		}
+	}
 	if t2.hasDefaultContextBase {
 		t.defaultContextBase = t2.defaultContextBase
 		t.hasDefaultContextBase = true
 		t.useDefaultContextBase = t2.useDefaultContextBase
 	}
+	if t2.Context != nil || t2.input != nil {
+		// the input the context is relative to
+		t.input = t2.input
+	}
 	if t2.Context != nil {
 		t.Context = t2.Context
 		t.contextBase = t2.contextBase
@@ -1086,6 +1150,9 @@ func (t *Target) Merge(t2 *Target) {
 	if t2.Call != nil {
 		t.Call = t2.Call
 	}
+	if t2.Retry != nil {
+		t.Retry = t2.Retry
+	}
 	if t2.Annotations != nil { // merge
 		t.Annotations = append(t.Annotations, t2.Annotations...)
 	}
@@ -1244,6 +1311,8 @@ func (t *Target) AddOverrides(overrides map[string]Override, ent *EntitlementCon
 			t.Target = &value
 		case "call":
 			t.Call = &value
+		case "retry":
+			t.Retry = &value
 		case "secret":
 			if len(keys) != 2 {
 				return errors.Errorf("invalid format for secret, expecting secret.<id>=<value>")
@@ -1407,8 +1476,8 @@ func (t *Target) updateSecret(id, value string) error {
 			return errors.Errorf("secret override id %q does not match declared secret %q", next.ID, id)
 		}
 
-		s.Env = next.Env
-		s.FilePath = next.FilePath
+		next.ID = s.ID
+		*s = next
 		return nil
 	}
 
@@ -1447,35 +1516,136 @@ func (t *Target) GetEvalContexts(ectx *hcl.EvalContext, block *hcl.Block, loadDe
 	if err != nil {
 		return nil, err
 	}
+	if value.ContainsMarked() {
+		return nil, errors.Errorf("matrix can't contain sensitive values")
+	}
 
 	if !value.Type().IsMapType() && !value.Type().IsObjectType() {
 		return nil, errors.Errorf("matrix must be a map")
 	}
 	matrix := value.AsValueMap()
 
-	ectxs := []*hcl.EvalContext{ectx}
+	var include, exclude []map[string]cty.Value
+	dims := make([]string, 0, len(matrix))
 	for k, expr := range matrix {
 		if !expr.CanIterateElements() {
 			return nil, errors.Errorf("matrix values must be a list")
 		}
-
-		ectxs2 := []*hcl.EvalContext{}
-		for _, v := range expr.AsValueSlice() {
-			for _, e := range ectxs {
-				e2 := ectx.NewChild()
-				e2.Variables = make(map[string]cty.Value)
-				if e != ectx {
-					maps.Copy(e2.Variables, e.Variables)
+		if k == "include" || k == "exclude" {
+			if rules, ok := matrixRules(expr); ok {
+				if k == "include" {
+					include = rules
+				} else {
+					exclude = rules
 				}
-				e2.Variables[k] = v
-				ectxs2 = append(ectxs2, e2)
+				continue
+			}
+		}
+		dims = append(dims, k)
+	}
+	slices.Sort(dims)
+
+	combos := []map[string]cty.Value{{}}
+	for _, k := range dims {
+		var combos2 []map[string]cty.Value
+		for _, v := range matrix[k].AsValueSlice() {
+			for _, c := range combos {
+				c2 := maps.Clone(c)
+				c2[k] = v
+				combos2 = append(combos2, c2)
 			}
 		}
-		ectxs = ectxs2
+		combos = combos2
+	}
+	combos = applyMatrixRules(combos, dims, include, exclude)
+
+	ectxs := make([]*hcl.EvalContext, 0, len(combos))
+	for _, c := range combos {
+		if len(c) == 0 {
+			ectxs = append(ectxs, ectx)
+			continue
+		}
+		e := ectx.NewChild()
+		e.Variables = c
+		ectxs = append(ectxs, e)
 	}
 	return ectxs, nil
 }
 
+// matrixRules returns the entries of a matrix include or exclude list. The
+// list is only treated as rules if all entries are objects so a regular
+// matrix dimension can still use these names. An empty list and empty
+// objects don't add any rules.
+func matrixRules(v cty.Value) ([]map[string]cty.Value, bool) {
+	var rules []map[string]cty.Value
+	for _, e := range v.AsValueSlice() {
+		if e.IsNull() || !(e.Type().IsObjectType() || e.Type().IsMapType()) {
+			return nil, false
+		}
+		if e.LengthInt() == 0 {
+			continue
+		}
+		rules = append(rules, e.AsValueMap())
+	}
+	return rules, true
+}
+
+// applyMatrixRules filters matrix combinations with exclude rules and then
+// extends them with include rules, following the semantics of GitHub Actions
+// matrix strategies. An exclude rule removes every combination matching all
+// its values. An include rule adds its values to every combination it does
+// not conflict with on the original dimensions, or creates a new combination
+// if it can't be added to any.
+func applyMatrixRules(combos []map[string]cty.Value, dims []string, include, exclude []map[string]cty.Value) []map[string]cty.Value {
+	if len(exclude) > 0 {
+		combos = slices.DeleteFunc(combos, func(c map[string]cty.Value) bool {
+			return slices.ContainsFunc(exclude, func(rule map[string]cty.Value) bool {
+				for k, v := range rule {
+					if cv, ok := c[k]; !ok || !cv.RawEquals(v) {
+						return false
+					}
+				}
+				return true
+			})
+		})
+	}
+	if len(include) == 0 {
+		return combos
+	}
+
+	if len(dims) == 0 {
+		// without dimensions every include rule is its own combination
+		combos = make([]map[string]cty.Value, 0, len(include))
+		for _, rule := range include {
+			combos = append(combos, maps.Clone(rule))
+		}
+		return combos
+	}
+	for _, rule := range include {
+		var added bool
+		for _, c := range combos {
+			if !matrixRuleMatches(c, dims, rule) {
+				continue
+			}
+			maps.Copy(c, rule)
+			added = true
+		}
+		if !added {
+			combos = append(combos, maps.Clone(rule))
+		}
+	}
+	return combos
+}
+
+func matrixRuleMatches(c map[string]cty.Value, dims []string, rule map[string]cty.Value) bool {
+	for _, k := range dims {
+		if v, ok := rule[k]; ok && !c[k].RawEquals(v) {
+			return false
+		}
+	}
+	return true
+}
+
 func (g *Group) GetName(ectx *hcl.EvalContext, block *hcl.Block, loadDeps func(hcl.Expression) hcl.Diagnostics) (string, error) {
 	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
 		Attributes: []hcl.AttributeSchema{{Name: "name"}, {Name: "matrix"}},
@@ -1515,6 +1685,9 @@ func (t *Target) GetName(ectx *hcl.EvalContext, block *hcl.Block, loadDeps func(
 	if diags != nil {
 		return "", diags
 	}
+	if value.ContainsMarked() {
+		return "", errors.Errorf("name can't contain sensitive values")
+	}
 
 	value, err := convert.Convert(value, cty.String)
 	if err != nil {
@@ -1526,6 +1699,10 @@ func (t *Target) GetName(ectx *hcl.EvalContext, block *hcl.Block, loadDeps func(
 func TargetsToBuildOpt(m map[string]*Target, inp *Input) (map[string]build.Options, error) {
 	m2 := make(map[string]build.Options, len(m))
 	for k, v := range m {
+		inp := inp
+		if v.input != nil {
+			inp = v.input
+		}
 		bo, err := toBuildOpt(v, inp)
 		if err != nil {
 			return nil, err
@@ -1788,7 +1965,7 @@ func toBuildOpt(t *Target, inp *Input) (*build.Options, error) {
 		secrets = append(secrets, gitAuthSecretsFromEnv(inp.URL)...)
 	}
 	bo.SecretSpecs = secrets.Normalize()
-	secretAttachment, err := build.CreateSecrets(bo.SecretSpecs)
+	secretAttachment, err := build.CreateSecrets(bo.SecretSpecs, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1815,6 +1992,13 @@ func toBuildOpt(t *Target, inp *Input) (*build.Options, error) {
 		}
 	}
 
+	if t.Retry != nil {
+		bo.Retry, err = buildflags.ParseRetryPolicy(*t.Retry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if t.CacheFrom != nil {
 		bo.CacheFrom = build.CreateCaches(t.CacheFrom)
 	}
@@ -3,8 +3,10 @@ package bake
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
@@ -2842,6 +2844,35 @@ target "mtx" {
 	}
 }
 
+func TestReadTargetsMatrixExcludeInclude(t *testing.T) {
+	ctx := context.TODO()
+	f := File{
+		Name: "docker-bake.hcl",
+		Data: []byte(`
+target "app" {
+  name = "app-${base}-${replace(platform, "/", "-")}"
+  matrix = {
+    base = ["alpine", "debian"]
+    platform = ["linux/amd64", "linux/arm/v6"]
+    exclude = [{ base = "alpine", platform = "linux/arm/v6" }]
+    include = [{ base = "distroless", platform = "linux/arm64" }]
+  }
+  platforms = [platform]
+}
+`),
+	}
+
+	m, g, err := ReadTargets(ctx, []File{f}, []string{"app"}, []string{"app-distroless-*.args.FOO=bar"}, nil, nil, &EntitlementConf{})
+	require.NoError(t, err)
+
+	keys := slices.Sorted(maps.Keys(m))
+	require.Equal(t, []string{"app-alpine-linux-amd64", "app-debian-linux-amd64", "app-debian-linux-arm-v6", "app-distroless-linux-arm64"}, keys)
+	require.Equal(t, []string{"linux/arm64"}, m["app-distroless-linux-arm64"].Platforms)
+	require.Equal(t, map[string]*string{"FOO": ptrstr("bar")}, m["app-distroless-linux-arm64"].Args)
+	require.Nil(t, m["app-debian-linux-amd64"].Args)
+	require.ElementsMatch(t, keys, g["app"].Targets)
+}
+
 func TestRemoteURLWithSubdir(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -2896,3 +2927,38 @@ func stringify[V fmt.Stringer](values []V) []string {
 	sort.Strings(s)
 	return s
 }
+
+func TestReadTargetsRetry(t *testing.T) {
+	fp := File{
+		Name: "docker-bake.hcl",
+		Data: []byte(`
+		target "base" {
+			retry = "attempts=2,on=push"
+		}
+		target "app" {
+			inherits = ["base"]
+		}
+		`),
+	}
+
+	ctx := context.TODO()
+	m, _, err := ReadTargets(ctx, []File{fp}, []string{"app"}, nil, nil, nil, &EntitlementConf{})
+	require.NoError(t, err)
+
+	bo, err := TargetsToBuildOpt(m, &Input{})
+	require.NoError(t, err)
+	require.Equal(t, &buildflags.RetryPolicy{Attempts: 2, On: []string{buildflags.RetryOnPush}}, bo["app"].Retry)
+
+	m, _, err = ReadTargets(ctx, []File{fp}, []string{"app"}, []string{"app.retry=on=network|node"}, nil, nil, &EntitlementConf{})
+	require.NoError(t, err)
+
+	bo, err = TargetsToBuildOpt(m, &Input{})
+	require.NoError(t, err)
+	require.Equal(t, &buildflags.RetryPolicy{Attempts: 3, On: []string{buildflags.RetryOnNetwork, buildflags.RetryOnNode}}, bo["app"].Retry)
+
+	m, _, err = ReadTargets(ctx, []File{fp}, []string{"app"}, []string{"app.retry=on=disk"}, nil, nil, &EntitlementConf{})
+	require.NoError(t, err)
+
+	_, err = TargetsToBuildOpt(m, &Input{})
+	require.ErrorContains(t, err, `invalid retry condition "disk"`)
+}
@@ -0,0 +1,159 @@
+package bake
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/docker/buildx/build"
+	"github.com/docker/buildx/util/urlutil"
+)
+
+// ChangedTargets returns the names of the targets of the build options whose
+// local contexts, named contexts or Dockerfiles contain one of the changed
+// files, and of the targets that depend on them through target: named
+// contexts. Targets with remote inputs are always considered changed, as
+// their changes can't be tracked. Changed files must be absolute paths.
+// Symlinks are resolved on both sides, so targets of a checkout reached
+// through a symlink are matched.
+func ChangedTargets(bo map[string]build.Options, changed []string) ([]string, error) {
+	changed = slices.Clone(changed)
+	for i, f := range changed {
+		changed[i] = evalSymlinks(f)
+	}
+	affected := map[string]struct{}{}
+	for name, opt := range bo {
+		ok, err := inputsChanged(opt.Inputs, changed)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			affected[name] = struct{}{}
+		}
+	}
+
+	// propagate changes to the targets linked to changed targets
+	for {
+		n := len(affected)
+		for name, opt := range bo {
+			if _, ok := affected[name]; ok {
+				continue
+			}
+			for _, target := range linkedTargets(opt.Inputs) {
+				if _, ok := affected[target]; ok {
+					affected[name] = struct{}{}
+					break
+				}
+			}
+		}
+		if len(affected) == n {
+			break
+		}
+	}
+	return slices.Sorted(maps.Keys(affected)), nil
+}
+
+// ChangedDefinition returns the name of the first bake definition file that
+// is one of the changed files. Files read from stdin are ignored. Changed
+// files must be absolute paths, symlinks are resolved on both sides.
+func ChangedDefinition(files []File, changed []string) (string, bool, error) {
+	changed = slices.Clone(changed)
+	for i, f := range changed {
+		changed[i] = evalSymlinks(f)
+	}
+	for _, f := range files {
+		if f.Name == "-" {
+			continue
+		}
+		fn, err := filepath.Abs(f.Name)
+		if err != nil {
+			return "", false, err
+		}
+		if slices.Contains(changed, evalSymlinks(fn)) {
+			return f.Name, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// WithLinkedTargets returns names and the targets they depend on through
+// target: named contexts, which need to be built along with them.
+func WithLinkedTargets(bo map[string]build.Options, names []string) []string {
+	res := map[string]struct{}{}
+	queue := slices.Clone(names)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, ok := res[name]; ok {
+			continue
+		}
+		res[name] = struct{}{}
+		if opt, ok := bo[name]; ok {
+			queue = append(queue, linkedTargets(opt.Inputs)...)
+		}
+	}
+	return slices.Sorted(maps.Keys(res))
+}
+
+func linkedTargets(inp build.Inputs) []string {
+	var targets []string
+	for _, v := range inp.NamedContexts {
+		if target, ok := strings.CutPrefix(v.Path, "target:"); ok {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+func inputsChanged(inp build.Inputs, changed []string) (bool, error) {
+	if inp.ContextState != nil || urlutil.IsRemoteURL(inp.ContextPath) {
+		return true, nil
+	}
+	for _, v := range inp.NamedContexts {
+		if v.State != nil || urlutil.IsRemoteURL(v.Path) {
+			return true, nil
+		}
+	}
+	if inp.DockerfileInline != "" {
+		inp.DockerfilePath = ""
+	}
+	for _, p := range collectLocalPaths(inp) {
+		if p == "" {
+			continue
+		}
+		p, err := filepath.Abs(p)
+		if err != nil {
+			return false, err
+		}
+		p = evalSymlinks(p)
+		if slices.ContainsFunc(changed, func(f string) bool { return pathContains(p, f) }) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// evalSymlinks resolves the symlinks of an absolute path. The symlinks of the
+// parent directories of a path that doesn't exist, like a deleted file, are
+// still resolved.
+func evalSymlinks(p string) string {
+	if resolved, err := filepath.EvalSymlinks(p); err == nil {
+		return resolved
+	}
+	dir, base := filepath.Split(p)
+	dir = filepath.Clean(dir)
+	if dir == p {
+		return p
+	}
+	return filepath.Join(evalSymlinks(dir), base)
+}
+
+// pathContains reports whether file is p or is inside the directory p.
+func pathContains(p, file string) bool {
+	rel, err := filepath.Rel(p, file)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
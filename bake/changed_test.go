@@ -0,0 +1,161 @@
+package bake
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/docker/buildx/build"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedTargets(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	bo := map[string]build.Options{
+		"base": {
+			Inputs: build.Inputs{
+				ContextPath:    "base",
+				DockerfilePath: "base/Dockerfile",
+			},
+		},
+		"app": {
+			Inputs: build.Inputs{
+				ContextPath:    "app",
+				DockerfilePath: "dockerfiles/app.Dockerfile",
+				NamedContexts: map[string]build.NamedContext{
+					"base":   {Path: "target:base"},
+					"shared": {Path: "shared"},
+					"alpine": {Path: "docker-image://alpine"},
+				},
+			},
+		},
+		"test": {
+			Inputs: build.Inputs{
+				ContextPath:    "app",
+				DockerfilePath: "app/Dockerfile",
+				NamedContexts: map[string]build.NamedContext{
+					"app": {Path: "target:app"},
+				},
+			},
+		},
+		"docs": {
+			Inputs: build.Inputs{
+				ContextPath:      "docs",
+				DockerfileInline: "FROM scratch\nCOPY . .\n",
+			},
+		},
+		"remote": {
+			Inputs: build.Inputs{
+				ContextPath:    "https://github.com/docker/buildx.git",
+				DockerfilePath: "Dockerfile",
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		changed  []string
+		expected []string
+	}{
+		{
+			changed:  nil,
+			expected: []string{"remote"},
+		},
+		{
+			changed:  []string{"README.md", "apps/main.go"},
+			expected: []string{"remote"},
+		},
+		{
+			changed:  []string{"base/go.mod"},
+			expected: []string{"app", "base", "remote", "test"},
+		},
+		{
+			changed:  []string{"shared/lib.go"},
+			expected: []string{"app", "remote", "test"},
+		},
+		{
+			changed:  []string{"dockerfiles/app.Dockerfile"},
+			expected: []string{"app", "remote", "test"},
+		},
+		{
+			changed:  []string{"app/Dockerfile"},
+			expected: []string{"app", "remote", "test"},
+		},
+		{
+			changed:  []string{"docs/index.md"},
+			expected: []string{"docs", "remote"},
+		},
+	} {
+		changed := make([]string, 0, len(tt.changed))
+		for _, f := range tt.changed {
+			changed = append(changed, filepath.Join(dir, f))
+		}
+		affected, err := ChangedTargets(bo, changed)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, affected, "changed: %v", tt.changed)
+	}
+
+	require.Equal(t, []string{"app", "base", "test"}, WithLinkedTargets(bo, []string{"test"}))
+	require.Equal(t, []string{"docs"}, WithLinkedTargets(bo, []string{"docs"}))
+}
+
+func TestChangedDefinition(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks may require elevated privileges on Windows")
+	}
+	dir := t.TempDir()
+	link := filepath.Join(t.TempDir(), "checkout")
+	require.NoError(t, os.Symlink(dir, link))
+	t.Chdir(link)
+
+	files := []File{
+		{Name: "-"},
+		{Name: "docker-bake.hcl"},
+		{Name: filepath.Join("common", "docker-bake.hcl")},
+	}
+
+	// changed files are listed by git under the resolved root of the repo
+	name, ok, err := ChangedDefinition(files, []string{filepath.Join(dir, "common", "docker-bake.hcl")})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, filepath.Join("common", "docker-bake.hcl"), name)
+
+	name, ok, err = ChangedDefinition(files, []string{filepath.Join(link, "docker-bake.hcl")})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "docker-bake.hcl", name)
+
+	_, ok, err = ChangedDefinition(files, []string{filepath.Join(dir, "Dockerfile")})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestChangedTargetsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks may require elevated privileges on Windows")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0o755))
+
+	// changed files are resolved by git while contexts are relative to the
+	// working directory, which may be reached through a symlink
+	link := filepath.Join(t.TempDir(), "checkout")
+	require.NoError(t, os.Symlink(dir, link))
+	t.Chdir(link)
+
+	bo := map[string]build.Options{
+		"docs": {
+			Inputs: build.Inputs{
+				ContextPath:      "docs",
+				DockerfileInline: "FROM scratch\nCOPY . .\n",
+			},
+		},
+	}
+	for _, root := range []string{dir, link} {
+		affected, err := ChangedTargets(bo, []string{filepath.Join(root, "docs", "index.md")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"docs"}, affected, "root: %s", root)
+	}
+}
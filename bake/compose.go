@@ -14,6 +14,7 @@ import (
 	"github.com/compose-spec/compose-go/v2/loader"
 	composeschema "github.com/compose-spec/compose-go/v2/schema"
 	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/distribution/reference"
 	"github.com/docker/buildx/util/buildflags"
 	dockeropts "github.com/docker/cli/opts"
 	"github.com/docker/go-units"
@@ -26,7 +27,7 @@ func ParseComposeFiles(fs []File, envOverrides map[string]string, opts ...ParseO
 	if err != nil {
 		return nil, err
 	}
-	cfg, err := parseComposeFiles(fs, envs)
+	cfg, err := parseComposeFiles(fs, envs, composeProfiles(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +38,7 @@ func ParseComposeFiles(fs []File, envOverrides map[string]string, opts ...ParseO
 	return cfg, nil
 }
 
-func parseComposeFiles(fs []File, envs map[string]string) (*Config, error) {
+func parseComposeFiles(fs []File, envs map[string]string, profiles []string) (*Config, error) {
 	var cfgs []composetypes.ConfigFile
 	for _, f := range fs {
 		cfgs = append(cfgs, composetypes.ConfigFile{
@@ -45,7 +46,15 @@ func parseComposeFiles(fs []File, envs map[string]string) (*Config, error) {
 			Content:  f.Data,
 		})
 	}
-	return ParseCompose(cfgs, envs)
+	return parseCompose(cfgs, envs, profiles)
+}
+
+func composeProfiles(opts []ParseOpt) []string {
+	var profiles []string
+	for _, opt := range opts {
+		profiles = append(profiles, opt.Profiles...)
+	}
+	return profiles
 }
 
 func setComposeContextBase(c *Config, files []File) {
@@ -68,11 +77,25 @@ func setComposeContextBase(c *Config, files []File) {
 }
 
 func ParseCompose(cfgs []composetypes.ConfigFile, envs map[string]string) (*Config, error) {
+	return parseCompose(cfgs, envs, nil)
+}
+
+// parseCompose converts the services with a build section to targets. If
+// profiles are active, either set or read from COMPOSE_PROFILES, the default
+// group only contains the services without profiles and the services of the
+// active profiles. Otherwise, all services are in the default group.
+func parseCompose(cfgs []composetypes.ConfigFile, envs map[string]string, profiles []string) (*Config, error) {
 	cfg, err := loadComposeFiles(cfgs, envs)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(profiles) == 0 {
+		if v, ok := envs[consts.ComposeProfiles]; ok && v != "" {
+			profiles = strings.Split(v, ",")
+		}
+	}
+
 	var c Config
 	if len(cfg.Services) > 0 {
 		c.Groups = []*Group{}
@@ -199,7 +222,9 @@ func ParseCompose(cfgs []composetypes.ConfigFile, envs map[string]string) (*Conf
 				pull = &s.Build.Pull
 			}
 
-			g.Targets = append(g.Targets, targetName)
+			if len(profiles) == 0 || composeServiceEnabled(s, profiles) {
+				g.Targets = append(g.Targets, targetName)
+			}
 			t := &Target{
 				Name:             targetName,
 				Context:          contextPathP,
@@ -228,6 +253,12 @@ func ParseCompose(cfgs []composetypes.ConfigFile, envs map[string]string) (*Conf
 				NoCache:     noCache,
 				Pull:        pull,
 			}
+			if len(s.Build.Entitlements) > 0 {
+				t.Entitlements = s.Build.Entitlements
+			}
+			if s.Build.Privileged {
+				t.Entitlements = dedupSlice(append(t.Entitlements, string(EntitlementKeySecurityInsecure)))
+			}
 			if err = t.composeExtTarget(s.Build.Extensions); err != nil {
 				return nil, err
 			}
@@ -240,12 +271,61 @@ func ParseCompose(cfgs []composetypes.ConfigFile, envs map[string]string) (*Conf
 			}
 			c.Targets = append(c.Targets, t)
 		}
+		linkComposeDependencies(cfg, c.Targets)
 		c.Groups = append(c.Groups, g)
 	}
 
 	return &c, nil
 }
 
+// composeServiceEnabled returns true if the service has no profiles or one of
+// its profiles is active.
+func composeServiceEnabled(s composetypes.ServiceConfig, profiles []string) bool {
+	if len(s.Profiles) == 0 || slices.Contains(profiles, "*") {
+		return true
+	}
+	return slices.ContainsFunc(s.Profiles, func(p string) bool {
+		return slices.Contains(profiles, p)
+	})
+}
+
+// linkComposeDependencies adds a named context for the images of the services
+// a service depends on that have a build section, so they are built before the
+// service and resolved to the result of their build instead of being pulled.
+func linkComposeDependencies(cfg *composetypes.Project, targets []*Target) {
+	byName := make(map[string]*Target, len(targets))
+	for _, t := range targets {
+		byName[t.Name] = t
+	}
+	for _, s := range cfg.Services {
+		t, ok := byName[sanitizeTargetName(s.Name)]
+		if !ok {
+			continue
+		}
+		for _, dep := range slices.Sorted(maps.Keys(s.DependsOn)) {
+			dt, ok := byName[sanitizeTargetName(dep)]
+			if !ok || dt == t {
+				continue
+			}
+			for _, tag := range dt.Tags {
+				named, err := reference.ParseNormalizedNamed(tag)
+				if err != nil {
+					continue
+				}
+				// named contexts are matched by the familiar name of the image
+				name := strings.TrimSuffix(reference.FamiliarString(named), ":latest")
+				if _, ok := t.Contexts[name]; ok {
+					continue
+				}
+				if t.Contexts == nil {
+					t.Contexts = map[string]string{}
+				}
+				t.Contexts[name] = "target:" + dt.Name
+			}
+		}
+	}
+}
+
 func loadComposeFiles(cfgs []composetypes.ConfigFile, envs map[string]string, options ...func(*loader.Options)) (*composetypes.Project, error) {
 	if envs == nil {
 		envs = make(map[string]string)
@@ -279,7 +359,7 @@ func loadComposeFiles(cfgs []composetypes.ConfigFile, envs map[string]string, op
 						filteredServices[svcName] = map[string]any{}
 					} else if svcMap, ok := svc.(map[string]any); ok {
 						filteredService := make(map[string]any)
-						for _, svcField := range []string{"image", "build", "environment", "env_file"} {
+						for _, svcField := range []string{"image", "build", "environment", "env_file", "profiles", "depends_on"} {
 							if val, ok := svcMap[svcField]; ok {
 								filteredService[svcField] = val
 							}
@@ -421,6 +501,12 @@ type xbake struct {
 	NoCache       *bool       `yaml:"no-cache,omitempty"`
 	NoCacheFilter stringArray `yaml:"no-cache-filter,omitempty"`
 	Contexts      stringMap   `yaml:"contexts,omitempty"`
+	Attest        stringArray `yaml:"attest,omitempty"`
+	Annotations   stringArray `yaml:"annotations,omitempty"`
+	Call          string      `yaml:"call,omitempty"`
+	Entitlements  stringArray `yaml:"entitlements,omitempty"`
+	Policy        stringArray `yaml:"policy,omitempty"`
+	Resources     stringMap   `yaml:"resources,omitempty"`
 	// don't forget to update documentation if you add a new field:
 	// https://github.com/docker/docs/blob/main/content/build/bake/compose-file.md#extension-field-with-x-bake
 }
@@ -516,6 +602,39 @@ func (t *Target) composeExtTarget(exts map[string]any) error {
 	if len(xb.Contexts) > 0 {
 		t.Contexts = dedupMap(t.Contexts, composeToBuildkitNamedContexts(xb.Contexts))
 	}
+	if len(xb.Attest) > 0 {
+		attest, err := parseArrValue[buildflags.Attest](xb.Attest)
+		if err != nil {
+			return err
+		}
+		t.Attest = t.Attest.Merge(attest)
+	}
+	if len(xb.Annotations) > 0 {
+		t.Annotations = dedupSlice(append(t.Annotations, xb.Annotations...))
+	}
+	if xb.Call != "" {
+		t.Call = &xb.Call
+	}
+	if len(xb.Entitlements) > 0 {
+		t.Entitlements = dedupSlice(append(t.Entitlements, xb.Entitlements...))
+	}
+	if len(xb.Policy) > 0 {
+		policy, err := buildflags.ParsePolicyConfigs(xb.Policy)
+		if err != nil {
+			return err
+		}
+		t.Policy = append(t.Policy, policy...)
+	}
+	if len(xb.Resources) > 0 {
+		if t.Resources == nil {
+			t.Resources = &buildflags.ResourcesConfig{}
+		}
+		for _, k := range slices.Sorted(maps.Keys(xb.Resources)) {
+			if err := t.Resources.SetField(k, xb.Resources[k]); err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
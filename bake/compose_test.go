@@ -450,6 +450,123 @@ services:
 	require.Equal(t, []string{"default", "key=path/to/key"}, stringify(c.Targets[0].SSH))
 }
 
+func TestComposeExtBuildOptions(t *testing.T) {
+	dt := []byte(`
+services:
+  app:
+    build:
+      context: .
+      entitlements:
+        - network.host
+      privileged: true
+      x-bake:
+        attest:
+          - type=provenance,mode=max
+          - type=sbom
+        annotations: manifest:org.opencontainers.image.title=app
+        call: check
+        entitlements:
+          - network.host
+          - device=/dev/null
+        policy: filename=app.rego
+        resources:
+          memory: 2g
+          cpu-shares: 512
+`)
+
+	c, err := ParseCompose([]composetypes.ConfigFile{{Content: dt}}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(c.Targets))
+	app := c.Targets[0]
+	require.Equal(t, []string{"type=provenance,mode=max", "type=sbom"}, stringify(app.Attest))
+	require.Equal(t, []string{"manifest:org.opencontainers.image.title=app"}, app.Annotations)
+	require.Equal(t, ptrstr("check"), app.Call)
+	require.Equal(t, []string{"network.host", "security.insecure", "device=/dev/null"}, app.Entitlements)
+	require.Len(t, app.Policy, 1)
+	require.Equal(t, "app.rego", app.Policy[0].Files[0].Filename)
+	require.Equal(t, ptrstr("2g"), app.Resources.Memory)
+	require.Equal(t, int64(512), *app.Resources.CPUShares)
+
+	_, err = ParseCompose([]composetypes.ConfigFile{{Content: []byte(`
+services:
+  app:
+    build:
+      context: .
+      x-bake:
+        resources:
+          disk: 10g
+`)}}, nil)
+	require.ErrorContains(t, err, "unknown resources key disk")
+}
+
+func TestComposeProfiles(t *testing.T) {
+	dt := []byte(`
+services:
+  app:
+    build:
+      context: .
+  debug:
+    profiles: [debug]
+    build:
+      context: .
+  docs:
+    profiles: [docs, release]
+    build:
+      context: .
+  db:
+    profiles: [debug]
+    image: postgres
+`)
+
+	for _, tt := range []struct {
+		name     string
+		profiles []string
+		envs     map[string]string
+		expected []string
+	}{
+		{
+			name:     "none",
+			expected: []string{"app", "debug", "docs"},
+		},
+		{
+			name:     "profile",
+			profiles: []string{"release"},
+			expected: []string{"app", "docs"},
+		},
+		{
+			name:     "env",
+			envs:     map[string]string{"COMPOSE_PROFILES": "debug,docs"},
+			expected: []string{"app", "debug", "docs"},
+		},
+		{
+			name:     "override env",
+			profiles: []string{"debug"},
+			envs:     map[string]string{"COMPOSE_PROFILES": "docs"},
+			expected: []string{"app", "debug"},
+		},
+		{
+			name:     "all",
+			profiles: []string{"*"},
+			expected: []string{"app", "debug", "docs"},
+		},
+		{
+			name:     "unknown",
+			profiles: []string{"test"},
+			expected: []string{"app"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCompose([]composetypes.ConfigFile{{Content: dt}}, tt.envs, tt.profiles)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(c.Groups))
+			sort.Strings(c.Groups[0].Targets)
+			require.Equal(t, tt.expected, c.Groups[0].Targets)
+			// services of inactive profiles can still be built by name
+			require.Equal(t, 3, len(c.Targets))
+		})
+	}
+}
+
 func TestEnv(t *testing.T) {
 	envf, err := os.CreateTemp("", "env")
 	require.NoError(t, err)
@@ -817,6 +934,48 @@ services:
 	require.NoError(t, err)
 }
 
+func TestDependsOnContexts(t *testing.T) {
+	dt := []byte(`
+services:
+  app:
+    build:
+      context: .
+      additional_contexts:
+        org/tools: docker-image://org/tools:1.0
+    depends_on:
+      base:
+        condition: service_started
+      tools:
+        condition: service_started
+      db:
+        condition: service_healthy
+  base:
+    image: org/base:latest
+    build:
+      context: base
+      tags:
+        - docker.io/org/base:1.0
+  tools:
+    image: org/tools
+    build:
+      context: tools
+  db:
+    image: postgres
+`)
+	c, err := ParseCompose([]composetypes.ConfigFile{{Content: dt}}, nil)
+	require.NoError(t, err)
+	sort.Slice(c.Targets, func(i, j int) bool {
+		return c.Targets[i].Name < c.Targets[j].Name
+	})
+	require.Equal(t, "app", c.Targets[0].Name)
+	require.Equal(t, map[string]string{
+		"org/base:1.0": "target:base",
+		// additional contexts take precedence over dependencies
+		"org/tools": "docker-image://org/tools:1.0",
+	}, c.Targets[0].Contexts)
+	require.Empty(t, c.Targets[1].Contexts)
+}
+
 func TestInclude(t *testing.T) {
 	tmpdir := t.TempDir()
 
@@ -37,6 +37,8 @@ const (
 	EntitlementKeyImage             EntitlementKey = "image"
 	EntitlementKeySSH               EntitlementKey = "ssh"
 	EntitlementKeyBuildxLocalDelete EntitlementKey = EntitlementKey(buildflags.EntitlementBuildxLocalDelete)
+	EntitlementKeyBuildxSecretCmd   EntitlementKey = "buildx.secret.cmd"
+	EntitlementKeyBuildxSecretCred  EntitlementKey = "buildx.secret.credential"
 )
 
 type EntitlementConf struct {
@@ -49,6 +51,8 @@ type EntitlementConf struct {
 	ImageLoad         []string
 	SSH               bool
 	LocalOutputDelete bool
+	SecretCmd         bool
+	SecretCredential  bool
 }
 
 type EntitlementsDevicesConf struct {
@@ -68,6 +72,10 @@ func ParseEntitlements(in []string) (EntitlementConf, error) {
 			conf.SSH = true
 		case string(EntitlementKeyBuildxLocalDelete):
 			conf.LocalOutputDelete = true
+		case string(EntitlementKeyBuildxSecretCmd):
+			conf.SecretCmd = true
+		case string(EntitlementKeyBuildxSecretCred):
+			conf.SecretCredential = true
 		default:
 			k, v, _ := strings.Cut(e, "=")
 			switch k {
@@ -101,8 +109,8 @@ func ParseEntitlements(in []string) (EntitlementConf, error) {
 			case string(EntitlementKeyImage):
 				conf.ImagePush = append(conf.ImagePush, v)
 				conf.ImageLoad = append(conf.ImageLoad, v)
-			case string(EntitlementKeyBuildxLocalDelete):
-				return conf, errors.Errorf("%s does not accept a value", EntitlementKeyBuildxLocalDelete)
+			case string(EntitlementKeyBuildxLocalDelete), string(EntitlementKeyBuildxSecretCmd), string(EntitlementKeyBuildxSecretCred):
+				return conf, errors.Errorf("%s does not accept a value", k)
 			default:
 				return conf, errors.Errorf("unknown entitlement key %q", k)
 			}
@@ -203,6 +211,12 @@ func (c EntitlementConf) check(bo build.Options, expected *EntitlementConf) erro
 		if secret.FilePath != "" {
 			roPaths[secret.FilePath] = struct{}{}
 		}
+		if secret.Type == buildflags.SecretTypeCmd && !c.SecretCmd {
+			expected.SecretCmd = true
+		}
+		if secret.Type == buildflags.SecretTypeDockerCredential && !c.SecretCredential {
+			expected.SecretCredential = true
+		}
 	}
 
 	for _, ssh := range bo.SSHSpecs {
@@ -272,6 +286,14 @@ func (c EntitlementConf) Prompt(ctx context.Context, isRemote bool, out io.Write
 		msgs = append(msgs, " - Deleting stale files from local output destinations")
 		flags = append(flags, string(EntitlementKeyBuildxLocalDelete))
 	}
+	if c.SecretCmd {
+		msgs = append(msgs, " - Running local commands to provide secrets")
+		flags = append(flags, string(EntitlementKeyBuildxSecretCmd))
+	}
+	if c.SecretCredential {
+		msgs = append(msgs, " - Reading registry credentials to provide secrets")
+		flags = append(flags, string(EntitlementKeyBuildxSecretCred))
+	}
 
 	roPaths, rwPaths, commonPaths := groupSamePaths(c.FSRead, c.FSWrite)
 	wd, err := os.Getwd()
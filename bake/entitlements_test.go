@@ -441,6 +441,64 @@ func TestPromptLocalOutputDeleteCannotBeDisabledWithFSEntitlements(t *testing.T)
 	require.Contains(t, out.String(), "--allow=buildx.local.delete")
 }
 
+func TestParseEntitlementsSecretCmd(t *testing.T) {
+	conf, err := ParseEntitlements([]string{string(EntitlementKeyBuildxSecretCmd)})
+	require.NoError(t, err)
+	require.True(t, conf.SecretCmd)
+
+	_, err = ParseEntitlements([]string{string(EntitlementKeyBuildxSecretCmd) + "=true"})
+	require.ErrorContains(t, err, "buildx.secret.cmd does not accept a value")
+}
+
+func TestValidateEntitlementsSecretCmd(t *testing.T) {
+	bo := map[string]build.Options{
+		"app": {
+			Inputs: build.Inputs{
+				ContextState: &llb.State{},
+			},
+			SecretSpecs: []*buildflags.Secret{
+				{ID: "token", Type: buildflags.SecretTypeCmd, Cmd: "pass show token"},
+			},
+		},
+	}
+	expected, err := EntitlementConf{}.Validate(bo)
+	require.NoError(t, err)
+	require.Equal(t, EntitlementConf{SecretCmd: true}, expected)
+
+	expected, err = EntitlementConf{SecretCmd: true}.Validate(bo)
+	require.NoError(t, err)
+	require.Equal(t, EntitlementConf{}, expected)
+}
+
+func TestValidateEntitlementsSecretCredential(t *testing.T) {
+	conf, err := ParseEntitlements([]string{string(EntitlementKeyBuildxSecretCred)})
+	require.NoError(t, err)
+	require.True(t, conf.SecretCredential)
+
+	bo := map[string]build.Options{
+		"app": {
+			Inputs: build.Inputs{
+				ContextState: &llb.State{},
+			},
+			SecretSpecs: []*buildflags.Secret{
+				{ID: "token", Type: buildflags.SecretTypeDockerCredential, Host: "ghcr.io"},
+			},
+		},
+	}
+	expected, err := EntitlementConf{}.Validate(bo)
+	require.NoError(t, err)
+	require.Equal(t, EntitlementConf{SecretCredential: true}, expected)
+
+	// the cmd entitlement does not grant access to credentials
+	expected, err = EntitlementConf{SecretCmd: true}.Validate(bo)
+	require.NoError(t, err)
+	require.Equal(t, EntitlementConf{SecretCredential: true}, expected)
+
+	expected, err = conf.Validate(bo)
+	require.NoError(t, err)
+	require.Equal(t, EntitlementConf{}, expected)
+}
+
 func TestGroupSamePaths(t *testing.T) {
 	tests := []struct {
 		name      string
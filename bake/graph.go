@@ -0,0 +1,118 @@
+package bake
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	GraphNodeTarget = "target"
+	GraphNodeGroup  = "group"
+
+	GraphEdgeGroup    = "group"
+	GraphEdgeInherits = "inherits"
+	GraphEdgeContext  = "context"
+)
+
+// Graph describes the relations between the targets and groups of a bake
+// definition.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// GraphEdge links a group to its members, a target to the targets it
+// inherits from or a target to the targets used as named contexts.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+	// Context is the name of the build context for context edges
+	Context string `json:"context,omitempty"`
+}
+
+// Graph returns the dependency graph of the targets and groups defined in
+// the config. Edges are the ones declared in the definition, so targets
+// reached through inherits are not repeated for their children.
+func (c Config) Graph() *Graph {
+	g := &Graph{
+		Nodes: []GraphNode{},
+		Edges: []GraphEdge{},
+	}
+	for _, grp := range c.Groups {
+		g.Nodes = append(g.Nodes, GraphNode{Name: grp.Name, Type: GraphNodeGroup, Description: grp.Description})
+		for _, name := range grp.Targets {
+			g.Edges = append(g.Edges, GraphEdge{From: grp.Name, To: name, Type: GraphEdgeGroup})
+		}
+	}
+	for _, t := range c.Targets {
+		g.Nodes = append(g.Nodes, GraphNode{Name: t.Name, Type: GraphNodeTarget, Description: t.Description})
+		for _, name := range t.Inherits {
+			g.Edges = append(g.Edges, GraphEdge{From: t.Name, To: name, Type: GraphEdgeInherits})
+		}
+		for k, v := range t.Contexts {
+			if name, ok := strings.CutPrefix(v, "target:"); ok {
+				g.Edges = append(g.Edges, GraphEdge{From: t.Name, To: name, Type: GraphEdgeContext, Context: k})
+			}
+		}
+	}
+
+	slices.SortFunc(g.Nodes, func(a, b GraphNode) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Type, b.Type))
+	})
+	g.Nodes = slices.Compact(g.Nodes)
+	slices.SortFunc(g.Edges, func(a, b GraphEdge) int {
+		return cmp.Or(
+			cmp.Compare(a.From, b.From),
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(a.To, b.To),
+			cmp.Compare(a.Context, b.Context),
+		)
+	})
+	g.Edges = slices.Compact(g.Edges)
+	return g
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph bake {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + strconv.Quote(n.Name)}
+		if n.Type == GraphNodeGroup {
+			attrs = append(attrs, "shape=folder")
+		} else {
+			attrs = append(attrs, "shape=box")
+		}
+		if n.Description != "" {
+			attrs = append(attrs, "tooltip="+strconv.Quote(n.Description))
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", strconv.Quote(n.Name), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		switch e.Type {
+		case GraphEdgeGroup:
+			attrs = append(attrs, "style=dashed")
+		case GraphEdgeInherits:
+			attrs = append(attrs, "style=dotted", `label="inherits"`)
+		case GraphEdgeContext:
+			attrs = append(attrs, "label="+strconv.Quote(e.Context))
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), strings.Join(attrs, ", "))
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
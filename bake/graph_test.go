@@ -0,0 +1,89 @@
+package bake
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	t.Parallel()
+	dt := []byte(`
+		group "default" {
+			description = "Build everything"
+			targets = ["app", "docs"]
+		}
+
+		target "_common" {
+			args = {
+				GO_VERSION = "1.22"
+			}
+		}
+
+		target "base" {
+			inherits = ["_common"]
+		}
+
+		target "app" {
+			inherits = ["_common"]
+			contexts = {
+				base = "target:base"
+				src = "./src"
+			}
+		}
+
+		target "docs" {
+			description = "Build docs"
+			contexts = {
+				app = "target:app"
+			}
+		}
+	`)
+	c, err := ParseFile(dt, "docker-bake.hcl")
+	require.NoError(t, err)
+
+	g := c.Graph()
+	require.Equal(t, []GraphNode{
+		{Name: "_common", Type: GraphNodeTarget},
+		{Name: "app", Type: GraphNodeTarget},
+		{Name: "base", Type: GraphNodeTarget},
+		{Name: "default", Type: GraphNodeGroup, Description: "Build everything"},
+		{Name: "docs", Type: GraphNodeTarget, Description: "Build docs"},
+	}, g.Nodes)
+	require.Equal(t, []GraphEdge{
+		{From: "app", To: "base", Type: GraphEdgeContext, Context: "base"},
+		{From: "app", To: "_common", Type: GraphEdgeInherits},
+		{From: "base", To: "_common", Type: GraphEdgeInherits},
+		{From: "default", To: "app", Type: GraphEdgeGroup},
+		{From: "default", To: "docs", Type: GraphEdgeGroup},
+		{From: "docs", To: "app", Type: GraphEdgeContext, Context: "app"},
+	}, g.Edges)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteDOT(&buf))
+	require.Equal(t, `digraph bake {
+  rankdir=LR;
+  "_common" [label="_common", shape=box];
+  "app" [label="app", shape=box];
+  "base" [label="base", shape=box];
+  "default" [label="default", shape=folder, tooltip="Build everything"];
+  "docs" [label="docs", shape=box, tooltip="Build docs"];
+  "app" -> "base" [label="base"];
+  "app" -> "_common" [style=dotted, label="inherits"];
+  "base" -> "_common" [style=dotted, label="inherits"];
+  "default" -> "app" [style=dashed];
+  "default" -> "docs" [style=dashed];
+  "docs" -> "app" [label="app"];
+}
+`, buf.String())
+}
+
+func TestGraphEmpty(t *testing.T) {
+	t.Parallel()
+	g := Config{}.Graph()
+	require.Empty(t, g.Nodes)
+	require.Empty(t, g.Edges)
+	require.NotNil(t, g.Nodes)
+	require.NotNil(t, g.Edges)
+}
@@ -1412,6 +1412,139 @@ func TestHCLMatrixBadTypes(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestHCLMatrixExclude(t *testing.T) {
+	dt := []byte(`
+		target "default" {
+			matrix = {
+				base = ["alpine", "debian"]
+				platform = ["linux/amd64", "linux/arm/v6"]
+				exclude = [
+					{
+						base = "debian"
+						platform = "linux/arm/v6"
+					}
+				]
+			}
+			name = "${base}-${replace(platform, "/", "-")}"
+			platforms = [platform]
+		}
+		`)
+
+	c, err := ParseFile(dt, "docker-bake.hcl")
+	require.NoError(t, err)
+
+	names := make([]string, len(c.Targets))
+	for i, t := range c.Targets {
+		names[i] = t.Name
+	}
+	require.ElementsMatch(t, []string{"alpine-linux-amd64", "alpine-linux-arm-v6", "debian-linux-amd64"}, names)
+}
+
+func TestHCLMatrixInclude(t *testing.T) {
+	dt := []byte(`
+		target "default" {
+			matrix = {
+				base = ["alpine", "debian"]
+				variant = ["slim", "full"]
+				include = [
+					{
+						base = "alpine"
+						extra = "musl"
+					},
+					{
+						base = "ubuntu"
+						variant = "full"
+					}
+				]
+			}
+			name = "${base}-${variant}"
+			args = {
+				EXTRA = try(extra, "")
+			}
+		}
+		`)
+
+	c, err := ParseFile(dt, "docker-bake.hcl")
+	require.NoError(t, err)
+
+	extra := map[string]string{}
+	for _, t := range c.Targets {
+		extra[t.Name] = *t.Args["EXTRA"]
+	}
+	require.Equal(t, map[string]string{
+		"alpine-slim": "musl",
+		"alpine-full": "musl",
+		"debian-slim": "",
+		"debian-full": "",
+		"ubuntu-full": "",
+	}, extra)
+}
+
+func TestHCLMatrixEmptyRules(t *testing.T) {
+	dt := []byte(`
+		target "default" {
+			matrix = {
+				base = ["alpine", "debian"]
+				exclude = []
+				include = [{}]
+			}
+			name = base
+		}
+		`)
+
+	c, err := ParseFile(dt, "docker-bake.hcl")
+	require.NoError(t, err)
+
+	names := make([]string, len(c.Targets))
+	for i, t := range c.Targets {
+		names[i] = t.Name
+	}
+	require.ElementsMatch(t, []string{"alpine", "debian"}, names)
+}
+
+func TestHCLMatrixIncludeOnly(t *testing.T) {
+	dt := []byte(`
+		target "default" {
+			matrix = {
+				include = [
+					{ name = "a", platform = "linux/amd64" },
+					{ name = "b", platform = "linux/arm64" },
+				]
+			}
+			name = name
+			platforms = [platform]
+		}
+		`)
+
+	c, err := ParseFile(dt, "docker-bake.hcl")
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(c.Targets))
+	require.Equal(t, "a", c.Targets[0].Name)
+	require.Equal(t, []string{"linux/amd64"}, c.Targets[0].Platforms)
+	require.Equal(t, "b", c.Targets[1].Name)
+	require.Equal(t, []string{"linux/arm64"}, c.Targets[1].Platforms)
+}
+
+func TestHCLMatrixIncludeDimension(t *testing.T) {
+	// include and exclude are regular dimensions if their values aren't objects
+	dt := []byte(`
+		target "default" {
+			matrix = {
+				include = ["a", "b"]
+			}
+			name = include
+		}
+		`)
+
+	c, err := ParseFile(dt, "docker-bake.hcl")
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(c.Targets))
+	require.Equal(t, "a", c.Targets[0].Name)
+	require.Equal(t, "b", c.Targets[1].Name)
+}
+
 func TestHCLMatrixWithGlobalTarget(t *testing.T) {
 	dt := []byte(`
 		target "x" {
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"maps"
 	"math"
 	"math/big"
 	"reflect"
@@ -24,6 +25,15 @@ import (
 
 const jsonEnvOverrideSuffix = "_JSON"
 
+// SensitiveValue replaces sensitive values in the output of bake.
+const SensitiveValue = "(sensitive value)"
+
+type valueMark string
+
+// sensitiveMark marks the values of sensitive variables and the values
+// derived from them.
+const sensitiveMark = valueMark("sensitive")
+
 type Opt struct {
 	LookupVar     func(string) (string, bool)
 	Vars          map[string]string
@@ -35,6 +45,7 @@ type variable struct {
 	Type        hcl.Expression        `json:"type,omitempty" hcl:"type,optional"`
 	Default     *hcl.Attribute        `json:"default,omitempty" hcl:"default,optional"`
 	Description string                `json:"description,omitempty" hcl:"description,optional"`
+	Sensitive   bool                  `json:"sensitive,omitempty" hcl:"sensitive,optional"`
 	Validations []*variableValidation `json:"validation,omitempty" hcl:"validation,block"`
 	Body        hcl.Body              `json:"-" hcl:",body"`
 	Remain      hcl.Body              `json:"-" hcl:",remain"`
@@ -74,6 +85,7 @@ type parser struct {
 	blockEvalCtx map[*hcl.Block][]*hcl.EvalContext
 	blockNames   map[*hcl.Block][]string
 	blockTypes   map[string]reflect.Type
+	sensitiveB   map[uint64]map[string]struct{}
 
 	ectx *hcl.EvalContext
 
@@ -91,6 +103,12 @@ type WithGetName interface {
 	GetName(ectx *hcl.EvalContext, block *hcl.Block, loadDeps func(hcl.Expression) hcl.Diagnostics) (string, error)
 }
 
+// WithSensitiveAttributes is implemented by block types that track which
+// of their attributes contain sensitive values.
+type WithSensitiveAttributes interface {
+	SetSensitiveAttributes(names []string)
+}
+
 type WithBlockSource interface {
 	SetBlockSource(block *hcl.Block)
 }
@@ -276,6 +294,9 @@ func (p *parser) resolveValue(ectx *hcl.EvalContext, name string) (err error) {
 	var v *cty.Value
 	defer func() {
 		if v != nil {
+			if vr, ok := p.vars[name]; ok && vr.Sensitive {
+				*v = v.Mark(sensitiveMark)
+			}
 			p.ectx.Variables[name] = *v
 		}
 	}()
@@ -529,11 +550,26 @@ func (p *parser) resolveBlock(block *hcl.Block, target *hcl.BodySchema) (err err
 			}
 		}
 
+		// values are unmarked when decoded, so keep track of the attributes
+		// containing sensitive values
+		if _, ok := p.sensitiveB[key(block, ectx)]; !ok {
+			p.sensitiveB[key(block, ectx)] = map[string]struct{}{}
+		}
+		sensitive := p.sensitiveB[key(block, ectx)]
+		for _, a := range content.Attributes {
+			if v, diags := a.Expr.Value(ectx); !diags.HasErrors() && v.HasMarkDeep(sensitiveMark) {
+				sensitive[a.Name] = struct{}{}
+			}
+		}
+
 		// decode!
 		diag = decodeBody(body(), ectx, output.Interface())
 		if diag.HasErrors() {
 			return diag
 		}
+		if v, ok := output.Interface().(WithSensitiveAttributes); ok && len(sensitive) > 0 {
+			v.SetSensitiveAttributes(slices.Sorted(maps.Keys(sensitive)))
+		}
 
 		// mark all targeted properties as done
 		for _, a := range content.Attributes {
@@ -560,6 +596,15 @@ func (p *parser) resolveBlock(block *hcl.Block, target *hcl.BodySchema) (err err
 		if err != nil {
 			return err
 		}
+		if len(sensitive) > 0 && outputValue.Type().IsObjectType() && !outputValue.IsNull() {
+			attrs := outputValue.AsValueMap()
+			for name := range sensitive {
+				if v, ok := attrs[name]; ok {
+					attrs[name] = v.Mark(sensitiveMark)
+				}
+			}
+			outputValue = cty.ObjectVal(attrs)
+		}
 		var m map[string]cty.Value
 		if m2, ok := p.ectx.Variables[block.Type]; ok {
 			m = m2.AsValueMap()
@@ -649,6 +694,7 @@ func (p *parser) validateVariables(vars map[string]*variable, ectx *hcl.EvalCont
 				continue
 			}
 
+			resultVal, _ = resultVal.UnmarkDeep()
 			var err error
 			resultVal, err = convert.Convert(resultVal, cty.Bool)
 			if err != nil {
@@ -669,7 +715,10 @@ func (p *parser) validateVariables(vars map[string]*variable, ectx *hcl.EvalCont
 					continue
 				}
 				errorMessage := "This check failed, but has an invalid error message."
-				if !message.IsNull() {
+				switch {
+				case message.HasMarkDeep(sensitiveMark):
+					errorMessage = "The error message contains a sensitive value, so it is not displayed."
+				case !message.IsNull():
 					errorMessage = message.AsString()
 				}
 				diags = append(diags, &hcl.Diagnostic{
@@ -690,11 +739,15 @@ type Variable struct {
 	Description string  `json:"description,omitempty"`
 	Type        string  `json:"type,omitempty"`
 	Value       *string `json:"value,omitempty"`
+	Sensitive   bool    `json:"sensitive,omitempty"`
 }
 
 type ParseMeta struct {
 	Renamed      map[string]map[string][]string
 	AllVariables []*Variable
+	// SensitiveValues holds the values of the sensitive variables that
+	// can be converted to a string.
+	SensitiveValues map[string]string
 }
 
 func Parse(b hcl.Body, opt Opt, val any) (*ParseMeta, hcl.Diagnostics) {
@@ -738,6 +791,7 @@ func Parse(b hcl.Body, opt Opt, val any) (*ParseMeta, hcl.Diagnostics) {
 		blockEvalCtx: map[*hcl.Block][]*hcl.EvalContext{},
 		blockNames:   map[*hcl.Block][]string{},
 		blockTypes:   map[string]reflect.Type{},
+		sensitiveB:   map[uint64]map[string]struct{}{},
 		ectx: &hcl.EvalContext{
 			Variables: map[string]cty.Value{},
 			Functions: Stdlib(),
@@ -806,6 +860,7 @@ func Parse(b hcl.Body, opt Opt, val any) (*ParseMeta, hcl.Diagnostics) {
 	}
 
 	vars := make([]*Variable, 0, len(p.vars))
+	sensitiveValues := map[string]string{}
 	for k := range p.vars {
 		if err := p.resolveValue(p.ectx, k); err != nil {
 			if diags, ok := err.(hcl.Diagnostics); ok {
@@ -822,7 +877,16 @@ func Parse(b hcl.Body, opt Opt, val any) (*ParseMeta, hcl.Diagnostics) {
 		if tc != nil {
 			v.Type = tc.FriendlyNameForConstraint()
 		}
-		if vv := p.ectx.Variables[k]; !vv.IsNull() {
+		if vv := p.ectx.Variables[k]; vv.HasMarkDeep(sensitiveMark) {
+			v.Sensitive = true
+			s := SensitiveValue
+			v.Value = &s
+			if vv, _ := vv.UnmarkDeep(); !vv.IsNull() && vv.IsWhollyKnown() {
+				if sv, err := convert.Convert(vv, cty.String); err == nil {
+					sensitiveValues[k] = sv.AsString()
+				}
+			}
+		} else if !vv.IsNull() {
 			var s string
 			switch {
 			case tc != nil:
@@ -997,8 +1061,9 @@ func Parse(b hcl.Body, opt Opt, val any) (*ParseMeta, hcl.Diagnostics) {
 	}
 
 	return &ParseMeta{
-		Renamed:      renamed,
-		AllVariables: vars,
+		Renamed:         renamed,
+		AllVariables:    vars,
+		SensitiveValues: sensitiveValues,
 	}, nil
 }
 
@@ -1247,6 +1312,13 @@ func key(ks ...any) uint64 {
 }
 
 func decodeBody(body hcl.Body, ctx *hcl.EvalContext, val any) hcl.Diagnostics {
-	dec := gohcl.DecodeOptions{ImpliedType: ImpliedType}
+	dec := gohcl.DecodeOptions{
+		ImpliedType: ImpliedType,
+		Convert: func(in cty.Value, want cty.Type) (cty.Value, error) {
+			// sensitive attributes are tracked by resolveBlock
+			in, _ = in.UnmarkDeep()
+			return convert.Convert(in, want)
+		},
+	}
 	return dec.DecodeBody(body, ctx, val)
 }
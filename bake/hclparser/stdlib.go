@@ -93,6 +93,7 @@ var stdlibFunctions = []funcDef{
 	{name: "modulo", fn: stdlib.ModuloFunc},
 	{name: "multiply", fn: stdlib.MultiplyFunc},
 	{name: "negate", fn: stdlib.NegateFunc},
+	{name: "nonsensitive", factory: nonsensitiveFunc},
 	{name: "not", fn: stdlib.NotFunc},
 	{name: "notequal", fn: stdlib.NotEqualFunc},
 	{name: "or", fn: stdlib.OrFunc},
@@ -108,6 +109,7 @@ var stdlibFunctions = []funcDef{
 	{name: "rsadecrypt", fn: crypto.RsaDecryptFunc, descriptionAlt: `Decrypts an RSA-encrypted ciphertext.`},
 	{name: "sanitize", factory: sanitizeFunc},
 	{name: "semvercmp", factory: semvercmpFunc},
+	{name: "sensitive", factory: sensitiveFunc},
 	{name: "sethaselement", fn: stdlib.SetHasElementFunc},
 	{name: "setintersection", fn: stdlib.SetIntersectionFunc},
 	{name: "setproduct", fn: stdlib.SetProductFunc},
@@ -252,6 +254,54 @@ func sanitizeFunc() function.Function {
 	})
 }
 
+// sensitiveFunc constructs a function that marks a value as sensitive.
+func sensitiveFunc() function.Function {
+	return function.New(&function.Spec{
+		Description: `Marks a value as sensitive, so that it is redacted from the output of Bake.`,
+		Params: []function.Parameter{
+			{
+				Name:             "value",
+				Type:             cty.DynamicPseudoType,
+				AllowDynamicType: true,
+				AllowMarked:      true,
+				AllowNull:        true,
+				AllowUnknown:     true,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return args[0].Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return args[0].Mark(sensitiveMark), nil
+		},
+	})
+}
+
+// nonsensitiveFunc constructs a function that removes the sensitive mark
+// of a value.
+func nonsensitiveFunc() function.Function {
+	return function.New(&function.Spec{
+		Description: `Removes the sensitive mark of a value, so that it can be used in build arguments and labels.`,
+		Params: []function.Parameter{
+			{
+				Name:             "value",
+				Type:             cty.DynamicPseudoType,
+				AllowDynamicType: true,
+				AllowMarked:      true,
+				AllowNull:        true,
+				AllowUnknown:     true,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return args[0].Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			v, _ := args[0].UnmarkDeep()
+			return v, nil
+		},
+	})
+}
+
 // semvercmpFunc constructs a function that checks if a version satisfies a
 // constraint.
 func semvercmpFunc() function.Function {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
 )
 
 func TestIndexOf(t *testing.T) {
@@ -259,6 +260,22 @@ func TestSemverCmp(t *testing.T) {
 	}
 }
 
+func TestSensitive(t *testing.T) {
+	v, err := sensitiveFunc().Call([]cty.Value{cty.StringVal("s3cr3t")})
+	require.NoError(t, err)
+	require.True(t, v.HasMark(sensitiveMark))
+
+	// marks are propagated through other functions
+	v, err = stdlib.UpperFunc.Call([]cty.Value{v})
+	require.NoError(t, err)
+	require.True(t, v.HasMark(sensitiveMark))
+
+	v, err = nonsensitiveFunc().Call([]cty.Value{cty.ListVal([]cty.Value{v})})
+	require.NoError(t, err)
+	require.False(t, v.ContainsMarked())
+	require.Equal(t, cty.ListVal([]cty.Value{cty.StringVal("S3CR3T")}), v)
+}
+
 func TestUnixTimestampParseFunc(t *testing.T) {
 	type testCase struct {
 		input   cty.Value
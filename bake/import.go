@@ -0,0 +1,241 @@
+package bake
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/docker/buildx/bake/hclparser"
+	"github.com/docker/buildx/util/urlutil"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+)
+
+// Import loads another bake definition into a namespace. The targets and
+// groups of the imported definition are named "<namespace>.<name>".
+type Import struct {
+	Name      string            `json:"-" hcl:"name,label" cty:"name"`
+	Source    string            `json:"source" hcl:"source" cty:"source"`
+	Variables map[string]string `json:"variables,omitempty" hcl:"variables,optional" cty:"variables"`
+
+	// dir is the directory of the file declaring the import, local sources
+	// are relative to it
+	dir string
+}
+
+func (imp *Import) SetBlockSource(block *hcl.Block) {
+	imp.dir, _ = localFileDir(block.DefRange.Filename)
+}
+
+// importState is the state of the parsing of a definition that is passed
+// to the definitions it imports.
+type importState struct {
+	// lookupEnv allows setting variables from the environment, which is
+	// only done for the top-level definition
+	lookupEnv bool
+	// remote is set when parsing a remote definition
+	remote bool
+	// stack is the list of import sources being parsed
+	stack []string
+}
+
+func (c *Config) loadImports(defaults map[string]string, st importState, opts []ParseOpt) error {
+	for _, imp := range c.Imports {
+		ic, err := imp.load(defaults, st, opts)
+		if err != nil {
+			return errors.Wrapf(err, "failed to import %s", imp.Name)
+		}
+		ic.namespace(imp.Name)
+		*c = mergeConfig(*c, *ic)
+	}
+	return nil
+}
+
+func (imp *Import) load(defaults map[string]string, st importState, opts []ParseOpt) (*Config, error) {
+	if imp.Source == "" {
+		return nil, errors.New("source is required")
+	}
+
+	var key string
+	var files []File
+	var inp *Input
+	if urlutil.IsRemoteURL(imp.Source) {
+		readImport := importReader(opts)
+		if readImport == nil {
+			return nil, errors.Errorf("remote source %s is not supported", imp.Source)
+		}
+		key = imp.Source
+		if slices.Contains(st.stack, key) {
+			return nil, errors.Errorf("import cycle detected for %s", imp.Source)
+		}
+		var err error
+		if files, inp, err = readImport(imp.Source); err != nil {
+			return nil, err
+		}
+		st.remote = true
+	} else {
+		if st.remote {
+			return nil, errors.Errorf("local source %s can't be imported by a remote definition", imp.Source)
+		}
+		p := imp.Source
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(imp.dir, p)
+		}
+		var err error
+		if key, err = filepath.Abs(p); err != nil {
+			return nil, err
+		}
+		if slices.Contains(st.stack, key) {
+			return nil, errors.Errorf("import cycle detected for %s", imp.Source)
+		}
+		if files, err = readImportFiles(p); err != nil {
+			return nil, err
+		}
+		for _, opt := range opts {
+			if opt.OnImport != nil {
+				opt.OnImport(files)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("couldn't find a bake definition in %s", imp.Source)
+	}
+
+	st.lookupEnv = false
+	st.stack = append(slices.Clone(st.stack), key)
+	c, pm, err := parseFiles(files, defaults, imp.Variables, st, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for k := range imp.Variables {
+		if !slices.ContainsFunc(pm.AllVariables, func(v *hclparser.Variable) bool { return v.Name == k }) {
+			return nil, errors.Errorf("variable %s is not defined in %s", k, imp.Source)
+		}
+	}
+	if inp != nil {
+		// contexts of remote targets are relative to their source, targets
+		// of nested imports keep the input of their own source
+		for _, t := range c.Targets {
+			if t.input == nil {
+				t.input = inp
+			}
+		}
+	}
+	return c, nil
+}
+
+// readImportFiles reads a local import source, which is either a bake file
+// or a directory containing the default bake files.
+func readImportFiles(p string) ([]File, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		dt, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return []File{{Name: p, Data: dt}}, nil
+	}
+	var files []File
+	for _, name := range defaultFilenames() {
+		fn := filepath.Join(p, name)
+		dt, err := os.ReadFile(fn)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		files = append(files, File{Name: fn, Data: dt})
+	}
+	return files, nil
+}
+
+func importReader(opts []ParseOpt) func(string) ([]File, *Input, error) {
+	for _, opt := range opts {
+		if opt.ReadImport != nil {
+			return opt.ReadImport
+		}
+	}
+	return nil
+}
+
+// namespace prefixes the names of the targets and groups of an imported
+// definition, and the references to them, with the import name.
+func (c *Config) namespace(ns string) {
+	names := map[string]struct{}{}
+	for _, t := range c.Targets {
+		names[t.Name] = struct{}{}
+	}
+	for _, g := range c.Groups {
+		names[g.Name] = struct{}{}
+	}
+	prefix := func(name string) string {
+		if _, ok := names[name]; ok {
+			return ns + "." + name
+		}
+		return name
+	}
+
+	for _, t := range c.Targets {
+		t.Name = prefix(t.Name)
+		for i, name := range t.Inherits {
+			t.Inherits[i] = prefix(name)
+		}
+		for k, v := range t.Contexts {
+			if name, ok := strings.CutPrefix(v, "target:"); ok {
+				t.Contexts[k] = "target:" + prefix(name)
+			}
+		}
+	}
+	for _, g := range c.Groups {
+		g.Name = prefix(g.Name)
+		for i, name := range g.Targets {
+			g.Targets[i] = prefix(name)
+		}
+	}
+}
+
+// hasNamespace reports whether the config contains targets or groups
+// imported in the namespace ns.
+func (c Config) hasNamespace(ns string) bool {
+	prefix := ns + "."
+	return slices.ContainsFunc(c.Targets, func(t *Target) bool {
+		return strings.HasPrefix(t.Name, prefix)
+	}) || slices.ContainsFunc(c.Groups, func(g *Group) bool {
+		return strings.HasPrefix(g.Name, prefix)
+	})
+}
+
+// splitOverrideKey splits an override key into the target name, the field
+// and its optional key. The target name of an imported target contains its
+// namespaces.
+func (c Config) splitOverrideKey(key string) []string {
+	name, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return []string{key}
+	}
+	for c.hasNamespace(name) {
+		next, r, ok := strings.Cut(rest, ".")
+		if !ok {
+			break
+		}
+		candidate := name + "." + next
+		if !c.hasName(candidate) && !c.hasNamespace(candidate) && !strings.ContainsAny(next, "*?[]") {
+			break
+		}
+		name, rest = candidate, r
+	}
+	return append([]string{name}, strings.SplitN(rest, ".", 2)...)
+}
+
+func (c Config) hasName(name string) bool {
+	return slices.ContainsFunc(c.Targets, func(t *Target) bool {
+		return t.Name == name
+	}) || slices.ContainsFunc(c.Groups, func(g *Group) bool {
+		return g.Name == name
+	})
+}
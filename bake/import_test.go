@@ -0,0 +1,236 @@
+package bake
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/stretchr/testify/require"
+)
+
+func writeImportFile(t *testing.T, dir, name, dt string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(dt), 0o644))
+}
+
+func TestImport(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeImportFile(t, "common", "docker-bake.hcl", `
+variable "TAG" {
+  default = "latest"
+}
+group "default" {
+  targets = ["base"]
+}
+target "base" {
+  platforms = ["linux/amd64", "linux/arm64"]
+  args = {
+    TAG = TAG
+  }
+}
+target "tools" {
+  inherits = ["base"]
+  dockerfile = "tools.Dockerfile"
+}
+`)
+	fp := File{
+		Name: "docker-bake.hcl",
+		Data: []byte(`
+variable "VERSION" {
+  default = "v1"
+}
+import "common" {
+  source = "./common"
+  variables = {
+    TAG = VERSION
+  }
+}
+target "app" {
+  inherits = ["common.base"]
+  contexts = {
+    tools = "target:common.tools"
+  }
+}
+`),
+	}
+
+	c, _, err := ParseFiles([]File{fp}, nil, nil)
+	require.NoError(t, err)
+	require.True(t, c.hasName("common.base"))
+	require.True(t, c.hasName("common.tools"))
+	require.True(t, c.hasName("common.default"))
+
+	m, g, err := ReadTargets(t.Context(), []File{fp}, []string{"app"}, []string{"common.base.args.EXTRA=1"}, nil, nil, &EntitlementConf{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"app"}, g["default"].Targets)
+	require.Len(t, m, 2)
+	require.Equal(t, []string{"linux/amd64", "linux/arm64"}, m["app"].Platforms)
+	require.Equal(t, "v1", *m["app"].Args["TAG"])
+	require.Equal(t, "1", *m["app"].Args["EXTRA"])
+	require.Equal(t, "target:common.tools", m["app"].Contexts["tools"])
+	require.Equal(t, "tools.Dockerfile", *m["common.tools"].Dockerfile)
+	require.Equal(t, "v1", *m["common.tools"].Args["TAG"])
+
+	m, _, err = ReadTargets(t.Context(), []File{fp}, []string{"common.default"}, []string{"common.*.platform=linux/amd64"}, nil, nil, &EntitlementConf{})
+	require.NoError(t, err)
+	require.Len(t, m, 1)
+	require.Equal(t, []string{"linux/amd64"}, m["common.base"].Platforms)
+}
+
+func TestImportNested(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeImportFile(t, "lib", "base.hcl", `
+target "base" {
+  labels = {
+    "org.opencontainers.image.vendor" = "acme"
+  }
+}
+`)
+	writeImportFile(t, "common", "docker-bake.hcl", `
+import "lib" {
+  source = "../lib/base.hcl"
+}
+target "base" {
+  inherits = ["lib.base"]
+}
+`)
+	fp := File{
+		Name: "docker-bake.hcl",
+		Data: []byte(`
+import "common" {
+  source = "common"
+}
+target "app" {
+  inherits = ["common.base"]
+}
+`),
+	}
+
+	var imported []string
+	m, _, err := ReadTargets(t.Context(), []File{fp}, []string{"app", "common.lib.base"}, nil, nil, nil, &EntitlementConf{}, ParseOpt{
+		OnImport: func(files []File) {
+			for _, f := range files {
+				imported = append(imported, filepath.ToSlash(f.Name))
+			}
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "acme", *m["app"].Labels["org.opencontainers.image.vendor"])
+	require.Contains(t, m, "common.lib.base")
+	require.Contains(t, imported, "common/docker-bake.hcl")
+	require.Contains(t, imported, "lib/base.hcl")
+}
+
+func TestImportErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeImportFile(t, "common", "docker-bake.hcl", `
+target "base" {}
+`)
+	writeImportFile(t, "cycle", "docker-bake.hcl", `
+import "self" {
+  source = "."
+}
+`)
+
+	tests := []struct {
+		name   string
+		dt     string
+		errMsg string
+	}{
+		{
+			name: "undefined variable",
+			dt: `
+import "common" {
+  source = "./common"
+  variables = {
+    FOO = "bar"
+  }
+}`,
+			errMsg: "variable FOO is not defined in ./common",
+		},
+		{
+			name: "missing source",
+			dt: `
+import "common" {
+  source = "./missing"
+}`,
+			errMsg: "failed to import common",
+		},
+		{
+			name: "remote source",
+			dt: `
+import "common" {
+  source = "https://github.com/docker/buildx.git"
+}`,
+			errMsg: "remote source https://github.com/docker/buildx.git is not supported",
+		},
+		{
+			name: "cycle",
+			dt: `
+import "cycle" {
+  source = "./cycle"
+}`,
+			errMsg: "import cycle detected for .",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseFiles([]File{{Name: "docker-bake.hcl", Data: []byte(tt.dt)}}, nil, nil)
+			require.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestImportRemote(t *testing.T) {
+	var sources []string
+	st := llb.Scratch()
+	opt := ParseOpt{
+		ReadImport: func(source string) ([]File, *Input, error) {
+			sources = append(sources, source)
+			return []File{{
+				Name: "docker-bake.hcl",
+				Data: []byte(`
+target "base" {
+  context = "src"
+  args = {
+    FOO = "bar"
+  }
+}
+`),
+			}}, &Input{State: &st, URL: source}, nil
+		},
+	}
+	fp := File{
+		Name: "docker-bake.hcl",
+		Data: []byte(`
+import "common" {
+  source = "https://github.com/acme/bake.git#main"
+}
+target "app" {
+  inherits = ["common.base"]
+}
+target "local" {
+  inherits = ["common.base"]
+  context = "local"
+}
+`),
+	}
+
+	m, _, err := ReadTargets(t.Context(), []File{fp}, []string{"app", "local"}, nil, nil, nil, &EntitlementConf{}, opt)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://github.com/acme/bake.git#main"}, sources)
+	require.Equal(t, "bar", *m["app"].Args["FOO"])
+
+	bo, err := TargetsToBuildOpt(m, nil)
+	require.NoError(t, err)
+	// the context of the imported target is relative to its remote source
+	require.NotNil(t, bo["app"].Inputs.ContextState)
+	require.Equal(t, "https://github.com/acme/bake.git#main:src", bo["app"].Inputs.ContextPath)
+	require.Equal(t, "Dockerfile", bo["app"].Inputs.DockerfilePath)
+	// a local context overriding the imported one is relative to the working
+	// directory
+	require.Nil(t, bo["local"].Inputs.ContextState)
+	require.Equal(t, "local", bo["local"].Inputs.ContextPath)
+}
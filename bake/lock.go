@@ -0,0 +1,536 @@
+package bake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/docker/buildx/build"
+	"github.com/docker/buildx/util/urlutil"
+	"github.com/moby/buildkit/frontend/dockerfile/instructions"
+	"github.com/moby/buildkit/frontend/dockerfile/linter"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/moby/buildkit/frontend/dockerfile/shell"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// LockFilename is the name of the lockfile written by bake --lock.
+const LockFilename = "docker-bake.lock"
+
+const lockVersion = 1
+
+// Lock pins the remote definitions and images used by bake.
+type Lock struct {
+	Version int `json:"version"`
+	// Definitions maps the URL of a remote definition or import source to
+	// the commit of a Git repository or the digest of an HTTP resource.
+	Definitions map[string]string `json:"definitions,omitempty"`
+	// Images maps an image reference to the digest of its manifest or
+	// index. It contains the images of docker-image:// named contexts, and
+	// the frontend and base images of Dockerfiles.
+	Images map[string]string `json:"images,omitempty"`
+}
+
+// NewLock returns an empty lock.
+func NewLock() *Lock {
+	return &Lock{Version: lockVersion}
+}
+
+// LoadLock reads the lockfile fn to update it, or returns an empty lock if
+// it doesn't exist yet.
+func LoadLock(fn string) (*Lock, error) {
+	if _, err := os.Stat(fn); err != nil {
+		if os.IsNotExist(err) {
+			return NewLock(), nil
+		}
+		return nil, err
+	}
+	return ReadLock(fn)
+}
+
+// ReadLock reads the lockfile fn.
+func ReadLock(fn string) (*Lock, error) {
+	dt, err := os.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("lockfile %s not found, run bake with --lock to create it", fn)
+		}
+		return nil, err
+	}
+	var l Lock
+	dec := json.NewDecoder(bytes.NewReader(dt))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&l); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse lockfile %s", fn)
+	}
+	if l.Version != lockVersion {
+		return nil, errors.Errorf("unsupported lockfile version %d in %s", l.Version, fn)
+	}
+	return &l, nil
+}
+
+// Write writes the lock to fn.
+func (l *Lock) Write(fn string) error {
+	dt, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fn, append(dt, '\n'), 0o644)
+}
+
+// SetDefinition records the resolved checksum of a remote definition.
+func (l *Lock) SetDefinition(url, checksum string) {
+	if l.Definitions == nil {
+		l.Definitions = map[string]string{}
+	}
+	l.Definitions[url] = checksum
+}
+
+// Definition returns the locked checksum of a remote definition.
+func (l *Lock) Definition(url string) (string, error) {
+	checksum, ok := l.Definitions[url]
+	if !ok {
+		return "", errors.Errorf("remote definition %s is not locked, run bake with --lock to update %s", url, LockFilename)
+	}
+	return checksum, nil
+}
+
+// ImageResolver resolves an image reference to its descriptor.
+type ImageResolver interface {
+	Resolve(ctx context.Context, in string) (string, ocispecs.Descriptor, error)
+}
+
+// ResolveImages resolves the images used by the build options and records
+// their digests. Images already in the lock that are not used by the build
+// options are kept. Dockerfiles that are not available locally are read
+// from dockerfiles, see ReadRemoteDockerfiles.
+func (l *Lock) ResolveImages(ctx context.Context, bo map[string]build.Options, dockerfiles map[string][]byte, r ImageResolver) error {
+	refs := map[string]struct{}{}
+	for _, k := range slices.Sorted(maps.Keys(bo)) {
+		images, err := lockImages(bo[k], dockerfiles[k])
+		if err != nil {
+			return errors.Wrapf(err, "target %s", k)
+		}
+		for _, img := range images {
+			refs[img.ref.String()] = struct{}{}
+		}
+	}
+	if l.Images == nil {
+		l.Images = map[string]string{}
+	}
+	for _, ref := range slices.Sorted(maps.Keys(refs)) {
+		_, desc, err := r.Resolve(ctx, ref)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve image %s", ref)
+		}
+		l.Images[ref] = desc.Digest.String()
+	}
+	return nil
+}
+
+// Pin rewrites the docker-image:// named contexts of the build options and
+// adds named contexts for the base images of local Dockerfiles so that the
+// locked digests are used. The frontend image of a Dockerfile is pinned with
+// the BUILDKIT_SYNTAX build argument. Images that are not in the lock are an error.
+// Dockerfiles that are not available locally are read from dockerfiles.
+func (l *Lock) Pin(bo map[string]build.Options, dockerfiles map[string][]byte) error {
+	for k, opt := range bo {
+		images, err := lockImages(opt, dockerfiles[k])
+		if err != nil {
+			return errors.Wrapf(err, "target %s", k)
+		}
+		if len(images) == 0 {
+			continue
+		}
+		namedContexts := maps.Clone(opt.Inputs.NamedContexts)
+		if namedContexts == nil {
+			namedContexts = map[string]build.NamedContext{}
+		}
+		buildArgs := opt.BuildArgs
+		for _, img := range images {
+			dgst, ok := l.Images[img.ref.String()]
+			if !ok {
+				return errors.Errorf("image %s of target %s is not locked, run bake with --lock to update %s", img.ref, k, LockFilename)
+			}
+			d, err := digest.Parse(dgst)
+			if err != nil {
+				return errors.Wrapf(err, "invalid digest for image %s in %s", img.ref, LockFilename)
+			}
+			pinned, err := reference.WithDigest(img.ref, d)
+			if err != nil {
+				return err
+			}
+			if img.frontend {
+				buildArgs = maps.Clone(buildArgs)
+				if buildArgs == nil {
+					buildArgs = map[string]string{}
+				}
+				buildArgs["BUILDKIT_SYNTAX"] = strings.TrimSpace(pinned.String() + " " + img.args)
+				continue
+			}
+			namedContexts[img.name] = build.NamedContext{Path: "docker-image://" + pinned.String()}
+		}
+		opt.Inputs.NamedContexts = namedContexts
+		opt.BuildArgs = buildArgs
+		bo[k] = opt
+	}
+	return nil
+}
+
+// lockImage is an image used by a build, with the name of the named context
+// that selects it. The frontend image of a Dockerfile is selected by the
+// BUILDKIT_SYNTAX build argument instead, followed by its arguments.
+type lockImage struct {
+	name     string
+	ref      reference.NamedTagged
+	frontend bool
+	args     string
+}
+
+// lockImages returns the images of the docker-image:// named contexts, the
+// frontend image and the base images of the Dockerfile of a build that are
+// not pinned to a digest. The Dockerfile is read from dt if it's not available locally.
+func lockImages(opt build.Options, dt []byte) ([]lockImage, error) {
+	var images []lockImage
+	for _, name := range slices.Sorted(maps.Keys(opt.Inputs.NamedContexts)) {
+		v, ok := strings.CutPrefix(opt.Inputs.NamedContexts[name].Path, "docker-image://")
+		if !ok {
+			continue
+		}
+		ref, ok, err := lockImageRef(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "named context %s", name)
+		}
+		if ok {
+			images = append(images, lockImage{name: name, ref: ref})
+		}
+	}
+
+	if dt == nil {
+		var err error
+		if dt, err = readDockerfile(opt.Inputs); err != nil {
+			return nil, err
+		}
+	}
+	frontend, err := frontendImage(opt.BuildArgs, dt)
+	if err != nil {
+		return nil, err
+	}
+	if frontend != nil {
+		images = append(images, *frontend)
+	}
+	if dt == nil {
+		return images, nil
+	}
+	bases, err := dockerfileBaseImages(dt, opt.Target, opt.BuildArgs, opt.Platforms)
+	if err != nil {
+		return nil, err
+	}
+	for _, base := range bases {
+		ref, ok, err := lockImageRef(base)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		// the frontend looks up named contexts by familiar name
+		name := strings.TrimSuffix(reference.FamiliarString(ref), ":latest")
+		if _, ok := opt.Inputs.NamedContexts[name]; ok {
+			continue
+		}
+		if slices.ContainsFunc(images, func(img lockImage) bool { return !img.frontend && img.name == name }) {
+			continue
+		}
+		images = append(images, lockImage{name: name, ref: ref})
+	}
+	return images, nil
+}
+
+// frontendImage returns the frontend image of a build, set by the
+// BUILDKIT_SYNTAX build argument or by the syntax directive of the
+// Dockerfile dt.
+func frontendImage(buildArgs map[string]string, dt []byte) (*lockImage, error) {
+	cmdline, ok := buildArgs["BUILDKIT_SYNTAX"]
+	if !ok {
+		if _, cmdline, _, ok = parser.DetectSyntax(dt); !ok {
+			return nil, nil
+		}
+	}
+	src, args, _ := strings.Cut(strings.TrimSpace(cmdline), " ")
+	if src == "" {
+		// an empty BUILDKIT_SYNTAX build argument is reported by the build
+		return nil, nil
+	}
+	ref, ok, err := lockImageRef(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "frontend")
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &lockImage{ref: ref, frontend: true, args: args}, nil
+}
+
+// lockImageRef parses an image reference that can be locked. References
+// that are already pinned to a digest can't.
+func lockImageRef(s string) (reference.NamedTagged, bool, error) {
+	named, err := reference.ParseNormalizedNamed(s)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "cannot lock image %q", s)
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return nil, false, nil
+	}
+	tagged, ok := reference.TagNameOnly(named).(reference.NamedTagged)
+	return tagged, ok, nil
+}
+
+// isRemoteDockerfile reports whether the Dockerfile of a build is not
+// available locally and has to be read with ReadRemoteDockerfiles.
+func isRemoteDockerfile(inp build.Inputs) bool {
+	if inp.DockerfileInline != "" {
+		return false
+	}
+	if urlutil.IsRemoteURL(inp.DockerfilePath) {
+		return true
+	}
+	if filepath.IsAbs(inp.DockerfilePath) {
+		return false
+	}
+	return inp.ContextState != nil || inp.ContextPath == "-" || inp.DockerfilePath == "-" || urlutil.IsRemoteURL(inp.ContextPath)
+}
+
+// readDockerfile returns the local Dockerfile of a build. Remote Dockerfiles
+// can't be read and are an error, so their base images are never left
+// unpinned.
+func readDockerfile(inp build.Inputs) ([]byte, error) {
+	if inp.DockerfileInline != "" {
+		return []byte(inp.DockerfileInline), nil
+	}
+	if isRemoteDockerfile(inp) {
+		return nil, errors.Errorf("cannot lock the base images of Dockerfile %s: not available locally", inp.DockerfilePath)
+	}
+	dt, err := os.ReadFile(inp.DockerfilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return dt, nil
+}
+
+// dockerfileBaseImages returns the images used by the stages a target stage
+// of a Dockerfile depends on, in FROM instructions, COPY --from flags and
+// RUN --mount from options, for each of the target platforms. The
+// Dockerfile is parsed into instructions by the frontend and the build
+// arguments of the stages are expanded like it does, with the platform of
+// the client as build platform. References to stages and scratch are
+// skipped. The last stage is the target if target is empty.
+func dockerfileBaseImages(dt []byte, target string, buildArgs map[string]string, plats []ocispecs.Platform) ([]string, error) {
+	res, err := parser.Parse(bytes.NewReader(dt))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse dockerfile")
+	}
+	stages, metaArgs, err := instructions.Parse(res.AST, linter.New(&linter.Config{}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse dockerfile")
+	}
+	if len(stages) == 0 {
+		return nil, nil
+	}
+	lex := shell.NewLex(res.EscapeToken)
+
+	if len(plats) == 0 {
+		plats = []ocispecs.Platform{platforms.DefaultSpec()}
+	}
+	var images []string
+	for _, p := range plats {
+		imgs, err := stageBaseImages(stages, metaArgs, lex, target, buildArgs, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range imgs {
+			if !slices.Contains(images, img) {
+				images = append(images, img)
+			}
+		}
+	}
+	return images, nil
+}
+
+func stageBaseImages(stages []instructions.Stage, metaArgs []instructions.ArgCommand, lex *shell.Lex, target string, buildArgs map[string]string, p ocispecs.Platform) ([]string, error) {
+	bp := platforms.Normalize(platforms.DefaultSpec())
+	p = platforms.Normalize(p)
+	global := []string{
+		"BUILDPLATFORM=" + platforms.Format(bp),
+		"BUILDOS=" + bp.OS,
+		"BUILDARCH=" + bp.Architecture,
+		"BUILDVARIANT=" + bp.Variant,
+		"TARGETPLATFORM=" + platforms.Format(p),
+		"TARGETOS=" + p.OS,
+		"TARGETARCH=" + p.Architecture,
+		"TARGETVARIANT=" + p.Variant,
+	}
+	for _, cmd := range metaArgs {
+		for _, kv := range cmd.Args {
+			v, err := argValue(lex, kv, buildArgs, global)
+			if err != nil {
+				return nil, err
+			}
+			if v != nil {
+				global = append(global, kv.Key+"="+*v)
+			}
+		}
+	}
+
+	idx := len(stages) - 1
+	if target != "" {
+		idx = slices.IndexFunc(stages, func(st instructions.Stage) bool {
+			return strings.EqualFold(st.Name, target)
+		})
+		if idx < 0 {
+			return nil, errors.Errorf("target stage %q could not be found", target)
+		}
+	}
+	stageIndex := func(name string) (int, bool) {
+		if i := slices.IndexFunc(stages, func(st instructions.Stage) bool {
+			return strings.EqualFold(st.Name, name)
+		}); i >= 0 {
+			return i, true
+		}
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(stages) {
+			return i, true
+		}
+		return 0, false
+	}
+
+	// the dependencies of the stages reachable from the target, like the
+	// frontend only the images of those stages are used
+	var images []string
+	visited := map[int]struct{}{}
+	queue := []int{idx}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[i]; ok {
+			continue
+		}
+		visited[i] = struct{}{}
+		deps, err := stageDeps(stages[i], lex, global, buildArgs)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			if si, ok := stageIndex(dep); ok {
+				queue = append(queue, si)
+				continue
+			}
+			if dep != "scratch" && !slices.Contains(images, dep) {
+				images = append(images, dep)
+			}
+		}
+	}
+	return images, nil
+}
+
+// stageDeps returns the expanded names of the stages and images a stage
+// depends on. The base name is expanded with the global build arguments,
+// the flags of the commands with the arguments and environment variables
+// declared in the stage, as the frontend does.
+func stageDeps(st instructions.Stage, lex *shell.Lex, global []string, buildArgs map[string]string) ([]string, error) {
+	expand := func(env []string, word string) (string, error) {
+		name, _, err := lex.ProcessWord(word, shell.EnvsFromSlice(env))
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			return "", errors.Errorf("cannot lock image %q: expands to an empty name", word)
+		}
+		return name, nil
+	}
+
+	base, err := expand(global, st.BaseName)
+	if err != nil {
+		return nil, err
+	}
+	deps := []string{base}
+	var env []string
+	for _, cmd := range st.Commands {
+		switch c := cmd.(type) {
+		case *instructions.ArgCommand:
+			for _, kv := range c.Args {
+				v, err := argValue(lex, kv, buildArgs, env)
+				if err != nil {
+					return nil, err
+				}
+				if v == nil {
+					// global arguments are inherited by redeclaring them
+					if gv, ok := shell.EnvsFromSlice(global).Get(kv.Key); ok {
+						v = &gv
+					}
+				}
+				if v != nil {
+					env = append(env, kv.Key+"="+*v)
+				}
+			}
+		case *instructions.EnvCommand:
+			for _, kv := range c.Env {
+				v, _, err := lex.ProcessWord(kv.Value, shell.EnvsFromSlice(env))
+				if err != nil {
+					return nil, err
+				}
+				env = append(env, kv.Key+"="+v)
+			}
+		case *instructions.CopyCommand:
+			if c.From == "" {
+				continue
+			}
+			from, err := expand(env, c.From)
+			if err != nil {
+				return nil, err
+			}
+			deps = append(deps, from)
+		case *instructions.RunCommand:
+			for _, m := range instructions.GetMounts(c) {
+				if m.From == "" {
+					continue
+				}
+				from, err := expand(env, m.From)
+				if err != nil {
+					return nil, err
+				}
+				deps = append(deps, from)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// argValue returns the value of a build argument declaration, set as a
+// build argument or by its default expanded with env. It's nil if the
+// argument has neither.
+func argValue(lex *shell.Lex, kv instructions.KeyValuePairOptional, buildArgs map[string]string, env []string) (*string, error) {
+	if v, ok := buildArgs[kv.Key]; ok {
+		return &v, nil
+	}
+	if kv.Value == nil {
+		return nil, nil
+	}
+	v, _, err := lex.ProcessWord(*kv.Value, shell.EnvsFromSlice(env))
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
@@ -0,0 +1,335 @@
+package bake
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/buildx/build"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerfileBaseImages(t *testing.T) {
+	plats := []ocispecs.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}}
+	tests := []struct {
+		name      string
+		dt        string
+		target    string
+		buildArgs map[string]string
+		plats     []ocispecs.Platform
+		expected  []string
+		errMsg    string
+	}{
+		{
+			name: "multi-stage",
+			dt: `
+FROM golang:1.24-alpine AS build
+COPY --from=tonistiigi/xx:1.6 / /
+FROM build AS test
+RUN --mount=type=bind,from=build,target=/src --mount=type=cache,target=/root/.cache --mount=from=busybox:1.37,target=/bb true
+FROM alpine:3.21 AS base
+COPY --from=0 /out /
+COPY --from=test /out /
+FROM scratch AS out
+FROM base
+`,
+			expected: []string{"alpine:3.21", "golang:1.24-alpine", "tonistiigi/xx:1.6", "busybox:1.37"},
+		},
+		{
+			name: "unreachable stages",
+			dt: `
+FROM golang:1.24-alpine AS build
+FROM debian:bookworm AS unused
+COPY --from=tonistiigi/xx:1.6 / /
+FROM alpine:3.21
+COPY --from=build /out /
+`,
+			expected: []string{"alpine:3.21", "golang:1.24-alpine"},
+		},
+		{
+			name: "target",
+			dt: `
+FROM golang:1.24-alpine AS build
+FROM alpine:3.21
+`,
+			target:   "build",
+			expected: []string{"golang:1.24-alpine"},
+		},
+		{
+			name:   "unknown target",
+			dt:     "FROM alpine:3.21 AS base\n",
+			target: "release",
+			errMsg: `target stage "release" could not be found`,
+		},
+		{
+			name: "arg in from",
+			dt: `
+ARG GO_VERSION=1.24
+ARG ALPINE_VERSION
+ARG BASE=golang:${GO_VERSION}
+FROM ${BASE}-alpine AS build
+FROM alpine:${ALPINE_VERSION:-3.21}
+COPY --from=build /out /
+`,
+			expected: []string{"alpine:3.21", "golang:1.24-alpine"},
+		},
+		{
+			name: "arg in from with build args",
+			dt: `
+ARG GO_VERSION=1.24
+ARG ALPINE_VERSION
+ARG BASE=golang:${GO_VERSION}
+FROM ${BASE}-alpine AS build
+FROM alpine:${ALPINE_VERSION:-3.21}
+COPY --from=build /out /
+`,
+			buildArgs: map[string]string{"GO_VERSION": "1.23", "ALPINE_VERSION": "3.20"},
+			expected:  []string{"alpine:3.20", "golang:1.23-alpine"},
+		},
+		{
+			name: "arg in stage",
+			dt: `
+ARG XX_VERSION=1.6
+FROM alpine:3.21
+ARG XX_VERSION
+ARG BUSYBOX=busybox:1.37
+ENV XX=tonistiigi/xx:${XX_VERSION}
+COPY --from=${XX} / /
+COPY --from=${BUSYBOX} / /
+`,
+			expected: []string{"alpine:3.21", "tonistiigi/xx:1.6", "busybox:1.37"},
+		},
+		{
+			name: "arg in mount",
+			dt: `
+FROM alpine:3.21
+ARG BUSYBOX=busybox:1.37
+RUN --mount=from=${BUSYBOX},target=/bb true
+`,
+			errMsg: "'from' doesn't support variable expansion",
+		},
+		{
+			name: "global arg not redeclared in stage",
+			dt: `
+ARG XX=tonistiigi/xx:1.6
+FROM alpine:3.21
+COPY --from=${XX} / /
+`,
+			errMsg: "expands to an empty name",
+		},
+		{
+			name: "platform",
+			dt: `
+FROM --platform=$BUILDPLATFORM tonistiigi/xx:1.6 AS xx
+FROM --platform=linux/amd64 golang:1.24-alpine AS build
+COPY --from=xx / /
+FROM tonistiigi/debian:${TARGETARCH}
+COPY --from=build /out /
+`,
+			expected: []string{"tonistiigi/debian:amd64", "golang:1.24-alpine", "tonistiigi/xx:1.6", "tonistiigi/debian:arm64"},
+		},
+		{
+			name: "platform arg in stage",
+			dt: `
+FROM alpine:3.21
+ARG TARGETARCH
+COPY --from=tonistiigi/debian:${TARGETARCH} / /
+`,
+			plats:    plats[1:],
+			expected: []string{"alpine:3.21", "tonistiigi/debian:arm64"},
+		},
+		{
+			name:     "pinned",
+			dt:       "FROM docker.io/library/busybox@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef\n",
+			expected: []string{"docker.io/library/busybox@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+		},
+		{
+			name:   "empty name",
+			dt:     "ARG BASE\nFROM ${BASE}\n",
+			errMsg: "expands to an empty name",
+		},
+		{
+			name:   "invalid instruction",
+			dt:     "FROM alpine:3.21\nCOPY --from\n",
+			errMsg: "failed to parse dockerfile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plats
+			if tt.plats != nil {
+				p = tt.plats
+			}
+			images, err := dockerfileBaseImages([]byte(tt.dt), tt.target, tt.buildArgs, p)
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, images)
+		})
+	}
+}
+
+func TestLockImagesFrontend(t *testing.T) {
+	opt := build.Options{
+		Inputs: build.Inputs{
+			DockerfileInline: "# syntax=docker/dockerfile:1.12 --frontend-flag\nFROM docker/dockerfile:1.12\n",
+		},
+	}
+	images, err := lockImages(opt, nil)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	require.True(t, images[0].frontend)
+	require.Equal(t, "docker.io/docker/dockerfile:1.12", images[0].ref.String())
+	require.Equal(t, "--frontend-flag", images[0].args)
+	// the base image with the same name as the frontend is still pinned with
+	// a named context
+	require.False(t, images[1].frontend)
+	require.Equal(t, "docker/dockerfile:1.12", images[1].name)
+
+	// the build argument overrides the directive
+	opt.BuildArgs = map[string]string{"BUILDKIT_SYNTAX": "docker/dockerfile-upstream:master"}
+	images, err = lockImages(opt, nil)
+	require.NoError(t, err)
+	require.Equal(t, "docker.io/docker/dockerfile-upstream:master", images[0].ref.String())
+	require.Empty(t, images[0].args)
+
+	opt.BuildArgs = map[string]string{"BUILDKIT_SYNTAX": "docker/dockerfile@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}
+	images, err = lockImages(opt, nil)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	require.False(t, images[0].frontend)
+}
+
+func TestLockImagesRemoteDockerfile(t *testing.T) {
+	opt := build.Options{
+		Inputs: build.Inputs{
+			ContextPath:    "https://github.com/docker/buildx.git",
+			DockerfilePath: "Dockerfile",
+		},
+	}
+	require.True(t, isRemoteDockerfile(opt.Inputs))
+	_, err := lockImages(opt, nil)
+	require.ErrorContains(t, err, "not available locally")
+
+	images, err := lockImages(opt, []byte("FROM alpine:3.21\n"))
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	require.Equal(t, "docker.io/library/alpine:3.21", images[0].ref.String())
+
+	require.False(t, isRemoteDockerfile(build.Inputs{ContextPath: "https://github.com/docker/buildx.git", DockerfileInline: "FROM alpine\n"}))
+	require.False(t, isRemoteDockerfile(build.Inputs{ContextPath: "https://github.com/docker/buildx.git", DockerfilePath: "/src/Dockerfile"}))
+	require.True(t, isRemoteDockerfile(build.Inputs{ContextPath: ".", DockerfilePath: "-"}))
+}
+
+type fakeImageResolver map[string]digest.Digest
+
+func (r fakeImageResolver) Resolve(_ context.Context, in string) (string, ocispecs.Descriptor, error) {
+	dgst, ok := r[in]
+	if !ok {
+		return "", ocispecs.Descriptor{}, errors.Errorf("%s: not found", in)
+	}
+	return in, ocispecs.Descriptor{Digest: dgst}, nil
+}
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	require.NoError(t, os.WriteFile(dockerfile, []byte("# syntax=docker/dockerfile:1\nFROM alpine AS base\nFROM golang:1.24\nCOPY --from=base / /\n"), 0o644))
+
+	bo := map[string]build.Options{
+		"app": {
+			Inputs: build.Inputs{
+				ContextPath:    dir,
+				DockerfilePath: dockerfile,
+				NamedContexts: map[string]build.NamedContext{
+					"golang": {Path: "docker-image://golang:1.23"},
+					"src":    {Path: "."},
+				},
+			},
+		},
+		"inline": {
+			Inputs: build.Inputs{
+				ContextPath:      dir,
+				DockerfileInline: "FROM busybox:1.37@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef\n",
+			},
+		},
+	}
+
+	resolver := fakeImageResolver{
+		"docker.io/docker/dockerfile:1":   digest.FromString("dockerfile:1"),
+		"docker.io/library/alpine:latest": digest.FromString("alpine"),
+		"docker.io/library/golang:1.23":   digest.FromString("golang:1.23"),
+		"docker.io/library/golang:1.24":   digest.FromString("golang:1.24"),
+	}
+
+	lock := NewLock()
+	lock.SetDefinition("https://github.com/docker/buildx.git", "0123456789abcdef0123456789abcdef01234567")
+	require.NoError(t, lock.ResolveImages(t.Context(), bo, nil, resolver))
+	require.Len(t, lock.Images, 4)
+
+	fn := filepath.Join(dir, LockFilename)
+	require.NoError(t, lock.Write(fn))
+	lock, err := ReadLock(fn)
+	require.NoError(t, err)
+	checksum, err := lock.Definition("https://github.com/docker/buildx.git")
+	require.NoError(t, err)
+	require.Equal(t, "0123456789abcdef0123456789abcdef01234567", checksum)
+	_, err = lock.Definition("https://github.com/docker/cli.git")
+	require.Error(t, err)
+
+	require.NoError(t, lock.Pin(bo, nil))
+	require.Equal(t, map[string]build.NamedContext{
+		"alpine":      {Path: "docker-image://docker.io/library/alpine:latest@" + digest.FromString("alpine").String()},
+		"golang":      {Path: "docker-image://docker.io/library/golang:1.23@" + digest.FromString("golang:1.23").String()},
+		"golang:1.24": {Path: "docker-image://docker.io/library/golang:1.24@" + digest.FromString("golang:1.24").String()},
+		"src":         {Path: "."},
+	}, bo["app"].Inputs.NamedContexts)
+	require.Equal(t, map[string]string{
+		"BUILDKIT_SYNTAX": "docker.io/docker/dockerfile:1@" + digest.FromString("dockerfile:1").String(),
+	}, bo["app"].BuildArgs)
+	require.Empty(t, bo["inline"].Inputs.NamedContexts)
+	require.Empty(t, bo["inline"].BuildArgs)
+
+	// pinned images are not locked again
+	require.NoError(t, lock.Pin(bo, nil))
+
+	bo["app"].Inputs.NamedContexts["golang"] = build.NamedContext{Path: "docker-image://golang:1.22"}
+	require.ErrorContains(t, lock.Pin(bo, nil), "image docker.io/library/golang:1.22 of target app is not locked")
+}
+
+func TestReadLockNotFound(t *testing.T) {
+	_, err := ReadLock(filepath.Join(t.TempDir(), LockFilename))
+	require.ErrorContains(t, err, "run bake with --lock")
+}
+
+func TestLoadLockMerge(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), LockFilename)
+	lock, err := LoadLock(fn)
+	require.NoError(t, err)
+	require.Equal(t, NewLock(), lock)
+
+	lock.SetDefinition("https://github.com/docker/buildx.git", "0123456789abcdef0123456789abcdef01234567")
+	lock.Images = map[string]string{"docker.io/library/golang:1.24": digest.FromString("golang:1.24").String()}
+	require.NoError(t, lock.Write(fn))
+
+	// images of targets that are not built are kept when the lock is updated
+	lock, err = LoadLock(fn)
+	require.NoError(t, err)
+	bo := map[string]build.Options{
+		"app": {Inputs: build.Inputs{DockerfileInline: "FROM alpine:3.21\n"}},
+	}
+	require.NoError(t, lock.ResolveImages(t.Context(), bo, nil, fakeImageResolver{
+		"docker.io/library/alpine:3.21": digest.FromString("alpine"),
+	}))
+	require.Equal(t, map[string]string{
+		"docker.io/library/alpine:3.21": digest.FromString("alpine").String(),
+		"docker.io/library/golang:1.24": digest.FromString("golang:1.24").String(),
+	}, lock.Images)
+	require.Len(t, lock.Definitions, 1)
+}
@@ -4,7 +4,9 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/docker/buildx/build"
@@ -12,12 +14,16 @@ import (
 	"github.com/docker/buildx/driver"
 	"github.com/docker/buildx/util/buildflags"
 	"github.com/docker/buildx/util/progress"
+	"github.com/docker/buildx/util/urlutil"
 	"github.com/docker/go-units"
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/client/llb/sourceresolver"
 	"github.com/moby/buildkit/frontend/dockerui"
 	gwclient "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 )
 
@@ -26,14 +32,30 @@ const maxBakeDefinitionSize = 2 * 1024 * 1024 // 2 MB
 type Input struct {
 	State *llb.State
 	URL   string
+	// Checksum is the commit of a remote Git definition or the digest of a
+	// remote HTTP definition. It is only set if it was resolved or pinned.
+	Checksum string
 }
 
-func ReadRemoteFiles(ctx context.Context, nodes []builder.Node, url string, names []string, pw progress.Writer) ([]File, *Input, error) {
+// RemoteOpt configures how remote definitions are read.
+type RemoteOpt struct {
+	// Checksum pins the definition to a Git commit or HTTP digest.
+	Checksum string
+	// ResolveChecksum resolves the checksum of the definition.
+	ResolveChecksum bool
+}
+
+func ReadRemoteFiles(ctx context.Context, nodes []builder.Node, url string, names []string, opt RemoteOpt, pw progress.Writer) ([]File, *Input, error) {
 	var sessions []session.Attachable
 	var filename string
 
+	var gitOpts []llb.GitOption
+	if opt.Checksum != "" {
+		gitOpts = append(gitOpts, llb.GitChecksum(opt.Checksum))
+	}
+
 	keepGitDir := false
-	st, ok, err := dockerui.DetectGitContext(url, &keepGitDir)
+	st, ok, err := dockerui.DetectGitContext(url, &keepGitDir, gitOpts...)
 	if ok {
 		if err != nil {
 			return nil, nil, err
@@ -45,7 +67,7 @@ func ReadRemoteFiles(ctx context.Context, nodes []builder.Node, url string, name
 			sessions = append(sessions, ssh)
 		}
 		if gitAuthSecrets := gitAuthSecretsFromEnv(url); len(gitAuthSecrets) > 0 {
-			if secrets, err := build.CreateSecrets(gitAuthSecrets); err == nil {
+			if secrets, err := build.CreateSecrets(gitAuthSecrets, nil); err == nil {
 				sessions = append(sessions, secrets)
 			}
 		}
@@ -54,9 +76,17 @@ func ReadRemoteFiles(ctx context.Context, nodes []builder.Node, url string, name
 		if !ok {
 			return nil, nil, errors.Errorf("not url context")
 		}
+		if opt.Checksum != "" {
+			dgst, err := digest.Parse(opt.Checksum)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid checksum for remote definition %s", url)
+			}
+			hst := llb.HTTP(url, llb.Filename(filename), llb.Checksum(dgst), dockerui.WithInternalName("load remote build context"))
+			st = &hst
+		}
 	}
 
-	inp := &Input{State: st, URL: url}
+	inp := &Input{State: st, URL: url, Checksum: opt.Checksum}
 	var files []File
 
 	var node *builder.Node
@@ -82,6 +112,11 @@ func ReadRemoteFiles(ctx context.Context, nodes []builder.Node, url string, name
 		if err != nil {
 			return nil, err
 		}
+		if opt.ResolveChecksum && opt.Checksum == "" {
+			if inp.Checksum, err = resolveChecksum(ctx, c, def); err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve checksum of remote definition %s", url)
+			}
+		}
 		res, err := c.Solve(ctx, gwclient.SolveRequest{
 			Definition: def.ToPB(),
 		})
@@ -108,6 +143,161 @@ func ReadRemoteFiles(ctx context.Context, nodes []builder.Node, url string, name
 	return files, inp, nil
 }
 
+// ReadRemoteDockerfiles reads the Dockerfiles of the build options that are
+// not available locally through the builder, so the images they use can be
+// locked. Dockerfiles of remote definitions are read from the definition,
+// and the ones of remote build contexts from their Git repository or HTTP
+// URL. Dockerfiles read from stdin can't be read and are an error.
+func ReadRemoteDockerfiles(ctx context.Context, nodes []builder.Node, bo map[string]build.Options, pw progress.Writer) (map[string][]byte, error) {
+	type remoteDockerfile struct {
+		st       llb.State
+		filename string
+	}
+	remotes := map[string]remoteDockerfile{}
+	var gitAuthSecrets buildflags.Secrets
+	for _, k := range slices.Sorted(maps.Keys(bo)) {
+		inp := bo[k].Inputs
+		if !isRemoteDockerfile(inp) {
+			continue
+		}
+		st, filename, err := remoteDockerfileState(inp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot lock the base images of target %s", k)
+		}
+		remotes[k] = remoteDockerfile{st: st, filename: filename}
+		gitAuthSecrets = gitAuthSecrets.Merge(gitAuthSecretsFromEnv(inp.ContextPath))
+	}
+	if len(remotes) == 0 {
+		return nil, nil
+	}
+
+	var sessions []session.Attachable
+	if ssh, err := build.CreateSSH([]*buildflags.SSH{{
+		ID:    "default",
+		Paths: strings.Split(os.Getenv("BUILDX_BAKE_GIT_SSH"), ","),
+	}}); err == nil {
+		sessions = append(sessions, ssh)
+	}
+	if len(gitAuthSecrets) > 0 {
+		if secrets, err := build.CreateSecrets(gitAuthSecrets, nil); err == nil {
+			sessions = append(sessions, secrets)
+		}
+	}
+
+	var node *builder.Node
+	for i, n := range nodes {
+		if n.Err == nil {
+			node = &nodes[i]
+			break
+		}
+	}
+	if node == nil {
+		return nil, errors.New("no builder node available to read remote Dockerfiles")
+	}
+	c, err := driver.Boot(ctx, ctx, node.Driver, pw)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerfiles := make(map[string][]byte, len(remotes))
+	ch, done := progress.NewChannel(pw)
+	defer func() { <-done }()
+	_, err = c.Build(ctx, client.SolveOpt{Session: sessions, Internal: true}, "buildx", func(ctx context.Context, c gwclient.Client) (*gwclient.Result, error) {
+		for _, k := range slices.Sorted(maps.Keys(remotes)) {
+			r := remotes[k]
+			def, err := r.st.Marshal(ctx)
+			if err != nil {
+				return nil, err
+			}
+			res, err := c.Solve(ctx, gwclient.SolveRequest{
+				Definition: def.ToPB(),
+			})
+			if err != nil {
+				return nil, err
+			}
+			ref, err := res.SingleRef()
+			if err != nil {
+				return nil, err
+			}
+			dt, err := ref.ReadFile(ctx, gwclient.ReadRequest{
+				Filename: r.filename,
+			})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read Dockerfile of target %s", k)
+			}
+			dockerfiles[k] = dt
+		}
+		return nil, nil
+	}, ch)
+	if err != nil {
+		return nil, err
+	}
+	return dockerfiles, nil
+}
+
+// remoteDockerfileState returns the state and the path of the Dockerfile
+// of a build that is not available locally.
+func remoteDockerfileState(inp build.Inputs) (llb.State, string, error) {
+	filename := inp.DockerfilePath
+	if filename == "" {
+		filename = "Dockerfile"
+	}
+	switch {
+	case urlutil.IsRemoteURL(inp.DockerfilePath):
+		if st, filename, ok := dockerui.DetectHTTPContext(inp.DockerfilePath); ok {
+			return *st, filename, nil
+		}
+	case inp.ContextPath == "-" || inp.DockerfilePath == "-":
+		return llb.State{}, "", errors.New("Dockerfile is read from stdin")
+	case inp.ContextState != nil:
+		return *inp.ContextState, filename, nil
+	case urlutil.IsRemoteURL(inp.ContextPath):
+		keepGitDir := false
+		st, ok, err := dockerui.DetectGitContext(inp.ContextPath, &keepGitDir)
+		if err != nil {
+			return llb.State{}, "", err
+		}
+		if ok {
+			return *st, filename, nil
+		}
+	}
+	return llb.State{}, "", errors.Errorf("Dockerfile %s can't be read from context %s", filename, inp.ContextPath)
+}
+
+// resolveChecksum resolves the commit of the Git source or the digest of
+// the HTTP source of a definition.
+func resolveChecksum(ctx context.Context, c gwclient.Client, def *llb.Definition) (string, error) {
+	var src *pb.SourceOp
+	for _, dt := range def.Def {
+		var op pb.Op
+		if err := op.UnmarshalVT(dt); err != nil {
+			return "", err
+		}
+		if src = op.GetSource(); src != nil {
+			break
+		}
+	}
+	if src == nil {
+		return "", errors.New("no source found")
+	}
+	md, err := c.ResolveSourceMetadata(ctx, src, sourceresolver.Opt{
+		GitOpt:  &sourceresolver.ResolveGitOpt{},
+		HTTPOpt: &sourceresolver.ResolveHTTPOpt{},
+	})
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case md.Git != nil && md.Git.CommitChecksum != "":
+		return md.Git.CommitChecksum, nil
+	case md.Git != nil && md.Git.Checksum != "":
+		return md.Git.Checksum, nil
+	case md.HTTP != nil && md.HTTP.Digest != "":
+		return md.HTTP.Digest.String(), nil
+	}
+	return "", errors.New("no checksum returned")
+}
+
 func isArchive(header []byte) bool {
 	for _, m := range [][]byte{
 		{0x42, 0x5A, 0x68},                   // bzip2
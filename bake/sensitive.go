@@ -0,0 +1,67 @@
+package bake
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/docker/buildx/bake/hclparser"
+	"github.com/pkg/errors"
+)
+
+// nonSensitiveAttributes are the attributes of a target that can't contain
+// sensitive values, as they are persisted in the image config and the
+// provenance of the build.
+var nonSensitiveAttributes = []string{"args", "labels"}
+
+// checkSensitive returns an error if a sensitive value flows into an
+// attribute of a target that would persist it, and sets the value of the
+// env secrets of the targets that refer to sensitive variables.
+//
+// An env secret uses the value of the sensitive variable with the same name
+// as its env. A secret whose env isn't set in the environment is an error if
+// its name matches a variable that isn't sensitive, or a sensitive variable
+// with a different case, as the secret would be empty instead of using the
+// value of the variable.
+func checkSensitive(c Config, pm *hclparser.ParseMeta) error {
+	for _, t := range c.Targets {
+		for _, name := range nonSensitiveAttributes {
+			if slices.Contains(t.sensitive, name) {
+				return errors.Errorf("target %s: %s can't contain sensitive values, use a secret or the nonsensitive function", t.Name, name)
+			}
+		}
+		for _, s := range t.Secrets {
+			if s.Type != "" || s.Env == "" || s.FilePath != "" {
+				continue
+			}
+			if v, ok := pm.SensitiveValues[s.Env]; ok {
+				s.Value = v
+				continue
+			}
+			if _, ok := os.LookupEnv(s.Env); ok {
+				continue
+			}
+			if err := checkSecretVariable(t.Name, s.ID, s.Env, pm.AllVariables); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkSecretVariable returns an error if the env of a secret refers to a
+// variable whose value can't be used by the secret.
+func checkSecretVariable(target, id, env string, vars []*hclparser.Variable) error {
+	for _, v := range vars {
+		if !strings.EqualFold(v.Name, env) {
+			continue
+		}
+		if v.Sensitive {
+			return errors.Errorf("target %s: secret %s: env %s doesn't match the name of the sensitive variable %s", target, id, env, v.Name)
+		}
+		if v.Value != nil && *v.Value != "" {
+			return errors.Errorf("target %s: secret %s: variable %s isn't sensitive, set sensitive = true to use its value for the secret", target, id, v.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package bake
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/buildx/bake/hclparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensitiveVariables(t *testing.T) {
+	fp := File{
+		Name: "docker-bake.hcl",
+		Data: []byte(`
+variable "TOKEN" {
+  sensitive = true
+}
+variable "AUTH" {
+  default = "Bearer ${TOKEN}"
+}
+variable "REGISTRY" {
+  default = "docker.io"
+}
+target "base" {
+  cache-to = ["type=gha,token=${upper(TOKEN)}"]
+}
+target "app" {
+  inherits = ["base"]
+  tags = ["${REGISTRY}/app"]
+  output = ["type=registry,auth=${target.base.cache-to[0].token}"]
+  secret = ["id=token,env=TOKEN", "id=auth,env=AUTH", "id=gh,env=GH_TOKEN"]
+  args = {
+    TOKEN_SHA = nonsensitive(sha256(TOKEN))
+  }
+}
+`),
+	}
+
+	_, pm, err := ParseFiles([]File{fp}, nil, map[string]string{"TOKEN": "s3cr3t"})
+	require.NoError(t, err)
+
+	vars := map[string]*hclparser.Variable{}
+	for _, v := range pm.AllVariables {
+		vars[v.Name] = v
+	}
+	require.True(t, vars["TOKEN"].Sensitive)
+	require.Equal(t, hclparser.SensitiveValue, *vars["TOKEN"].Value)
+	require.True(t, vars["AUTH"].Sensitive)
+	require.Equal(t, hclparser.SensitiveValue, *vars["AUTH"].Value)
+	require.False(t, vars["REGISTRY"].Sensitive)
+	require.Equal(t, "docker.io", *vars["REGISTRY"].Value)
+	require.Equal(t, map[string]string{"TOKEN": "s3cr3t", "AUTH": "Bearer s3cr3t"}, pm.SensitiveValues)
+
+	m, _, err := ReadTargets(t.Context(), []File{fp}, []string{"app"}, nil, nil, map[string]string{"TOKEN": "s3cr3t"}, &EntitlementConf{})
+	require.NoError(t, err)
+	app := m["app"]
+	require.Equal(t, "type=gha,token=S3CR3T", app.CacheTo[0].String())
+	require.Len(t, app.Secrets, 3)
+	for _, s := range app.Secrets {
+		switch s.ID {
+		case "token":
+			require.Equal(t, "s3cr3t", s.Value)
+		case "auth":
+			require.Equal(t, "Bearer s3cr3t", s.Value)
+		default:
+			require.Empty(t, s.Value)
+		}
+	}
+
+	dt, err := json.Marshal(app)
+	require.NoError(t, err)
+	require.NotContains(t, string(dt), "s3cr3t")
+	require.NotContains(t, string(dt), "S3CR3T")
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(dt, &out))
+	require.Equal(t, hclparser.SensitiveValue, out["cache-to"])
+	require.Equal(t, hclparser.SensitiveValue, out["output"])
+	require.Equal(t, []any{"docker.io/app"}, out["tags"])
+	require.NotEqual(t, hclparser.SensitiveValue, out["args"])
+}
+
+func TestSensitiveVariablesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		dt     string
+		errMsg string
+	}{
+		{
+			name: "args",
+			dt: `
+variable "TOKEN" {
+  sensitive = true
+}
+target "app" {
+  args = {
+    TOKEN = TOKEN
+  }
+}`,
+			errMsg: "target app: args can't contain sensitive values",
+		},
+		{
+			name: "labels",
+			dt: `
+variable "TOKEN" {
+  sensitive = true
+}
+variable "LABEL" {
+  default = "token=${TOKEN}"
+}
+target "app" {
+  labels = {
+    foo = LABEL
+  }
+}`,
+			errMsg: "target app: labels can't contain sensitive values",
+		},
+		{
+			name: "function",
+			dt: `
+target "app" {
+  args = {
+    FOO = sensitive("bar")
+  }
+}`,
+			errMsg: "target app: args can't contain sensitive values",
+		},
+		{
+			name: "validation",
+			dt: `
+variable "TOKEN" {
+  sensitive = true
+  validation {
+    condition = strlen(TOKEN) > 10
+    error_message = "invalid token ${TOKEN}"
+  }
+}`,
+			errMsg: "The error message contains a sensitive value",
+		},
+		{
+			name: "secret of non-sensitive variable",
+			dt: `
+variable "REGISTRY_TOKEN" {
+  default = "s3cr3t"
+}
+target "app" {
+  secret = ["id=token,env=REGISTRY_TOKEN"]
+}`,
+			errMsg: "target app: secret token: variable REGISTRY_TOKEN isn't sensitive",
+		},
+		{
+			name: "secret name mismatch",
+			dt: `
+variable "token" {
+  sensitive = true
+}
+target "app" {
+  secret = ["id=token,env=TOKEN"]
+}`,
+			errMsg: "target app: secret token: env TOKEN doesn't match the name of the sensitive variable token",
+		},
+		{
+			name: "matrix",
+			dt: `
+variable "TOKEN" {
+  sensitive = true
+}
+target "app" {
+  name = "app-${item}"
+  matrix = {
+    item = [TOKEN]
+  }
+}`,
+			errMsg: "matrix can't contain sensitive values",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseFiles([]File{{Name: "docker-bake.hcl", Data: []byte(tt.dt)}}, nil, map[string]string{"TOKEN": "s3cr3t", "token": "s3cr3t"})
+			require.ErrorContains(t, err, tt.errMsg)
+			require.NotContains(t, err.Error(), "s3cr3t")
+		})
+	}
+}
+
+func TestSensitiveSecretFromEnv(t *testing.T) {
+	// the secret reads the environment, so the variable doesn't need to be
+	// sensitive
+	t.Setenv("REGISTRY_TOKEN", "s3cr3t")
+	fp := File{
+		Name: "docker-bake.hcl",
+		Data: []byte(`
+variable "REGISTRY_TOKEN" {
+  default = "fallback"
+}
+target "app" {
+  secret = ["id=token,env=REGISTRY_TOKEN"]
+}`),
+	}
+	m, _, err := ReadTargets(t.Context(), []File{fp}, []string{"app"}, nil, nil, nil, &EntitlementConf{})
+	require.NoError(t, err)
+	require.Empty(t, m["app"].Secrets[0].Value)
+}
@@ -0,0 +1,166 @@
+package bake
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/buildx/build"
+	"github.com/pkg/errors"
+)
+
+// Shard is a partition of the targets of a bake invocation, built by a
+// single job.
+type Shard struct {
+	// Index is the 1-based index of the shard
+	Index   int      `json:"index"`
+	Targets []string `json:"targets"`
+	// Duration is the estimated duration of the shard, only set if the
+	// duration of some targets is known
+	Duration time.Duration `json:"-"`
+}
+
+// ParseShard parses a shard in the INDEX/TOTAL format, INDEX being between
+// 1 and TOTAL.
+func ParseShard(s string) (index, total int, err error) {
+	i, t, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, errors.Errorf("invalid shard %q, expected INDEX/TOTAL", s)
+	}
+	if index, err = strconv.Atoi(i); err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid shard index %q", i)
+	}
+	if total, err = strconv.Atoi(t); err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid shard total %q", t)
+	}
+	if total < 1 {
+		return 0, 0, errors.Errorf("invalid shard total %d, must be at least 1", total)
+	}
+	if index < 1 || index > total {
+		return 0, 0, errors.Errorf("invalid shard index %d, must be between 1 and %d", index, total)
+	}
+	return index, total, nil
+}
+
+// ReadDurations reads the durations of targets used to balance shards from
+// a JSON file mapping target names to durations, e.g. {"app": "3m20s"}.
+func ReadDurations(fn string) (map[string]time.Duration, error) {
+	dt, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(dt, &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse durations file %s", fn)
+	}
+	durations := make(map[string]time.Duration, len(m))
+	for name, v := range m {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid duration for target %s in %s", name, fn)
+		}
+		durations[name] = d
+	}
+	return durations, nil
+}
+
+// Shards partitions the targets of the build options into total shards of
+// balanced durations. Targets linked through target: named contexts are kept
+// in the same shard. Targets without a known duration are estimated with the
+// average of the known durations, or all count the same if none is known.
+// If total is lower than 1, each group of linked targets gets its own shard.
+// The partition is deterministic for the same targets and durations.
+func Shards(bo map[string]build.Options, total int, durations map[string]time.Duration) []Shard {
+	// group linked targets with a union-find
+	parent := make(map[string]string, len(bo))
+	var find func(string) string
+	find = func(name string) string {
+		if p := parent[name]; p != name {
+			parent[name] = find(p)
+		}
+		return parent[name]
+	}
+	for name := range bo {
+		parent[name] = name
+	}
+	for name, opt := range bo {
+		for _, target := range linkedTargets(opt.Inputs) {
+			if _, ok := bo[target]; !ok {
+				continue
+			}
+			a, b := find(name), find(target)
+			if a != b {
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	var known time.Duration
+	var nknown int
+	for name := range bo {
+		if d, ok := durations[name]; ok {
+			known += d
+			nknown++
+		}
+	}
+	estimate := time.Duration(1)
+	if nknown > 0 {
+		estimate = known / time.Duration(nknown)
+	}
+
+	type component struct {
+		targets  []string
+		duration time.Duration
+	}
+	components := map[string]*component{}
+	for _, name := range slices.Sorted(maps.Keys(bo)) {
+		root := find(name)
+		c, ok := components[root]
+		if !ok {
+			c = &component{}
+			components[root] = c
+		}
+		c.targets = append(c.targets, name)
+		if d, ok := durations[name]; ok {
+			c.duration += d
+		} else {
+			c.duration += estimate
+		}
+	}
+
+	// longest processing time first: assign the longest components to the
+	// shard with the lowest load
+	sorted := slices.SortedFunc(maps.Values(components), func(a, b *component) int {
+		return cmp.Or(cmp.Compare(b.duration, a.duration), cmp.Compare(a.targets[0], b.targets[0]))
+	})
+	if total < 1 {
+		total = len(components)
+	}
+	shards := make([]Shard, total)
+	loads := make([]time.Duration, total)
+	for i := range shards {
+		shards[i] = Shard{Index: i + 1, Targets: []string{}}
+	}
+	for _, c := range sorted {
+		i := 0
+		for j := range loads {
+			if loads[j] < loads[i] {
+				i = j
+			}
+		}
+		shards[i].Targets = append(shards[i].Targets, c.targets...)
+		loads[i] += c.duration
+	}
+	for i := range shards {
+		slices.Sort(shards[i].Targets)
+		if nknown > 0 {
+			shards[i].Duration = loads[i]
+		}
+	}
+	return shards
+}
@@ -0,0 +1,84 @@
+package bake
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/buildx/build"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShard(t *testing.T) {
+	index, total, err := ParseShard("2/3")
+	require.NoError(t, err)
+	require.Equal(t, 2, index)
+	require.Equal(t, 3, total)
+
+	for _, s := range []string{"", "2", "0/3", "4/3", "1/0", "a/3", "1/b"} {
+		_, _, err := ParseShard(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestShards(t *testing.T) {
+	bo := map[string]build.Options{
+		"app": {Inputs: build.Inputs{NamedContexts: map[string]build.NamedContext{
+			"base": {Path: "target:base"},
+		}}},
+		"base":  {},
+		"docs":  {},
+		"lint":  {},
+		"test":  {},
+		"tools": {},
+	}
+
+	// without durations, shards are balanced by the number of targets
+	shards := Shards(bo, 3, nil)
+	require.Equal(t, []Shard{
+		{Index: 1, Targets: []string{"app", "base"}},
+		{Index: 2, Targets: []string{"docs", "test"}},
+		{Index: 3, Targets: []string{"lint", "tools"}},
+	}, shards)
+
+	shards = Shards(bo, 2, map[string]time.Duration{
+		"app":   4 * time.Minute,
+		"base":  2 * time.Minute,
+		"docs":  time.Minute,
+		"test":  5 * time.Minute,
+		"tools": 3 * time.Minute,
+	})
+	// lint is estimated with the average of 3 minutes
+	require.Equal(t, []Shard{
+		{Index: 1, Targets: []string{"app", "base", "tools"}, Duration: 9 * time.Minute},
+		{Index: 2, Targets: []string{"docs", "lint", "test"}, Duration: 9 * time.Minute},
+	}, shards)
+
+	// one shard per group of linked targets
+	shards = Shards(bo, 0, nil)
+	require.Len(t, shards, 5)
+	require.Equal(t, Shard{Index: 1, Targets: []string{"app", "base"}}, shards[0])
+
+	// more shards than targets
+	shards = Shards(map[string]build.Options{"app": {}}, 2, nil)
+	require.Equal(t, []Shard{
+		{Index: 1, Targets: []string{"app"}},
+		{Index: 2, Targets: []string{}},
+	}, shards)
+}
+
+func TestReadDurations(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "durations.json")
+	require.NoError(t, os.WriteFile(fn, []byte(`{"app": "3m20s", "docs": "45s"}`), 0o644))
+	durations, err := ReadDurations(fn)
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Duration{
+		"app":  3*time.Minute + 20*time.Second,
+		"docs": 45 * time.Second,
+	}, durations)
+
+	require.NoError(t, os.WriteFile(fn, []byte(`{"app": "soon"}`), 0o644))
+	_, err = ReadDurations(fn)
+	require.ErrorContains(t, err, "invalid duration for target app")
+}
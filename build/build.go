@@ -6,6 +6,7 @@ import (
 	_ "crypto/sha256" // ensure digests can be computed
 	"encoding/base64"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"maps"
@@ -21,6 +22,7 @@ import (
 	noderesolver "github.com/docker/buildx/build/resolver"
 	"github.com/docker/buildx/builder"
 	"github.com/docker/buildx/driver"
+	"github.com/docker/buildx/policy"
 	"github.com/docker/buildx/util/buildflags"
 	"github.com/docker/buildx/util/confutil"
 	"github.com/docker/buildx/util/desktop"
@@ -97,6 +99,7 @@ type Options struct {
 	GroupRef               string
 	Annotations            map[exptypes.AnnotationKey]string // Not used during build, annotations are already set in Exports. Just used to check for support with drivers.
 	Policy                 []buildflags.PolicyConfig
+	Retry                  *buildflags.RetryPolicy
 }
 
 // ResourceLimits holds the cgroup resource constraints applied to individual
@@ -162,7 +165,7 @@ func withPolicyConfig(defaultPolicy policyOpt, configs []buildflags.PolicyConfig
 		if !cfg.Disabled {
 			continue
 		}
-		if cfg.Reset || cfg.Strict != nil || cfg.LogLevel != nil || len(cfg.Files) > 0 {
+		if cfg.Reset || cfg.Strict != nil || cfg.LogLevel != nil || cfg.AuditLog != "" || len(cfg.Files) > 0 {
 			return nil, errors.New("disabled policy cannot be combined with other policy flags")
 		}
 		if len(configs) > 1 {
@@ -232,6 +235,9 @@ type NamedContext struct {
 type reqForNode struct {
 	*noderesolver.ResolvedNode
 	so *client.SolveOpt
+	// retrySolveOpt creates the solve options of the build for the node it
+	// is moved to by a retry, as they depend on the driver of the node
+	retrySolveOpt func(ctx context.Context, from, to *noderesolver.ResolvedNode) (*client.SolveOpt, func(error), error)
 }
 
 func filterAvailableNodes(nodes []builder.Node) ([]builder.Node, error) {
@@ -314,7 +320,10 @@ func newBuildRequests(ctx context.Context, docker *dockerutil.Client, cfg *confu
 		}
 	}()
 
-	for k, opt := range opts {
+	// builds denied by policy are reported for all targets before failing
+	var denied []error
+	for _, k := range slices.Sorted(maps.Keys(opts)) {
+		opt := opts[k]
 		multiDriver := len(drivers[k]) > 1
 		hasMobyDriver := false
 		addGitAttrs, err := getGitAttributes(ctx, opt.Inputs.ContextPath, opt.Inputs.DockerfilePath)
@@ -324,6 +333,27 @@ func newBuildRequests(ctx context.Context, docker *dockerutil.Client, cfg *confu
 		if opt.Ref == "" {
 			opt.Ref = identity.NewID()
 		}
+		// the options of the build are checked against the policies once,
+		// before the build is scheduled on its nodes
+		var plats []ocispecs.Platform
+		for _, np := range drivers[k] {
+			plats = append(plats, np.Platforms()...)
+		}
+		var policyRecords []policy.AuditRecord
+		if len(drivers[k]) > 0 {
+			policyRecords, err = checkBuildPolicy(ctx, drivers[k][0], &opt, policyBuildInput(&opt, plats), w)
+			if err != nil {
+				var deniedErr *policy.BuildDeniedError
+				if !errors.As(err, &deniedErr) {
+					return nil, nil, err
+				}
+				if len(opts) > 1 {
+					err = errors.Wrapf(err, "target %s", k)
+				}
+				denied = append(denied, err)
+				continue
+			}
+		}
 		var reqn []*reqForNode
 		for _, np := range drivers[k] {
 			if np.Node().Driver.IsMobyDriver() {
@@ -334,6 +364,7 @@ func newBuildRequests(ctx context.Context, docker *dockerutil.Client, cfg *confu
 			if err != nil {
 				return nil, nil, err
 			}
+			retryOpt := cloneOutputs(opt)
 			localOpt := opt
 			so, release, err := toSolveOpt(ctx, np, multiDriver, &localOpt, gatewayOpts, cfg, w, docker)
 			opts[k] = localOpt
@@ -344,10 +375,30 @@ func newBuildRequests(ctx context.Context, docker *dockerutil.Client, cfg *confu
 			if err := saveLocalState(so, k, opt, np.Node(), cfg); err != nil {
 				return nil, nil, err
 			}
+			if err := savePolicyDecisions(so.Ref, np.Node(), cfg, policyRecords); err != nil {
+				logrus.WithError(err).Debug("failed to save policy decisions")
+			}
 			addGitAttrs(so)
 			reqn = append(reqn, &reqForNode{
 				ResolvedNode: np,
 				so:           so,
+				retrySolveOpt: func(ctx context.Context, from, to *noderesolver.ResolvedNode) (*client.SolveOpt, func(error), error) {
+					gatewayOpts, err := to.BuildOpts(ctx)
+					if err != nil {
+						return nil, nil, err
+					}
+					localOpt := cloneOutputs(retryOpt)
+					so, release, err := toSolveOpt(ctx, to, multiDriver, &localOpt, gatewayOpts, cfg, w, docker)
+					if err != nil {
+						return nil, nil, err
+					}
+					if err := moveLocalState(so, k, retryOpt, from.Node(), to.Node(), cfg); err != nil {
+						release(err)
+						return nil, nil, err
+					}
+					addGitAttrs(so)
+					return so, release, nil
+				},
 			})
 		}
 		reqForNodes[k] = reqn
@@ -374,9 +425,90 @@ func newBuildRequests(ctx context.Context, docker *dockerutil.Client, cfg *confu
 			}
 		}
 	}
+	if len(denied) > 0 {
+		return nil, nil, stderrors.Join(denied...)
+	}
 	return reqForNodes, releaseAll, nil
 }
 
+// cloneOutputs returns a copy of opt with its own exports and cache exports,
+// as toSolveOpt modifies them for the node it is called with.
+func cloneOutputs(opt Options) Options {
+	exports := make([]client.ExportEntry, len(opt.Exports))
+	for i, e := range opt.Exports {
+		e.Attrs = maps.Clone(e.Attrs)
+		exports[i] = e
+	}
+	opt.Exports = exports
+	opt.CacheTo = slices.Clone(opt.CacheTo)
+	return opt
+}
+
+// multiNodeExports validates the exporters of a build running on multiple
+// nodes, and makes image exporters pushing to a registry push by digest so
+// the manifest list can be created once all the nodes are done. It returns
+// the names of the pushed images.
+func multiNodeExports(so *client.SolveOpt) (pushNames string, insecurePush bool, _ error) {
+	for i, e := range so.Exports {
+		switch e.Type {
+		case "oci", "tar":
+			return "", false, errors.Errorf("%s for multi-node builds currently not supported", e.Type)
+		case "image":
+			if pushNames == "" && e.Attrs["push"] != "" {
+				if ok, _ := strconv.ParseBool(e.Attrs["push"]); ok {
+					pushNames = e.Attrs["name"]
+					if pushNames == "" {
+						return "", false, errors.Errorf("tag is needed when pushing to registry")
+					}
+					names, err := toRepoOnly(e.Attrs["name"])
+					if err != nil {
+						return "", false, err
+					}
+					if ok, _ := strconv.ParseBool(e.Attrs["registry.insecure"]); ok {
+						insecurePush = true
+					}
+					e.Attrs["name"] = names
+					e.Attrs["push-by-digest"] = "true"
+					so.Exports[i].Attrs = e.Attrs
+				}
+			}
+		}
+	}
+	return pushNames, insecurePush, nil
+}
+
+// newSolveRequest moves the frontend of the solve options to a gateway
+// solve request.
+func newSolveRequest(ctx context.Context, so *client.SolveOpt, opt Options) (gateway.SolveRequest, error) {
+	frontendInputs := make(map[string]*pb.Definition)
+	for key, st := range so.FrontendInputs {
+		def, err := st.Marshal(ctx)
+		if err != nil {
+			return gateway.SolveRequest{}, err
+		}
+		frontendInputs[key] = def.ToPB()
+	}
+
+	req := gateway.SolveRequest{
+		Frontend:       so.Frontend,
+		FrontendInputs: frontendInputs,
+		FrontendOpt:    make(map[string]string),
+	}
+	maps.Copy(req.FrontendOpt, so.FrontendAttrs)
+	so.Frontend = ""
+	so.FrontendInputs = nil
+
+	if opt.CallFunc != nil {
+		if _, ok := req.FrontendOpt["frontend.caps"]; !ok {
+			req.FrontendOpt["frontend.caps"] = "moby.buildkit.frontend.subrequests+forward"
+		} else {
+			req.FrontendOpt["frontend.caps"] += ",moby.buildkit.frontend.subrequests+forward"
+		}
+		req.FrontendOpt["requestid"] = "frontend." + opt.CallFunc.Name
+	}
+	return req, nil
+}
+
 func validateTargetLinks(reqForNodes map[string][]*reqForNode, drivers map[string][]*noderesolver.ResolvedNode, opts map[string]Options) error {
 	for name := range opts {
 		dps := reqForNodes[name]
@@ -496,7 +628,7 @@ func BuildWithResultHandler(ctx context.Context, nodes []builder.Node, opts map[
 	for k, opt := range opts {
 		optPlatforms[k] = opt.Platforms
 	}
-	drivers, err := noderesolver.ResolveAll(ctx, nodes, optPlatforms, w)
+	drivers, err := noderesolver.ResolveAll(ctx, nodes, optPlatforms, scheduleOpt(nodes, opts, cfg), w)
 	if err != nil {
 		return nil, err
 	}
@@ -556,32 +688,15 @@ func BuildWithResultHandler(ctx context.Context, nodes []builder.Node, opts map[
 
 			for i, dp := range dps {
 				node := dp.Node()
-				so := reqForNodes[k][i].so
+				rn := reqForNodes[k][i]
+				so := rn.so
 				if multiDriver {
-					for i, e := range so.Exports {
-						switch e.Type {
-						case "oci", "tar":
-							return errors.Errorf("%s for multi-node builds currently not supported", e.Type)
-						case "image":
-							if pushNames == "" && e.Attrs["push"] != "" {
-								if ok, _ := strconv.ParseBool(e.Attrs["push"]); ok {
-									pushNames = e.Attrs["name"]
-									if pushNames == "" {
-										return errors.Errorf("tag is needed when pushing to registry")
-									}
-									names, err := toRepoOnly(e.Attrs["name"])
-									if err != nil {
-										return err
-									}
-									if ok, _ := strconv.ParseBool(e.Attrs["registry.insecure"]); ok {
-										insecurePush = true
-									}
-									e.Attrs["name"] = names
-									e.Attrs["push-by-digest"] = "true"
-									so.Exports[i].Attrs = e.Attrs
-								}
-							}
-						}
+					names, insecure, err := multiNodeExports(so)
+					if err != nil {
+						return err
+					}
+					if pushNames == "" {
+						pushNames, insecurePush = names, insecure
 					}
 				}
 
@@ -630,7 +745,7 @@ func BuildWithResultHandler(ctx context.Context, nodes []builder.Node, opts map[
 					done = wg.Done
 				}
 
-				eg2.Go(func() error {
+				eg2.Go(func() (retErr error) {
 					if done != nil {
 						defer done()
 					}
@@ -641,26 +756,10 @@ func BuildWithResultHandler(ctx context.Context, nodes []builder.Node, opts map[
 						return err
 					}
 
-					frontendInputs := make(map[string]*pb.Definition)
-					for key, st := range so.FrontendInputs {
-						def, err := st.Marshal(ctx)
-						if err != nil {
-							return err
-						}
-						frontendInputs[key] = def.ToPB()
-					}
-
-					req := gateway.SolveRequest{
-						Frontend:       so.Frontend,
-						FrontendInputs: frontendInputs,
-						FrontendOpt:    make(map[string]string),
+					req, err := newSolveRequest(ctx, so, opt)
+					if err != nil {
+						return err
 					}
-					maps.Copy(req.FrontendOpt, so.FrontendAttrs)
-					so.Frontend = ""
-					so.FrontendInputs = nil
-
-					ch, done := progress.NewChannel(pw)
-					defer func() { <-done }()
 
 					var (
 						callRes     map[string][]byte
@@ -670,15 +769,6 @@ func BuildWithResultHandler(ctx context.Context, nodes []builder.Node, opts map[
 						// Capture the error from this build function.
 						defer catchFrontendError(&retErr, &frontendErr)
 
-						if opt.CallFunc != nil {
-							if _, ok := req.FrontendOpt["frontend.caps"]; !ok {
-								req.FrontendOpt["frontend.caps"] = "moby.buildkit.frontend.subrequests+forward"
-							} else {
-								req.FrontendOpt["frontend.caps"] += ",moby.buildkit.frontend.subrequests+forward"
-							}
-							req.FrontendOpt["requestid"] = "frontend." + opt.CallFunc.Name
-						}
-
 						res, err := solve(ctx, c, req)
 						if err != nil {
 							return nil, err
@@ -705,14 +795,66 @@ func BuildWithResultHandler(ctx context.Context, nodes []builder.Node, opts map[
 						return res, nil
 					}
 
-					buildRef := fmt.Sprintf("%s/%s/%s", node.Builder, node.Name, so.Ref)
+					// connection retries can't be used when the session or the
+					// solver vertices are shared with other targets
+					reconnect := done == nil && !linkedTargets.isLinked(rKey)
+					if !reconnect && (opt.Retry.Enabled(buildflags.RetryOnNetwork) || opt.Retry.Enabled(buildflags.RetryOnNode)) {
+						progress.Write(pw, "[retry] connection failures are not retried because the target shares its session or build steps with other targets", func() error {
+							return nil
+						})
+					}
 
 					span, ctx := tracing.StartSpan(ctx, "build")
-					rr, err := c.Build(ctx, *so, "buildx", buildFunc, ch)
-					if errors.Is(frontendErr, ErrRestart) {
-						err = ErrRestart
-					}
+					c0 := c
+					soNode := dp
+					var releaseRetry func(error)
+					defer func() {
+						if releaseRetry != nil {
+							releaseRetry(retErr)
+						}
+					}()
+					rr, rdp, c, err := buildWithRetry(ctx, opt.Retry, dp, c, reconnect, pw, func(ctx context.Context, dp *noderesolver.ResolvedNode, c *client.Client) (*client.SolveResponse, error) {
+						if dp != soNode {
+							// the build moved to another node, so the solve
+							// options are created again for its driver
+							so2, release, err := rn.retrySolveOpt(ctx, soNode, dp)
+							if err != nil {
+								return nil, err
+							}
+							if multiDriver {
+								if _, _, err := multiNodeExports(so2); err != nil {
+									release(err)
+									return nil, err
+								}
+							}
+							req2, err := newSolveRequest(ctx, so2, opt)
+							if err != nil {
+								release(err)
+								return nil, err
+							}
+							if releaseRetry != nil {
+								releaseRetry(nil)
+							}
+							so, req, soNode, releaseRetry = so2, req2, dp, release
+						}
+
+						ch, done := progress.NewChannel(pw)
+						defer func() { <-done }()
+
+						callRes, frontendErr = nil, nil
+						rr, err := c.Build(ctx, *so, "buildx", buildFunc, ch)
+						if errors.Is(frontendErr, ErrRestart) {
+							err = ErrRestart
+						}
+						return rr, err
+					})
 					tracing.FinishWithError(span, err)
+					if c != c0 {
+						defer c.Close()
+					}
+
+					node := rdp.Node()
+					buildRef := fmt.Sprintf("%s/%s/%s", node.Builder, node.Name, so.Ref)
 
 					if !so.Internal && desktop.BuildBackendEnabled() && node.Driver.HistoryAPISupported(ctx) {
 						if err != nil {
@@ -736,14 +878,17 @@ func BuildWithResultHandler(ctx context.Context, nodes []builder.Node, opts map[
 					}
 					if opt.CallFunc == nil {
 						rr.ExporterResponse["buildx.build.ref"] = buildRef
+						if strategy := nodeSchedulingStrategy(); strategy != noderesolver.StrategyFirst {
+							rr.ExporterResponse["buildx.build.node"] = node.Name
+							rr.ExporterResponse["buildx.build.scheduling"] = string(strategy)
+						}
 						if node.Driver.HistoryAPISupported(ctx) {
 							if err := setRecordProvenance(ctx, c, rr, so.Ref, opt.ProvenanceResponseMode, pw); err != nil {
 								return err
 							}
 						}
 					}
-					node := dp.Node().Driver
-					if node.IsMobyDriver() {
+					if node := node.Driver; node.IsMobyDriver() {
 						for _, e := range so.Exports {
 							if e.Type == "moby" && e.Attrs["push"] != "" && !node.Features(ctx)[driver.DirectPush] {
 								if ok, _ := strconv.ParseBool(e.Attrs["push"]); ok {
@@ -1063,6 +1208,28 @@ func remoteDigestWithMoby(ctx context.Context, d *driver.DriverHandle, name stri
 	return remoteImage.Descriptor.Digest.String(), nil
 }
 
+// scheduleOpt returns the options for spreading targets over the nodes of the
+// builder based on the BUILDX_NODE_SCHEDULING environment variable.
+func scheduleOpt(nodes []builder.Node, opts map[string]Options, cfg *confutil.Config) *noderesolver.ScheduleOpt {
+	strategy := nodeSchedulingStrategy()
+	if strategy == noderesolver.StrategyFirst {
+		return nil
+	}
+	links := map[string][]string{}
+	for k, opt := range opts {
+		for _, nc := range opt.Inputs.NamedContexts {
+			if target, ok := strings.CutPrefix(nc.Path, "target:"); ok {
+				links[k] = append(links[k], target)
+			}
+		}
+	}
+	return &noderesolver.ScheduleOpt{
+		Strategy: strategy,
+		Affinity: lastNodeLookup(nodes[0].Builder, opts, cfg),
+		Links:    links,
+	}
+}
+
 func resultKey(node *noderesolver.ResolvedNode, name string) string {
 	return fmt.Sprintf("%s-%s", node.Key(), name)
 }
@@ -67,6 +67,7 @@ type Container struct {
 	container       gateway.Container
 	releaseCh       chan struct{}
 	resultCtx       *ResultHandle
+	cfg             *InvokeConfig
 }
 
 func NewContainer(ctx context.Context, resultCtx *ResultHandle, cfg *InvokeConfig) (*Container, error) {
@@ -90,6 +91,7 @@ func NewContainer(ctx context.Context, resultCtx *ResultHandle, cfg *InvokeConfi
 				container:       bkContainer,
 				releaseCh:       releaseCh,
 				resultCtx:       resultCtx,
+				cfg:             cfg,
 			}
 			doneCh := make(chan struct{})
 			defer close(doneCh)
@@ -200,6 +202,12 @@ func (c *Container) CanInvoke(ctx context.Context, cfg *InvokeConfig) error {
 	return errors.Errorf("%s: reached symlink resolution limit", cmd)
 }
 
+// ResolvePath returns the mount containing an absolute path of the container
+// and the path relative to the root of that mount.
+func (c *Container) ResolvePath(fpath string) (string, int, error) {
+	return c.resultCtx.inferMountIndex(fpath, c.cfg)
+}
+
 func (c *Container) ReadFile(ctx context.Context, req gateway.ReadContainerRequest) ([]byte, error) {
 	return c.container.ReadFile(ctx, req)
 }
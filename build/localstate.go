@@ -2,12 +2,15 @@ package build
 
 import (
 	"path/filepath"
+	"sync"
 
 	"github.com/docker/buildx/builder"
 	"github.com/docker/buildx/localstate"
+	"github.com/docker/buildx/policy"
 	"github.com/docker/buildx/util/confutil"
 	"github.com/docker/buildx/util/urlutil"
 	"github.com/moby/buildkit/client"
+	"github.com/sirupsen/logrus"
 )
 
 func saveLocalState(so *client.SolveOpt, target string, opts Options, node builder.Node, cfg *confutil.Config) error {
@@ -43,3 +46,80 @@ func saveLocalState(so *client.SolveOpt, target string, opts Options, node build
 		GroupRef:       opts.GroupRef,
 	})
 }
+
+// moveLocalState moves the local state of a build to the node the build was
+// moved to by a retry.
+func moveLocalState(so *client.SolveOpt, target string, opts Options, from, to builder.Node, cfg *confutil.Config) error {
+	if err := saveLocalState(so, target, opts, to, cfg); err != nil {
+		return err
+	}
+	if so.Ref == "" || opts.CallFunc != nil {
+		return nil
+	}
+	l, err := localstate.New(cfg)
+	if err != nil {
+		return err
+	}
+	return l.RemoveRef(from.Builder, from.Name, so.Ref)
+}
+
+// lastNodeLookup returns a function resolving the node that last built a
+// target, used by the cache-affinity scheduling strategy. The local state is
+// read once, on the first lookup.
+func lastNodeLookup(builderName string, opts map[string]Options, cfg *confutil.Config) func(string) string {
+	var last map[localstate.TargetKey]string
+	load := sync.OnceFunc(func() {
+		if builderName == "" {
+			return
+		}
+		l, err := localstate.New(cfg)
+		if err != nil {
+			return
+		}
+		if last, err = l.LastNodes(builderName); err != nil {
+			logrus.WithError(err).Debugf("failed to read last nodes for builder %s", builderName)
+		}
+	})
+	return func(target string) string {
+		opt, ok := opts[target]
+		if !ok {
+			return ""
+		}
+		lp := opt.Inputs.ContextPath
+		if lp != "" && !urlutil.IsRemoteURL(lp) && lp != "-" {
+			var err error
+			if lp, err = filepath.Abs(lp); err != nil {
+				return ""
+			}
+		}
+		load()
+		return last[localstate.TargetKey{Target: target, LocalPath: lp}]
+	}
+}
+
+// savePolicyDecisions attaches the policy decisions made during a build to
+// its local state so they can be looked up from the build history.
+func savePolicyDecisions(ref string, node builder.Node, cfg *confutil.Config, records []policy.AuditRecord) error {
+	if ref == "" || len(records) == 0 {
+		return nil
+	}
+	l, err := localstate.New(cfg)
+	if err != nil {
+		return err
+	}
+	st, err := l.ReadRef(node.Builder, node.Name, ref)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		st.PolicyDecisions = append(st.PolicyDecisions, localstate.PolicyDecision{
+			Source:       rec.Source,
+			Kind:         rec.Kind,
+			Platform:     rec.Platform,
+			Action:       rec.Action,
+			Update:       rec.Update,
+			DenyMessages: rec.DenyMessages,
+		})
+	}
+	return l.SaveRef(node.Builder, node.Name, ref, *st)
+}
@@ -41,7 +41,6 @@ import (
 	gateway "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session"
-	"github.com/moby/buildkit/session/secrets/secretsprovider"
 	"github.com/moby/buildkit/session/sshforward/sshprovider"
 	"github.com/moby/buildkit/session/upload/uploadprovider"
 	"github.com/moby/buildkit/solver/pb"
@@ -54,6 +53,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/tonistiigi/fsutil"
+	"github.com/tonistiigi/go-csvvalue"
 )
 
 var sendGitQueryAsInput = sync.OnceValue(func() bool {
@@ -82,6 +82,18 @@ func policyExplicitlyDisabled(configs []buildflags.PolicyConfig) bool {
 	return false
 }
 
+// policyAuditLogPath returns the audit log path set by the last policy
+// config that specifies one.
+func policyAuditLogPath(configs []buildflags.PolicyConfig) string {
+	var p string
+	for _, cfg := range configs {
+		if cfg.AuditLog != "" {
+			p = cfg.AuditLog
+		}
+	}
+	return p
+}
+
 type policyProgressLogger struct {
 	ch      chan *client.SolveStatus
 	done    chan struct{}
@@ -642,13 +654,14 @@ func proxyArgKeyExists(buildArgs map[string]string, key string) bool {
 	return false
 }
 
-func configureSourcePolicy(ctx context.Context, np *noderesolver.ResolvedNode, opt *Options, cfg *confutil.Config, bopts gateway.BuildOpts, so *client.SolveOpt, pw progress.Writer) (_ []func(error), err error) {
+// buildPolicies returns the policies of a build, preceded by the builtin
+// default policy if it's enabled, and the environment they're evaluated in.
+func buildPolicies(opt *Options) ([]policyOpt, policy.Env, error) {
 	if opt.Inputs.policy == nil {
 		if len(opt.Policy) > 0 {
-			return nil, errors.New("policy file specified but no policy FS in build context")
+			return nil, policy.Env{}, errors.New("policy file specified but no policy FS in build context")
 		}
-		so.SourcePolicyProvider = nil
-		return nil, nil
+		return nil, policy.Env{}, nil
 	}
 
 	env := policy.Env{}
@@ -664,7 +677,7 @@ func configureSourcePolicy(ctx context.Context, np *noderesolver.ResolvedNode, o
 
 	popts, err := withPolicyConfig(*opt.Inputs.policy, opt.Policy)
 	if err != nil {
-		return nil, err
+		return nil, policy.Env{}, err
 	}
 
 	// Prepend the builtin default policy when enabled and not explicitly
@@ -682,7 +695,75 @@ func configureSourcePolicy(ctx context.Context, np *noderesolver.ResolvedNode, o
 		builtin.SkipCaps = true
 		popts = append([]policyOpt{builtin}, popts...)
 	}
+	return popts, env, nil
+}
+
+// checkBuildPolicy evaluates the policies of a build for its options before
+// it's scheduled on any node, and returns the recorded decisions. It's called
+// once per build, whatever the number of nodes it runs on. A client of np is
+// only opened to read policies from a remote context.
+func checkBuildPolicy(ctx context.Context, np *noderesolver.ResolvedNode, opt *Options, b *policy.Build, pw progress.Writer) (_ []policy.AuditRecord, err error) {
+	popts, env, err := buildPolicies(opt)
+	if err != nil || len(popts) == 0 {
+		return nil, err
+	}
 
+	var sourceResolver *sourcemeta.Resolver
+	if slices.ContainsFunc(popts, func(popt policyOpt) bool { return popt.ContextState != nil }) {
+		c, err := np.Client(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sourceResolver = sourcemeta.NewResolver(c, sourcemeta.WithProgressWriter(pw), sourcemeta.WithSession(opt.Session))
+		defer sourceResolver.Close()
+	}
+	loadedOpts, err := resolvePolicyOpts(ctx, popts, sourceResolver)
+	if err != nil {
+		return nil, err
+	}
+	auditLog, err := policy.NewAuditLog(policyAuditLogPath(opt.Policy))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := auditLog.Close(); err != nil {
+			logrus.WithError(err).Warn("failed to close policy audit log")
+		}
+	}()
+
+	policyLogger := newPolicyProgressLogger(pw, "checking build options against policies")
+	defer func() {
+		policyLogger.Close(err)
+	}()
+	for _, popt := range loadedOpts {
+		policyLevel := logrus.GetLevel()
+		if popt.LogLevel != nil {
+			policyLevel = *popt.LogLevel
+		}
+		p := policy.NewPolicy(policy.Opt{
+			Files: popt.Files,
+			Env:   env,
+			Log: func(level logrus.Level, msg string) {
+				if level <= policyLevel {
+					policyLogger.Log(msg)
+				}
+			},
+			FS:             popt.FS,
+			SourceResolver: sourceResolver,
+			Audit:          auditLog,
+		})
+		if err := p.CheckBuild(ctx, b); err != nil {
+			return nil, err
+		}
+	}
+	return auditLog.Records(), nil
+}
+
+func configureSourcePolicy(ctx context.Context, np *noderesolver.ResolvedNode, opt *Options, cfg *confutil.Config, bopts gateway.BuildOpts, so *client.SolveOpt, pw progress.Writer) (_ []func(error), err error) {
+	popts, env, err := buildPolicies(opt)
+	if err != nil {
+		return nil, err
+	}
 	if len(popts) == 0 {
 		so.SourcePolicyProvider = nil
 		return nil, nil
@@ -712,6 +793,18 @@ func configureSourcePolicy(ctx context.Context, np *noderesolver.ResolvedNode, o
 	if err != nil {
 		return nil, err
 	}
+	auditLog, err := policy.NewAuditLog(policyAuditLogPath(opt.Policy))
+	if err != nil {
+		return nil, err
+	}
+	defers = append(defers, func(error) {
+		if err := auditLog.Close(); err != nil {
+			logrus.WithError(err).Warn("failed to close policy audit log")
+		}
+		if err := savePolicyDecisions(so.Ref, np.Node(), cfg, auditLog.Records()); err != nil {
+			logrus.WithError(err).Debug("failed to save policy decisions")
+		}
+	})
 	var policyFiles []string
 	for _, popt := range loadedOpts {
 		for _, f := range popt.Files {
@@ -754,6 +847,7 @@ func configureSourcePolicy(ctx context.Context, np *noderesolver.ResolvedNode, o
 			VerifierProvider: policy.SignatureVerifier(cfg),
 			DefaultPlatform:  defaultPlatform(bopts),
 			SourceResolver:   sourceResolver,
+			Audit:            auditLog,
 		})
 		if !popt.SkipCaps {
 			if err := applyPolicyCaps(ctx, p, bopts, so); err != nil {
@@ -792,6 +886,67 @@ func applyPolicyCaps(ctx context.Context, p *policy.Policy, bopts gateway.BuildO
 	return nil
 }
 
+// policyBuildInput describes the options of a build for the policies
+// evaluated before the build starts. plats are the platforms of all the
+// nodes of the build.
+func policyBuildInput(opt *Options, plats []ocispecs.Platform) *policy.Build {
+	b := &policy.Build{
+		Context:      opt.Inputs.ContextPath,
+		Tags:         opt.Tags,
+		Entitlements: opt.Allow,
+		Network:      opt.NetworkMode,
+	}
+	for _, p := range plats {
+		b.Platforms = append(b.Platforms, platforms.Format(p))
+	}
+	for name, nc := range opt.Inputs.NamedContexts {
+		if nc.Path == "" {
+			continue
+		}
+		if b.Contexts == nil {
+			b.Contexts = map[string]string{}
+		}
+		b.Contexts[name] = nc.Path
+	}
+	for _, e := range opt.Exports {
+		b.Outputs = append(b.Outputs, policy.BuildOutput{Type: e.Type, Attrs: e.Attrs})
+	}
+	for _, s := range opt.SecretSpecs {
+		b.Secrets = append(b.Secrets, s.ID)
+	}
+	for _, s := range opt.SSHSpecs {
+		b.SSH = append(b.SSH, s.ID)
+	}
+	attests := make(map[string]string)
+	for k, v := range opt.Attests {
+		if v != nil {
+			attests[k] = *v
+		}
+	}
+	if _, ok := opt.Attests["provenance"]; !ok {
+		// default provenance attestation, if supported by the node
+		if noProv, _ := strconv.ParseBool(os.Getenv(noDefaultAttestationsEnv)); !noProv {
+			attests["provenance"] = "mode=min,inline-only=true"
+		}
+	}
+	for _, typ := range slices.Sorted(maps.Keys(attests)) {
+		a := policy.BuildAttestation{Type: typ}
+		fields, _ := csvvalue.Fields(attests[typ], nil)
+		for _, field := range fields {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || key == "type" {
+				continue
+			}
+			if a.Attrs == nil {
+				a.Attrs = map[string]string{}
+			}
+			a.Attrs[key] = value
+		}
+		b.Attestations = append(b.Attestations, a)
+	}
+	return b
+}
+
 func policyEnvFilename(inp Inputs) string {
 	base := filepath.Base(filepath.Clean(inp.DockerfilePath))
 	if base != "." && base != string(filepath.Separator) {
@@ -1261,22 +1416,6 @@ func CreateSSH(ssh []*buildflags.SSH) (session.Attachable, error) {
 	return sshprovider.NewSSHAgentProvider(configs)
 }
 
-func CreateSecrets(secrets []*buildflags.Secret) (session.Attachable, error) {
-	fs := make([]secretsprovider.Source, 0, len(secrets))
-	for _, secret := range secrets {
-		fs = append(fs, secretsprovider.Source{
-			ID:       secret.ID,
-			FilePath: secret.FilePath,
-			Env:      secret.Env,
-		})
-	}
-	store, err := secretsprovider.NewStore(fs)
-	if err != nil {
-		return nil, err
-	}
-	return secretsprovider.NewSecretProvider(store), nil
-}
-
 func CreateExports(entries []*buildflags.ExportEntry) ([]client.ExportEntry, []string, error) {
 	var outs []client.ExportEntry
 	var localPaths []string
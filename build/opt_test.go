@@ -228,6 +228,57 @@ decision := {
 	require.False(t, so.ProxyNetwork)
 }
 
+func TestPolicyBuildInput(t *testing.T) {
+	opt := &Options{
+		Inputs: Inputs{
+			ContextPath: ".",
+			NamedContexts: map[string]NamedContext{
+				"alpine": {Path: "docker-image://alpine"},
+			},
+		},
+		Tags:        []string{"docker.io/user/app:latest"},
+		Allow:       []string{"network.host"},
+		NetworkMode: "host",
+		Exports: []client.ExportEntry{{
+			Type:  client.ExporterImage,
+			Attrs: map[string]string{"name": "docker.io/user/app:latest", "push": "true"},
+		}},
+		Attests: map[string]*string{
+			"sbom": new("type=sbom"),
+		},
+		SecretSpecs: buildflags.Secrets{{ID: "token", Env: "TOKEN"}},
+		SSHSpecs:    []*buildflags.SSH{{ID: "default"}},
+	}
+	plats := []ocispecs.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64"},
+	}
+	t.Setenv(noDefaultAttestationsEnv, "")
+
+	require.Equal(t, &policy.Build{
+		Context:      ".",
+		Contexts:     map[string]string{"alpine": "docker-image://alpine"},
+		Platforms:    []string{"linux/amd64", "linux/arm64"},
+		Tags:         []string{"docker.io/user/app:latest"},
+		Entitlements: []string{"network.host"},
+		Network:      "host",
+		Outputs: []policy.BuildOutput{{
+			Type:  client.ExporterImage,
+			Attrs: map[string]string{"name": "docker.io/user/app:latest", "push": "true"},
+		}},
+		Secrets: []string{"token"},
+		SSH:     []string{"default"},
+		Attestations: []policy.BuildAttestation{
+			{Type: "provenance", Attrs: map[string]string{"mode": "min", "inline-only": "true"}},
+			{Type: "sbom"},
+		},
+	}, policyBuildInput(opt, plats))
+
+	// the default provenance attestation can be disabled
+	t.Setenv(noDefaultAttestationsEnv, "1")
+	require.Equal(t, []policy.BuildAttestation{{Type: "sbom"}}, policyBuildInput(opt, plats).Attestations)
+}
+
 func TestLoadInputsOCILayoutNamedContext(t *testing.T) {
 	layoutPath := t.TempDir()
 
@@ -1,10 +1,20 @@
 package build
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/containerd/platforms"
+	noderesolver "github.com/docker/buildx/build/resolver"
+	"github.com/docker/buildx/builder"
 	"github.com/docker/buildx/policy"
+	"github.com/docker/buildx/store"
 	"github.com/docker/buildx/util/buildflags"
+	"github.com/docker/buildx/util/confutil"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
@@ -47,6 +57,11 @@ func TestWithPolicyConfigDisabled(t *testing.T) {
 	})
 	require.Error(t, err)
 
+	_, err = withPolicyConfig(policyOpt{}, []buildflags.PolicyConfig{
+		{Disabled: true, AuditLog: "audit.jsonl"},
+	})
+	require.Error(t, err)
+
 	_, err = withPolicyConfig(policyOpt{}, []buildflags.PolicyConfig{
 		{Disabled: true},
 		{},
@@ -125,3 +140,76 @@ func TestWithPolicyConfigMultipleFilesAndOverrides(t *testing.T) {
 	require.False(t, out[2].Files[0].Optional)
 	require.True(t, out[2].Strict)
 }
+
+// TestPolicyAuditLogPath ensures the last configured audit log path wins.
+func TestPolicyAuditLogPath(t *testing.T) {
+	require.Empty(t, policyAuditLogPath(nil))
+	require.Equal(t, "b.jsonl", policyAuditLogPath([]buildflags.PolicyConfig{
+		{AuditLog: "a.jsonl"},
+		{Files: []policy.File{{Filename: "x.rego"}}},
+		{AuditLog: "b.jsonl"},
+		{Strict: new(true)},
+	}))
+}
+
+// TestNewBuildRequestsCheckBuildPolicyOnce ensures the build options of a
+// target running on several nodes are checked against the policies once.
+func TestNewBuildRequestsCheckBuildPolicyOnce(t *testing.T) {
+	amd64 := platforms.MustParse("linux/amd64")
+	arm64 := platforms.MustParse("linux/arm64")
+	drivers, err := noderesolver.ResolveAll(t.Context(), []builder.Node{
+		{Node: store.Node{Name: "amd64"}, Builder: "multi", Platforms: []ocispecs.Platform{amd64}},
+		{Node: store.Node{Name: "arm64"}, Builder: "multi", Platforms: []ocispecs.Platform{arm64}},
+	}, map[string][]ocispecs.Platform{"app": {amd64, arm64}}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, drivers["app"], 2)
+
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	opts := map[string]Options{
+		"app": {
+			Inputs: Inputs{
+				ContextPath: t.TempDir(),
+				policy: &policyOpt{
+					Files: []policyFileSpec{{
+						Filename: "policy.rego",
+						Data: []byte(`
+package docker
+
+default allow := false
+
+allow if input.image
+
+build_deny_msg contains msg if {
+	input.build.network == "host"
+	msg := "host network is not allowed"
+}
+
+allow if {
+	input.build
+	count(build_deny_msg) == 0
+}
+
+decision := {"allow": allow, "deny_msg": build_deny_msg}
+`),
+					}},
+				},
+			},
+			NetworkMode: "host",
+			Policy:      []buildflags.PolicyConfig{{AuditLog: auditLog}},
+		},
+	}
+
+	_, _, err = newBuildRequests(t.Context(), nil, confutil.NewConfig(nil, confutil.WithDir(t.TempDir())), drivers, nil, opts)
+	var denied *policy.BuildDeniedError
+	require.ErrorAs(t, err, &denied)
+	require.Equal(t, []string{"host network is not allowed"}, denied.Messages)
+
+	dt, err := os.ReadFile(auditLog)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(dt)), "\n")
+	require.Len(t, lines, 1)
+	var rec policy.AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &rec))
+	require.Equal(t, "linux/amd64,linux/arm64", rec.Platform)
+	require.Equal(t, "DENY", rec.Action)
+}
@@ -3,6 +3,7 @@ package resolver
 import (
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"strconv"
 	"sync"
@@ -22,15 +23,16 @@ import (
 )
 
 func Resolve(ctx context.Context, nodes []builder.Node, platforms []ocispecs.Platform, pw progress.Writer) ([]*ResolvedNode, error) {
-	result, err := ResolveAll(ctx, nodes, map[string][]ocispecs.Platform{"default": platforms}, pw)
+	result, err := ResolveAll(ctx, nodes, map[string][]ocispecs.Platform{"default": platforms}, nil, pw)
 	if err != nil {
 		return nil, err
 	}
 	return result["default"], nil
 }
 
-func ResolveAll(ctx context.Context, nodes []builder.Node, optPlatforms map[string][]ocispecs.Platform, pw progress.Writer) (map[string][]*ResolvedNode, error) {
+func ResolveAll(ctx context.Context, nodes []builder.Node, optPlatforms map[string][]ocispecs.Platform, sched *ScheduleOpt, pw progress.Writer) (map[string][]*ResolvedNode, error) {
 	driverRes := newDriverResolver(nodes)
+	driverRes.sched = newScheduler(sched)
 	drivers, err := driverRes.Resolve(ctx, optPlatforms, pw)
 	if err != nil {
 		return nil, err
@@ -72,6 +74,55 @@ func (dp ResolvedNode) Client(ctx context.Context) (*client.Client, error) {
 	return clients[0], nil
 }
 
+// Reconnect dials the node again with a new client. It is used to recover
+// from a dropped connection without affecting other builds sharing the
+// cached client of the node.
+func (dp ResolvedNode) Reconnect(ctx context.Context) (*client.Client, error) {
+	node := dp.resolver.nodes[dp.driverIndex]
+	if node.Driver == nil {
+		return nil, errors.Errorf("node %s has no driver", node.Name)
+	}
+	return node.Driver.UncachedClient(ctx)
+}
+
+// Fallback returns another node using the same driver that supports the
+// platforms of dp. Nodes with a key in exclude are skipped.
+func (dp ResolvedNode) Fallback(ctx context.Context, exclude []string) (*ResolvedNode, bool) {
+	r := dp.resolver
+	current := r.nodes[dp.driverIndex]
+	for i, n := range r.nodes {
+		if i == dp.driverIndex || n.Driver == nil || slices.Contains(exclude, strconv.Itoa(i)) {
+			continue
+		}
+		if n.Driver.Factory().Name() != current.Driver.Factory().Name() {
+			continue
+		}
+		if !supportsPlatforms(n.Platforms, dp.platforms) {
+			continue
+		}
+		if _, err := r.boot(ctx, []int{i}, nil); err != nil {
+			continue
+		}
+		return &ResolvedNode{
+			resolver:    r,
+			driverIndex: i,
+			platforms:   dp.platforms,
+		}, true
+	}
+	return nil, false
+}
+
+func supportsPlatforms(available, required []ocispecs.Platform) bool {
+	for _, p := range required {
+		if !slices.ContainsFunc(available, func(p2 ocispecs.Platform) bool {
+			return platforms.Only(p2).Match(p)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
 func (dp ResolvedNode) BuildOpts(ctx context.Context) (gateway.BuildOpts, error) {
 	opts, err := dp.resolver.opts(ctx, []int{dp.driverIndex}, nil)
 	if err != nil {
@@ -98,6 +149,7 @@ type nodeResolver struct {
 	nodes     []builder.Node
 	clients   cachedGroup[*client.Client]
 	buildOpts cachedGroup[gateway.BuildOpts]
+	sched     *scheduler
 }
 
 func newDriverResolver(nodes []builder.Node) *nodeResolver {
@@ -114,9 +166,15 @@ func (r *nodeResolver) Resolve(ctx context.Context, optPlatforms map[string][]oc
 		return nil, nil
 	}
 
+	if r.sched != nil && r.sched.needsLoad() {
+		r.loadActiveBuilds(ctx, optPlatforms)
+	}
+
+	keys := slices.Sorted(maps.Keys(optPlatforms))
+
 	nodes := map[string][]*ResolvedNode{}
-	for k, optPlatforms := range optPlatforms {
-		node, perfect, err := r.resolve(ctx, optPlatforms, pw, platforms.OnlyStrict, nil)
+	for _, k := range keys {
+		node, perfect, err := r.resolve(ctx, k, optPlatforms[k], pw, platforms.OnlyStrict, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -167,9 +225,12 @@ func (r *nodeResolver) Resolve(ctx context.Context, optPlatforms map[string][]oc
 
 		// then we can attempt to match against all the available platforms
 		// (this time we don't care about imperfect matches)
+		if r.sched != nil {
+			r.sched.reset()
+		}
 		nodes = map[string][]*ResolvedNode{}
-		for k, optPlatforms := range optPlatforms {
-			node, _, err := r.resolve(ctx, optPlatforms, pw, platforms.Only, func(idx int, n builder.Node) []ocispecs.Platform {
+		for _, k := range keys {
+			node, _, err := r.resolve(ctx, k, optPlatforms[k], pw, platforms.Only, func(idx int, n builder.Node) []ocispecs.Platform {
 				return workers[idx]
 			})
 			if err != nil {
@@ -197,7 +258,7 @@ func (r *nodeResolver) Resolve(ctx context.Context, optPlatforms map[string][]oc
 	return nodes, nil
 }
 
-func (r *nodeResolver) resolve(ctx context.Context, ps []ocispecs.Platform, pw progress.Writer, matcher matchMaker, additional func(idx int, n builder.Node) []ocispecs.Platform) ([]*ResolvedNode, bool, error) {
+func (r *nodeResolver) resolve(ctx context.Context, target string, ps []ocispecs.Platform, pw progress.Writer, matcher matchMaker, additional func(idx int, n builder.Node) []ocispecs.Platform) ([]*ResolvedNode, bool, error) {
 	if len(r.nodes) == 0 {
 		return nil, true, nil
 	}
@@ -205,7 +266,7 @@ func (r *nodeResolver) resolve(ctx context.Context, ps []ocispecs.Platform, pw p
 	perfect := true
 	nodeIdxs := make([]int, 0)
 	for _, p := range ps {
-		idx := r.get(p, matcher, additional)
+		idx := r.get(target, p, matcher, additional)
 		if idx == -1 {
 			idx = 0
 			perfect = false
@@ -240,15 +301,17 @@ func (r *nodeResolver) resolve(ctx context.Context, ps []ocispecs.Platform, pw p
 	return nodes, perfect, nil
 }
 
-func (r *nodeResolver) get(p ocispecs.Platform, matcher matchMaker, additionalPlatforms func(int, builder.Node) []ocispecs.Platform) int {
+func (r *nodeResolver) get(target string, p ocispecs.Platform, matcher matchMaker, additionalPlatforms func(int, builder.Node) []ocispecs.Platform) int {
 	best := -1
 	bestPlatform := ocispecs.Platform{}
+	nodePlatforms := make([][]ocispecs.Platform, len(r.nodes))
 	for i, node := range r.nodes {
 		platforms := node.Platforms
 		if additionalPlatforms != nil {
 			platforms = slices.Clone(platforms)
 			platforms = append(platforms, additionalPlatforms(i, node)...)
 		}
+		nodePlatforms[i] = platforms
 		for _, p2 := range platforms {
 			m := matcher(p2)
 			if !m.Match(p) {
@@ -266,7 +329,98 @@ func (r *nodeResolver) get(p ocispecs.Platform, matcher matchMaker, additionalPl
 			}
 		}
 	}
-	return best
+	if best == -1 || r.sched == nil {
+		return best
+	}
+
+	// any node providing the same best platform can be picked by the scheduler
+	bestKey := platforms.FormatAll(platforms.Normalize(bestPlatform))
+	candidates := []int{best}
+	for i, ps := range nodePlatforms {
+		if i == best {
+			continue
+		}
+		if slices.ContainsFunc(ps, func(p2 ocispecs.Platform) bool {
+			return platforms.FormatAll(platforms.Normalize(p2)) == bestKey && matcher(p2).Match(p)
+		}) {
+			candidates = append(candidates, i)
+		}
+	}
+	slices.Sort(candidates)
+
+	names := make([]string, len(r.nodes))
+	for i, n := range r.nodes {
+		names[i] = n.Name
+	}
+	return r.sched.pick(target, platforms.FormatAll(platforms.Normalize(p)), names, candidates)
+}
+
+// loadActiveBuilds fetches the number of active builds of the nodes able to
+// build one of the requested platforms so the scheduler can prefer idle nodes.
+// Nodes that are not running are not started, and nodes whose load can't be
+// read are only picked if no other candidate has a known load.
+func (r *nodeResolver) loadActiveBuilds(ctx context.Context, optPlatforms map[string][]ocispecs.Platform) {
+	if len(r.nodes) < 2 {
+		return
+	}
+	var requested []ocispecs.Platform
+	for _, ps := range optPlatforms {
+		requested = append(requested, ps...)
+	}
+	if len(requested) == 0 {
+		return
+	}
+
+	active := make([]int, len(r.nodes))
+	known := make([]bool, len(r.nodes))
+	var wg sync.WaitGroup
+	for i, n := range r.nodes {
+		if n.Driver == nil || !nodeMatchesAny(n, requested) {
+			continue
+		}
+		wg.Go(func() {
+			info, err := n.Driver.Info(ctx)
+			if err != nil || info.Status != driver.Running {
+				return
+			}
+			clients, err := r.boot(ctx, []int{i}, nil)
+			if err != nil || clients[0] == nil || !n.Driver.HistoryAPISupported(ctx) {
+				return
+			}
+			cnt, err := activeBuilds(ctx, clients[0])
+			if err != nil {
+				return
+			}
+			active[i] = cnt
+			known[i] = true
+		})
+	}
+	wg.Wait()
+
+	for i := range r.nodes {
+		if known[i] {
+			r.sched.active[i] = active[i]
+		} else {
+			r.sched.unknown[i] = struct{}{}
+		}
+	}
+}
+
+// nodeMatchesAny returns true if the node can build one of the platforms.
+// Nodes without platforms in the store haven't reported them yet and are
+// considered matching.
+func nodeMatchesAny(n builder.Node, ps []ocispecs.Platform) bool {
+	if len(n.Platforms) == 0 {
+		return true
+	}
+	for _, p := range ps {
+		if slices.ContainsFunc(n.Platforms, func(p2 ocispecs.Platform) bool {
+			return platforms.Only(p2).Match(p)
+		}) {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *nodeResolver) boot(ctx context.Context, idxs []int, pw progress.Writer) ([]*client.Client, error) {
@@ -16,7 +16,7 @@ func TestFindDriverSanity(t *testing.T) {
 		"builder-amd64": {platforms.DefaultSpec()},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.DefaultSpec()}, nil, platforms.OnlyStrict, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.DefaultSpec()}, nil, platforms.OnlyStrict, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -28,7 +28,7 @@ func TestFindDriverSanity(t *testing.T) {
 func TestFindDriverEmpty(t *testing.T) {
 	r := makeTestResolver(nil)
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.DefaultSpec()}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.DefaultSpec()}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Nil(t, res)
@@ -41,7 +41,7 @@ func TestFindDriverWeirdName(t *testing.T) {
 	})
 
 	// find first platform
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/beta")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/beta")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -54,7 +54,7 @@ func TestFindDriverUnknown(t *testing.T) {
 		"builder-amd64": {platforms.MustParse("linux/amd64")},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.False(t, perfect)
 	require.Len(t, res, 1)
@@ -69,7 +69,7 @@ func TestSelectNodeSinglePlatform(t *testing.T) {
 	})
 
 	// Request linux/amd64 platform, should match builder-amd64
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -77,7 +77,7 @@ func TestSelectNodeSinglePlatform(t *testing.T) {
 	require.Equal(t, "builder-amd64", res[0].Node().Builder)
 
 	// Request linux/riscv64 platform, should match builder-riscv64
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -85,7 +85,7 @@ func TestSelectNodeSinglePlatform(t *testing.T) {
 	require.Equal(t, "builder-riscv64", res[0].Node().Builder)
 
 	// Request unknown platform like linux/unknown, should default to first builder (builder-amd64)
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/unknown")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/unknown")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.False(t, perfect)
 	require.Len(t, res, 1)
@@ -99,21 +99,21 @@ func TestSelectNodeMultiPlatform(t *testing.T) {
 		"builder-riscv64":     {platforms.MustParse("linux/riscv64")},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
 	require.Equal(t, 0, res[0].driverIndex)
 	require.Equal(t, "builder-amd64-arm64", res[0].Node().Builder)
 
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm64")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
 	require.Equal(t, 0, res[0].driverIndex)
 	require.Equal(t, "builder-amd64-arm64", res[0].Node().Builder)
 
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -128,21 +128,21 @@ func TestSelectNodeNonStrict(t *testing.T) {
 	})
 
 	// arm64 should match itself
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm64")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
 	require.Equal(t, "builder-arm64", res[0].Node().Builder)
 
 	// arm64 may support arm/v8
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v8")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v8")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
 	require.Equal(t, "builder-arm64", res[0].Node().Builder)
 
 	// arm64 may support arm/v7
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -156,13 +156,13 @@ func TestSelectNodeNonStrictARM(t *testing.T) {
 		"builder-armv8": {platforms.MustParse("linux/arm/v8")},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v8")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v8")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
 	require.Equal(t, "builder-armv8", res[0].Node().Builder)
 
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -176,14 +176,14 @@ func TestSelectNodeNonStrictLower(t *testing.T) {
 	})
 
 	// v8 can't be built on v7 (so we should select the default)...
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v8")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v8")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.False(t, perfect)
 	require.Len(t, res, 1)
 	require.Equal(t, "builder-amd64", res[0].Node().Builder)
 
 	// ...but v6 can be built on v8
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v6")}, nil, platforms.Only, nil)
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v6")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -197,7 +197,7 @@ func TestSelectNodePreferStart(t *testing.T) {
 		"builder-riscv64-2": {platforms.MustParse("linux/riscv64")},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/riscv64")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -210,7 +210,7 @@ func TestSelectNodePreferExact(t *testing.T) {
 		"builder-armv7": {platforms.MustParse("linux/arm/v7")},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -223,7 +223,7 @@ func TestSelectNodeNoPlatform(t *testing.T) {
 		"builder-default": {platforms.DefaultSpec()},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
@@ -237,13 +237,13 @@ func TestSelectNodeAdditionalPlatforms(t *testing.T) {
 		"builder-armv8": {platforms.MustParse("linux/arm/v8")},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, nil)
 	require.NoError(t, err)
 	require.True(t, perfect)
 	require.Len(t, res, 1)
 	require.Equal(t, "builder-armv8", res[0].Node().Builder)
 
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, func(idx int, n builder.Node) []ocispecs.Platform {
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}, nil, platforms.Only, func(idx int, n builder.Node) []ocispecs.Platform {
 		if n.Builder == "builder-amd64" {
 			return []ocispecs.Platform{platforms.MustParse("linux/arm/v7")}
 		}
@@ -261,7 +261,7 @@ func TestSplitNodeMultiPlatform(t *testing.T) {
 		"builder-riscv64":     {platforms.MustParse("linux/riscv64")},
 	})
 
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{
 		platforms.MustParse("linux/amd64"),
 		platforms.MustParse("linux/arm64"),
 	}, nil, platforms.Only, nil)
@@ -270,7 +270,7 @@ func TestSplitNodeMultiPlatform(t *testing.T) {
 	require.Len(t, res, 1)
 	require.Equal(t, "builder-amd64-arm64", res[0].Node().Builder)
 
-	res, perfect, err = r.resolve(context.TODO(), []ocispecs.Platform{
+	res, perfect, err = r.resolve(context.TODO(), "default", []ocispecs.Platform{
 		platforms.MustParse("linux/amd64"),
 		platforms.MustParse("linux/riscv64"),
 	}, nil, platforms.Only, nil)
@@ -289,7 +289,7 @@ func TestSplitNodeMultiPlatformNoUnify(t *testing.T) {
 
 	// the "best" choice would be the node with both platforms, but we're using
 	// a naive algorithm that doesn't try to unify the platforms
-	res, perfect, err := r.resolve(context.TODO(), []ocispecs.Platform{
+	res, perfect, err := r.resolve(context.TODO(), "default", []ocispecs.Platform{
 		platforms.MustParse("linux/amd64"),
 		platforms.MustParse("linux/riscv64"),
 	}, nil, platforms.Only, nil)
@@ -300,16 +300,146 @@ func TestSplitNodeMultiPlatformNoUnify(t *testing.T) {
 	require.Equal(t, "builder-amd64-riscv64", res[1].Node().Builder)
 }
 
+func TestScheduleRoundRobin(t *testing.T) {
+	r := makeTestResolver(map[string][]ocispecs.Platform{
+		"builder-amd64-1": {platforms.MustParse("linux/amd64")},
+		"builder-amd64-2": {platforms.MustParse("linux/amd64")},
+		"builder-arm64":   {platforms.MustParse("linux/arm64")},
+	})
+	r.sched = newScheduler(&ScheduleOpt{Strategy: StrategyRoundRobin})
+
+	var got []string
+	for _, target := range []string{"a", "b", "c", "d"} {
+		res, perfect, err := r.resolve(context.TODO(), target, []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
+		require.NoError(t, err)
+		require.True(t, perfect)
+		require.Len(t, res, 1)
+		got = append(got, res[0].Node().Builder)
+	}
+	require.Equal(t, []string{"builder-amd64-1", "builder-amd64-2", "builder-amd64-1", "builder-amd64-2"}, got)
+
+	// nodes not providing the platform are never picked
+	res, _, err := r.resolve(context.TODO(), "e", []ocispecs.Platform{platforms.MustParse("linux/arm64")}, nil, platforms.Only, nil)
+	require.NoError(t, err)
+	require.Equal(t, "builder-arm64", res[0].Node().Builder)
+}
+
+func TestScheduleLeastLoaded(t *testing.T) {
+	r := makeTestResolver(map[string][]ocispecs.Platform{
+		"builder-amd64-1": {platforms.MustParse("linux/amd64")},
+		"builder-amd64-2": {platforms.MustParse("linux/amd64")},
+	})
+	r.sched = newScheduler(&ScheduleOpt{Strategy: StrategyLeastLoaded})
+	r.sched.active[0] = 2
+
+	var got []string
+	for _, target := range []string{"a", "b", "c", "d"} {
+		res, _, err := r.resolve(context.TODO(), target, []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
+		require.NoError(t, err)
+		got = append(got, res[0].Node().Builder)
+	}
+	require.Equal(t, []string{"builder-amd64-2", "builder-amd64-2", "builder-amd64-1", "builder-amd64-2"}, got)
+}
+
+func TestScheduleLeastLoadedUnknownLoad(t *testing.T) {
+	r := makeTestResolver(map[string][]ocispecs.Platform{
+		"builder-amd64-1": {platforms.MustParse("linux/amd64")},
+		"builder-amd64-2": {platforms.MustParse("linux/amd64")},
+	})
+	r.sched = newScheduler(&ScheduleOpt{Strategy: StrategyLeastLoaded})
+	r.sched.unknown[0] = struct{}{}
+	r.sched.active[1] = 3
+
+	// nodes with a known load are preferred over nodes that could not be queried
+	for _, target := range []string{"a", "b"} {
+		res, _, err := r.resolve(context.TODO(), target, []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
+		require.NoError(t, err)
+		require.Equal(t, "builder-amd64-2", res[0].Node().Builder)
+	}
+}
+
+func TestScheduleLinkedTargets(t *testing.T) {
+	r := makeTestResolver(map[string][]ocispecs.Platform{
+		"builder-amd64-1": {platforms.MustParse("linux/amd64")},
+		"builder-amd64-2": {platforms.MustParse("linux/amd64")},
+	})
+	r.sched = newScheduler(&ScheduleOpt{
+		Strategy: StrategyRoundRobin,
+		Links: map[string][]string{
+			"app": {"base"},
+		},
+	})
+
+	var got []string
+	for _, target := range []string{"app", "base", "other"} {
+		res, _, err := r.resolve(context.TODO(), target, []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
+		require.NoError(t, err)
+		got = append(got, res[0].Node().Builder)
+	}
+	require.Equal(t, []string{"builder-amd64-1", "builder-amd64-1", "builder-amd64-2"}, got)
+}
+
+func TestScheduleCacheAffinity(t *testing.T) {
+	r := makeTestResolver(map[string][]ocispecs.Platform{
+		"builder-amd64-1": {platforms.MustParse("linux/amd64")},
+		"builder-amd64-2": {platforms.MustParse("linux/amd64")},
+	})
+	r.sched = newScheduler(&ScheduleOpt{
+		Strategy: StrategyCacheAffinity,
+		Affinity: func(target string) string {
+			if target == "a" {
+				return "builder-amd64-2"
+			}
+			return ""
+		},
+	})
+
+	var got []string
+	for _, target := range []string{"a", "b", "c"} {
+		res, _, err := r.resolve(context.TODO(), target, []ocispecs.Platform{platforms.MustParse("linux/amd64")}, nil, platforms.Only, nil)
+		require.NoError(t, err)
+		got = append(got, res[0].Node().Builder)
+	}
+	require.Equal(t, []string{"builder-amd64-2", "builder-amd64-1", "builder-amd64-1"}, got)
+}
+
+func TestParseStrategy(t *testing.T) {
+	st, err := ParseStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, StrategyFirst, st)
+
+	st, err = ParseStrategy("Least-Loaded")
+	require.NoError(t, err)
+	require.Equal(t, StrategyLeastLoaded, st)
+
+	_, err = ParseStrategy("random")
+	require.Error(t, err)
+}
+
 func makeTestResolver(nodes map[string][]ocispecs.Platform) *nodeResolver {
 	var ns []builder.Node
 	for name, platforms := range nodes {
-		ns = append(ns, builder.Node{
+		n := builder.Node{
 			Builder:   name,
 			Platforms: platforms,
-		})
+		}
+		n.Name = name
+		ns = append(ns, n)
 	}
 	sort.Slice(ns, func(i, j int) bool {
 		return ns[i].Builder < ns[j].Builder
 	})
 	return newDriverResolver(ns)
 }
+
+func TestSupportsPlatforms(t *testing.T) {
+	available := []ocispecs.Platform{
+		platforms.MustParse("linux/amd64"),
+		platforms.MustParse("linux/arm64"),
+	}
+	require.True(t, supportsPlatforms(available, nil))
+	require.True(t, supportsPlatforms(available, []ocispecs.Platform{platforms.MustParse("linux/arm64")}))
+	require.True(t, supportsPlatforms(available, available))
+	require.False(t, supportsPlatforms(available, []ocispecs.Platform{platforms.MustParse("linux/riscv64")}))
+	require.False(t, supportsPlatforms(nil, []ocispecs.Platform{platforms.MustParse("linux/amd64")}))
+}
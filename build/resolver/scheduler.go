@@ -0,0 +1,202 @@
+package resolver
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/pkg/errors"
+)
+
+// Strategy defines how a node is picked when several nodes of a builder can
+// build the same platform equally well.
+type Strategy string
+
+const (
+	// StrategyFirst always picks the first matching node. This is the default.
+	StrategyFirst Strategy = "first"
+	// StrategyLeastLoaded picks the node with the fewest active builds.
+	StrategyLeastLoaded Strategy = "least-loaded"
+	// StrategyRoundRobin spreads targets over matching nodes in turn.
+	StrategyRoundRobin Strategy = "round-robin"
+	// StrategyCacheAffinity picks the node that previously built the same
+	// target and falls back to the least loaded node otherwise.
+	StrategyCacheAffinity Strategy = "cache-affinity"
+)
+
+func ParseStrategy(s string) (Strategy, error) {
+	switch st := Strategy(strings.ToLower(strings.TrimSpace(s))); st {
+	case "", StrategyFirst:
+		return StrategyFirst, nil
+	case StrategyLeastLoaded, StrategyRoundRobin, StrategyCacheAffinity:
+		return st, nil
+	default:
+		return "", errors.Errorf("invalid node scheduling strategy %q", s)
+	}
+}
+
+// ScheduleOpt configures how targets are spread across the nodes of a
+// builder.
+type ScheduleOpt struct {
+	Strategy Strategy
+	// Affinity returns the name of the node that last built the target. It
+	// is only used by StrategyCacheAffinity.
+	Affinity func(target string) string
+	// Links maps a target to the targets it uses as named contexts. Linked
+	// targets are always scheduled on the same node.
+	Links map[string][]string
+}
+
+// scheduler keeps the state of node assignments for a single resolve pass.
+type scheduler struct {
+	opt    ScheduleOpt
+	groups map[string]string
+
+	active   map[int]int
+	unknown  map[int]struct{}
+	assigned map[int]int
+	rr       map[string]int
+	picked   map[string]int
+}
+
+func newScheduler(opt *ScheduleOpt) *scheduler {
+	if opt == nil || opt.Strategy == "" || opt.Strategy == StrategyFirst {
+		return nil
+	}
+	s := &scheduler{
+		opt:     *opt,
+		groups:  map[string]string{},
+		active:  map[int]int{},
+		unknown: map[int]struct{}{},
+	}
+	for target, links := range opt.Links {
+		for _, l := range links {
+			s.union(target, l)
+		}
+	}
+	s.reset()
+	return s
+}
+
+func (s *scheduler) reset() {
+	s.assigned = map[int]int{}
+	s.rr = map[string]int{}
+	s.picked = map[string]int{}
+}
+
+func (s *scheduler) needsLoad() bool {
+	return s.opt.Strategy == StrategyLeastLoaded || s.opt.Strategy == StrategyCacheAffinity
+}
+
+func (s *scheduler) find(target string) string {
+	p, ok := s.groups[target]
+	if !ok || p == target {
+		return target
+	}
+	root := s.find(p)
+	s.groups[target] = root
+	return root
+}
+
+func (s *scheduler) union(a, b string) {
+	ra, rb := s.find(a), s.find(b)
+	if ra == rb {
+		return
+	}
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	s.groups[rb] = ra
+}
+
+// pick returns the index of the node used for building the platform with
+// the given key for target from the candidates able to build it.
+func (s *scheduler) pick(target, platformKey string, nodes []string, candidates []int) int {
+	key := s.find(target) + "/" + platformKey
+	if idx, ok := s.picked[key]; ok {
+		for _, c := range candidates {
+			if c == idx {
+				return idx
+			}
+		}
+	}
+
+	idx := candidates[0]
+	if len(candidates) > 1 {
+		switch s.opt.Strategy {
+		case StrategyRoundRobin:
+			idx = candidates[s.rr[platformKey]%len(candidates)]
+			s.rr[platformKey]++
+		case StrategyCacheAffinity:
+			idx = s.leastLoaded(candidates)
+			if s.opt.Affinity != nil {
+				if name := s.opt.Affinity(target); name != "" {
+					for _, c := range candidates {
+						if nodes[c] == name {
+							idx = c
+							break
+						}
+					}
+				}
+			}
+		case StrategyLeastLoaded:
+			idx = s.leastLoaded(candidates)
+		}
+	}
+	s.picked[key] = idx
+	s.assigned[idx]++
+	return idx
+}
+
+func (s *scheduler) leastLoaded(candidates []int) int {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		_, unknown := s.unknown[c]
+		_, bestUnknown := s.unknown[best]
+		if unknown != bestUnknown {
+			if bestUnknown {
+				best = c
+			}
+			continue
+		}
+		if s.active[c]+s.assigned[c] < s.active[best]+s.assigned[best] {
+			best = c
+		}
+	}
+	return best
+}
+
+// activeBuilds returns the number of builds currently running on a node
+// using the history API.
+func activeBuilds(ctx context.Context, c *client.Client) (int, error) {
+	cl, err := c.ControlClient().ListenBuildHistory(ctx, &controlapi.BuildHistoryRequest{
+		ActiveOnly: true,
+		EarlyExit:  true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cl.CloseSend()
+
+	refs := map[string]struct{}{}
+	for {
+		ev, err := cl.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return 0, err
+		}
+		if ev.Record == nil {
+			continue
+		}
+		if ev.Type == controlapi.BuildHistoryEventType_DELETED || ev.Record.CompletedAt != nil {
+			delete(refs, ev.Record.Ref)
+			continue
+		}
+		refs[ev.Record.Ref] = struct{}{}
+	}
+	return len(refs), nil
+}
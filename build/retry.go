@@ -0,0 +1,190 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	noderesolver "github.com/docker/buildx/build/resolver"
+	"github.com/docker/buildx/util/buildflags"
+	"github.com/docker/buildx/util/progress"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+const maxRetryDelay = 10 * time.Second
+
+// retryDelay is the delay before the second attempt, doubled for every
+// following attempt. It is a variable so tests don't have to wait.
+var retryDelay = time.Second
+
+// unexpectedStatusRe matches the message of a containerd
+// ErrUnexpectedStatus for a registry write request. The typed error doesn't
+// survive the gRPC boundary with BuildKit, only its message does.
+var unexpectedStatusRe = regexp.MustCompile(`unexpected status from (PUT|POST|PATCH) request to \S+: (\d{3})\b`)
+
+var connectionErrors = []string{
+	"transport is closing",
+	"connection reset by peer",
+	"broken pipe",
+	"error reading from server: EOF",
+}
+
+// retryReason returns the retry condition matching a build error, or an
+// empty string if the error is not transient.
+func retryReason(err error) string {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrRestart) {
+		return ""
+	}
+	if isPushServerError(err) {
+		return buildflags.RetryOnPush
+	}
+	if isConnectionError(err) {
+		return buildflags.RetryOnNetwork
+	}
+	return ""
+}
+
+// isPushServerError returns true if a registry write request failed with a
+// server error (5xx).
+func isPushServerError(err error) bool {
+	var se remoteerrors.ErrUnexpectedStatus
+	if errors.As(err, &se) {
+		return isRegistryWrite(se.RequestMethod) && se.StatusCode >= 500 && se.StatusCode < 600
+	}
+	m := unexpectedStatusRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return false
+	}
+	code, err := strconv.Atoi(m[2])
+	return err == nil && isRegistryWrite(m[1]) && code >= 500 && code < 600
+}
+
+func isRegistryWrite(method string) bool {
+	switch method {
+	case http.MethodPut, http.MethodPost, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+func isConnectionError(err error) bool {
+	if grpcerrors.Code(err) == codes.Unavailable {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	msg := err.Error()
+	for _, s := range connectionErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type buildAttemptFunc func(ctx context.Context, dp *noderesolver.ResolvedNode, c *client.Client) (*client.SolveResponse, error)
+
+// buildWithRetry runs fn and retries it on transient failures allowed by
+// the policy. Push failures are retried with the same client. Connection
+// failures re-dial the node, or move the build to another node supporting
+// the same platforms if the node can't be reached. Connection failures are
+// not retried if reconnect is false. It returns the node and client used by
+// the last attempt. Clients dialed for a retry are closed when they are
+// replaced, and the caller must close the returned client if it differs
+// from c.
+func buildWithRetry(ctx context.Context, policy *buildflags.RetryPolicy, dp *noderesolver.ResolvedNode, c *client.Client, reconnect bool, pw progress.Writer, fn buildAttemptFunc) (*client.SolveResponse, *noderesolver.ResolvedNode, *client.Client, error) {
+	var tried []string
+	orig := c
+	for attempt := 1; ; attempt++ {
+		resp, err := fn(ctx, dp, c)
+		if err == nil || policy == nil || attempt >= policy.Attempts {
+			return resp, dp, c, err
+		}
+
+		reason := retryReason(err)
+		switch {
+		case reason == buildflags.RetryOnPush && policy.Enabled(buildflags.RetryOnPush):
+		case reason == buildflags.RetryOnNetwork && reconnect && (policy.Enabled(buildflags.RetryOnNetwork) || policy.Enabled(buildflags.RetryOnNode)):
+		default:
+			return resp, dp, c, err
+		}
+		logrus.Debugf("build attempt %d/%d on node %s failed: %v", attempt, policy.Attempts, dp.Node().Name, err)
+
+		delay := min(retryDelay<<(attempt-1), maxRetryDelay)
+		select {
+		case <-ctx.Done():
+			return resp, dp, c, err
+		case <-time.After(delay):
+		}
+
+		if reason == buildflags.RetryOnNetwork {
+			dp2, c2, ok := reconnectNode(ctx, policy, dp, &tried)
+			if !ok {
+				return resp, dp, c, err
+			}
+			if dp2 != dp {
+				reason = buildflags.RetryOnNode
+			}
+			if c != orig {
+				c.Close()
+			}
+			dp, c = dp2, c2
+		}
+		progress.Write(pw, fmt.Sprintf("[retry] retrying after %s failure (attempt %d/%d on %s)", reason, attempt+1, policy.Attempts, dp.Node().Name), func() error {
+			return nil
+		})
+	}
+}
+
+// reconnectNode re-dials the node of a build, or finds another node
+// supporting the same platforms if the node can't be reached.
+func reconnectNode(ctx context.Context, policy *buildflags.RetryPolicy, dp *noderesolver.ResolvedNode, tried *[]string) (*noderesolver.ResolvedNode, *client.Client, bool) {
+	if policy.Enabled(buildflags.RetryOnNetwork) {
+		c, err := redial(ctx, dp)
+		if err == nil {
+			return dp, c, true
+		}
+		logrus.Debugf("failed to reconnect to node %s: %v", dp.Node().Name, err)
+	}
+	if !policy.Enabled(buildflags.RetryOnNode) {
+		return nil, nil, false
+	}
+	*tried = append(*tried, dp.Key())
+	for {
+		dp2, ok := dp.Fallback(ctx, *tried)
+		if !ok {
+			return nil, nil, false
+		}
+		c, err := redial(ctx, dp2)
+		if err == nil {
+			return dp2, c, true
+		}
+		logrus.Debugf("failed to connect to fallback node %s: %v", dp2.Node().Name, err)
+		*tried = append(*tried, dp2.Key())
+	}
+}
+
+// redial opens a new connection to the node and checks that it responds.
+func redial(ctx context.Context, dp *noderesolver.ResolvedNode) (*client.Client, error) {
+	c, err := dp.Reconnect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.Info(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,115 @@
+package build
+
+import (
+	"context"
+	"io"
+	"syscall"
+	"testing"
+
+	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	"github.com/docker/buildx/util/buildflags"
+	"github.com/moby/buildkit/client"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:     "push server error",
+			err:      errors.New("failed to push docker.io/user/app:latest: unexpected status from PUT request to https://registry-1.docker.io/v2/user/app/manifests/latest: 503 Service Unavailable"),
+			expected: buildflags.RetryOnPush,
+		},
+		{
+			name: "typed push server error",
+			err: errors.Wrap(remoteerrors.ErrUnexpectedStatus{
+				Status:        "502 Bad Gateway",
+				StatusCode:    502,
+				RequestMethod: "PATCH",
+				RequestURL:    "https://registry.example.com/v2/app/blobs/uploads/1",
+			}, "failed to push"),
+			expected: buildflags.RetryOnPush,
+		},
+		{
+			name: "pull server error",
+			err:  errors.New("failed to resolve source metadata for docker.io/library/alpine:latest: unexpected status from GET request to https://registry-1.docker.io/v2/library/alpine/manifests/latest: 503 Service Unavailable"),
+		},
+		{
+			name: "server error mentioning push",
+			err:  errors.New(`process "/bin/sh -c ./push.sh" did not complete successfully: HTTP 500 Internal Server Error`),
+		},
+		{
+			name: "push client error",
+			err:  errors.New("failed to push docker.io/user/app:latest: unexpected status from PUT request: 401 Unauthorized"),
+		},
+		{
+			name:     "unavailable",
+			err:      errors.Wrap(status.Error(codes.Unavailable, "connection error"), "failed to solve"),
+			expected: buildflags.RetryOnNetwork,
+		},
+		{
+			name:     "connection reset",
+			err:      errors.Wrap(syscall.ECONNRESET, "read"),
+			expected: buildflags.RetryOnNetwork,
+		},
+		{
+			name:     "unexpected eof",
+			err:      errors.Wrap(io.ErrUnexpectedEOF, "receiving status"),
+			expected: buildflags.RetryOnNetwork,
+		},
+		{
+			name:     "transport closing",
+			err:      errors.New("rpc error: code = Unknown desc = transport is closing"),
+			expected: buildflags.RetryOnNetwork,
+		},
+		{
+			name: "build error",
+			err:  errors.New(`process "/bin/sh -c exit 1" did not complete successfully: exit code: 1`),
+		},
+		{
+			name: "canceled",
+			err:  errors.Wrap(context.Canceled, "transport is closing"),
+		},
+		{
+			name: "restart",
+			err:  ErrRestart,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, retryReason(tt.err))
+		})
+	}
+}
+
+func TestCloneOutputs(t *testing.T) {
+	opt := Options{
+		Exports: []client.ExportEntry{
+			{Type: "image", Attrs: map[string]string{"name": "docker.io/foo/bar:latest", "push": "true"}},
+		},
+		CacheTo: make([]client.CacheOptionsEntry, 0, 1),
+	}
+	retryOpt := cloneOutputs(opt)
+
+	// exports modified for a node are not shared with the build on another node
+	so := &client.SolveOpt{Exports: opt.Exports}
+	names, insecure, err := multiNodeExports(so)
+	require.NoError(t, err)
+	require.Equal(t, "docker.io/foo/bar:latest", names)
+	require.False(t, insecure)
+	require.Equal(t, "true", opt.Exports[0].Attrs["push-by-digest"])
+	require.Equal(t, "docker.io/foo/bar", opt.Exports[0].Attrs["name"])
+	require.Equal(t, map[string]string{"name": "docker.io/foo/bar:latest", "push": "true"}, retryOpt.Exports[0].Attrs)
+
+	opt.CacheTo = append(opt.CacheTo, client.CacheOptionsEntry{Type: "inline"})
+	require.Empty(t, retryOpt.CacheTo[:cap(retryOpt.CacheTo)])
+}
@@ -0,0 +1,239 @@
+package build
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"sync"
+
+	"github.com/docker/buildx/util/buildflags"
+	"github.com/docker/cli/cli/config"
+	"github.com/google/shlex"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/auth/authprovider"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/pkg/errors"
+)
+
+// SecretStorePassphraseEnv is the environment variable holding the
+// passphrase of file-store secrets.
+const SecretStorePassphraseEnv = "BUILDX_SECRET_STORE_PASSPHRASE"
+
+const (
+	// file-store secrets are encrypted with the format of
+	// "openssl enc -aes-256-cbc -pbkdf2 -salt"
+	fileStoreMagic      = "Salted__"
+	fileStoreSaltLen    = 8
+	fileStoreIterations = 10000
+)
+
+// CreateSecrets returns the session attachable providing the secrets of a
+// build. File and env secrets are checked when the attachable is created,
+// other secret types are only resolved when the build requests them. If
+// authConfig is nil, docker-credential secrets are read from the default
+// docker config.
+func CreateSecrets(secrets []*buildflags.Secret, authConfig authprovider.AuthConfigProvider) (session.Attachable, error) {
+	store, err := newSecretStore(secrets, authConfig)
+	if err != nil {
+		return nil, err
+	}
+	return secretsprovider.NewSecretProvider(store), nil
+}
+
+func newSecretStore(specs []*buildflags.Secret, authConfig authprovider.AuthConfigProvider) (secrets.SecretStore, error) {
+	fs := make([]secretsprovider.Source, 0, len(specs))
+	providers := map[string]*buildflags.Secret{}
+	values := map[string][]byte{}
+	for _, secret := range specs {
+		if err := secret.Validate(); err != nil {
+			return nil, err
+		}
+		if secret.Value != "" {
+			if secret.ID == "" {
+				return nil, errors.Errorf("secret missing ID")
+			}
+			providers[secret.ID] = secret
+			values[secret.ID] = []byte(secret.Value)
+			continue
+		}
+		if secret.Type != "" {
+			if secret.ID == "" {
+				return nil, errors.Errorf("secret missing ID")
+			}
+			providers[secret.ID] = secret
+			continue
+		}
+		fs = append(fs, secretsprovider.Source{
+			ID:       secret.ID,
+			FilePath: secret.FilePath,
+			Env:      secret.Env,
+		})
+	}
+	store, err := secretsprovider.NewStore(fs)
+	if err != nil {
+		return nil, err
+	}
+	if len(providers) == 0 {
+		return store, nil
+	}
+	return &lazySecretStore{
+		base:       store,
+		providers:  providers,
+		authConfig: authConfig,
+		cache:      values,
+	}, nil
+}
+
+// lazySecretStore resolves the secrets of a provider on the first request
+// and keeps them for the following ones.
+type lazySecretStore struct {
+	base       secrets.SecretStore
+	providers  map[string]*buildflags.Secret
+	authConfig authprovider.AuthConfigProvider
+
+	mu    sync.Mutex
+	cache map[string][]byte
+}
+
+func (s *lazySecretStore) GetSecret(ctx context.Context, id string) ([]byte, error) {
+	secret, ok := s.providers[id]
+	if !ok {
+		return s.base.GetSecret(ctx, id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if dt, ok := s.cache[id]; ok {
+		return dt, nil
+	}
+
+	var dt []byte
+	var err error
+	switch secret.Type {
+	case buildflags.SecretTypeCmd:
+		dt, err = cmdSecret(ctx, secret.Cmd)
+	case buildflags.SecretTypeDockerCredential:
+		dt, err = s.dockerCredentialSecret(ctx, secret.Host, secret.Field)
+	case buildflags.SecretTypeFileStore:
+		dt, err = fileStoreSecret(secret.FilePath, cmp.Or(secret.Key, secret.ID))
+	default:
+		err = errors.Errorf("unsupported secret type %q", secret.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s", id)
+	}
+	s.cache[id] = dt
+	return dt, nil
+}
+
+// cmdSecret runs a local command and returns its output without the trailing
+// newline.
+func cmdSecret(ctx context.Context, cmd string) ([]byte, error) {
+	args, err := shlex.Split(cmd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse secret command %q", cmd)
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty secret command")
+	}
+	var stdout, stderr bytes.Buffer
+	c := osexec.CommandContext(ctx, args[0], args[1:]...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Wrapf(err, "secret command %q failed: %s", args[0], msg)
+		}
+		return nil, errors.Wrapf(err, "secret command %q failed", args[0])
+	}
+	dt := stdout.Bytes()
+	dt = bytes.TrimSuffix(dt, []byte("\n"))
+	dt = bytes.TrimSuffix(dt, []byte("\r"))
+	return dt, nil
+}
+
+func (s *lazySecretStore) dockerCredentialSecret(ctx context.Context, host, field string) ([]byte, error) {
+	if s.authConfig == nil {
+		s.authConfig = authprovider.LoadAuthConfig(config.LoadDefaultConfigFile(io.Discard))
+	}
+	if host == "docker.io" || host == "index.docker.io" {
+		host = authprovider.DockerHubRegistryHost
+	}
+	ac, err := s.authConfig(ctx, host, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var v string
+	if field == "username" {
+		v = ac.Username
+	} else {
+		v = cmp.Or(ac.Password, ac.IdentityToken)
+	}
+	if v == "" {
+		return nil, errors.Errorf("no docker credentials found for %s", host)
+	}
+	return []byte(v), nil
+}
+
+// fileStoreSecret returns the value of key in an encrypted store. The store
+// is a JSON object of string values encrypted with the passphrase set in
+// BUILDX_SECRET_STORE_PASSPHRASE, e.g. with:
+//
+//	openssl enc -aes-256-cbc -pbkdf2 -salt -in secrets.json -out secrets.enc
+func fileStoreSecret(path, key string) ([]byte, error) {
+	passphrase, ok := os.LookupEnv(SecretStorePassphraseEnv)
+	if !ok {
+		return nil, errors.Errorf("%s is not set", SecretStorePassphraseEnv)
+	}
+	dt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decryptFileStore(dt, passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read secret store %s", path)
+	}
+	v, ok := m[key]
+	if !ok {
+		return nil, errors.Errorf("key %q not found in secret store %s", key, path)
+	}
+	return []byte(v), nil
+}
+
+func decryptFileStore(dt []byte, passphrase string) (map[string]string, error) {
+	hdr := len(fileStoreMagic) + fileStoreSaltLen
+	if !bytes.HasPrefix(dt, []byte(fileStoreMagic)) || len(dt) <= hdr || (len(dt)-hdr)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid encrypted store format")
+	}
+	salt := dt[len(fileStoreMagic):hdr]
+	k, err := pbkdf2.Key(sha256.New, passphrase, salt, fileStoreIterations, 32+aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k[:32])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(dt)-hdr)
+	cipher.NewCBCDecrypter(block, k[32:]).CryptBlocks(out, dt[hdr:])
+
+	n := int(out[len(out)-1])
+	if n == 0 || n > aes.BlockSize || !bytes.Equal(out[len(out)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, errors.New("failed to decrypt, invalid passphrase")
+	}
+	var m map[string]string
+	if err := json.Unmarshal(out[:len(out)-n], &m); err != nil {
+		return nil, errors.New("failed to decrypt, invalid passphrase")
+	}
+	return m, nil
+}
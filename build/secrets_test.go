@@ -0,0 +1,113 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/buildx/util/buildflags"
+	"github.com/docker/cli/cli/config/types"
+	"github.com/moby/buildkit/session/auth/authprovider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazySecretStore(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+
+	var hosts []string
+	authConfig := func(_ context.Context, host string, _ []string, _ authprovider.ExpireCachedAuthCheck) (types.AuthConfig, error) {
+		hosts = append(hosts, host)
+		return types.AuthConfig{Username: "user", Password: "hunter2"}, nil
+	}
+
+	storePath := filepath.Join(dir, "secrets.enc")
+	require.NoError(t, os.WriteFile(storePath, encryptFileStore(t, []byte(`{"npm":"npm-token","pypi":"pypi-token"}`), "passphrase"), 0600))
+	t.Setenv(SecretStorePassphraseEnv, "passphrase")
+
+	s, err := newSecretStore([]*buildflags.Secret{
+		{ID: "cmd", Type: buildflags.SecretTypeCmd, Cmd: "sh -c 'touch " + marker + " && echo cmd-value'"},
+		{ID: "hub", Type: buildflags.SecretTypeDockerCredential, Host: "docker.io"},
+		{ID: "hub_user", Type: buildflags.SecretTypeDockerCredential, Host: "docker.io", Field: "username"},
+		{ID: "npm", Type: buildflags.SecretTypeFileStore, FilePath: storePath},
+		{ID: "pip", Type: buildflags.SecretTypeFileStore, FilePath: storePath, Key: "pypi"},
+		{ID: "missing", Type: buildflags.SecretTypeFileStore, FilePath: storePath},
+		{ID: "token", Env: "TOKEN", Value: "token-value"},
+	}, authConfig)
+	require.NoError(t, err)
+
+	// secrets are not resolved until they are requested
+	require.NoFileExists(t, marker)
+	require.Empty(t, hosts)
+
+	dt, err := s.GetSecret(context.TODO(), "cmd")
+	require.NoError(t, err)
+	require.Equal(t, "cmd-value", string(dt))
+	require.FileExists(t, marker)
+
+	// results are cached for the session
+	require.NoError(t, os.Remove(marker))
+	dt, err = s.GetSecret(context.TODO(), "cmd")
+	require.NoError(t, err)
+	require.Equal(t, "cmd-value", string(dt))
+	require.NoFileExists(t, marker)
+
+	dt, err = s.GetSecret(context.TODO(), "hub")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(dt))
+	dt, err = s.GetSecret(context.TODO(), "hub_user")
+	require.NoError(t, err)
+	require.Equal(t, "user", string(dt))
+	require.Equal(t, []string{authprovider.DockerHubRegistryHost, authprovider.DockerHubRegistryHost}, hosts)
+
+	dt, err = s.GetSecret(context.TODO(), "npm")
+	require.NoError(t, err)
+	require.Equal(t, "npm-token", string(dt))
+	dt, err = s.GetSecret(context.TODO(), "pip")
+	require.NoError(t, err)
+	require.Equal(t, "pypi-token", string(dt))
+	_, err = s.GetSecret(context.TODO(), "missing")
+	require.ErrorContains(t, err, `key "missing" not found`)
+
+	// values set by the caller take precedence over the environment
+	t.Setenv("TOKEN", "env-value")
+	dt, err = s.GetSecret(context.TODO(), "token")
+	require.NoError(t, err)
+	require.Equal(t, "token-value", string(dt))
+}
+
+func TestCmdSecretError(t *testing.T) {
+	_, err := cmdSecret(context.TODO(), "sh -c 'echo denied >&2; exit 1'")
+	require.ErrorContains(t, err, "denied")
+}
+
+func TestDecryptFileStoreInvalidPassphrase(t *testing.T) {
+	dt := encryptFileStore(t, []byte(`{"npm":"npm-token"}`), "passphrase")
+	_, err := decryptFileStore(dt, "wrong")
+	require.ErrorContains(t, err, "invalid passphrase")
+
+	_, err = decryptFileStore([]byte(`{"npm":"npm-token"}`), "passphrase")
+	require.ErrorContains(t, err, "invalid encrypted store format")
+}
+
+// encryptFileStore encrypts dt like
+// "openssl enc -aes-256-cbc -pbkdf2 -salt -S 0102030405060708".
+func encryptFileStore(t *testing.T, dt []byte, passphrase string) []byte {
+	t.Helper()
+	salt := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	k, err := pbkdf2.Key(sha256.New, passphrase, salt, fileStoreIterations, 32+aes.BlockSize)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(k[:32])
+	require.NoError(t, err)
+	n := aes.BlockSize - len(dt)%aes.BlockSize
+	dt = append(dt, bytes.Repeat([]byte{byte(n)}, n)...)
+	out := make([]byte, len(dt))
+	cipher.NewCBCEncrypter(block, k[32:]).CryptBlocks(out, dt)
+	return append(append([]byte(fileStoreMagic), salt...), out...)
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	noderesolver "github.com/docker/buildx/build/resolver"
 	"github.com/docker/buildx/driver"
 	"github.com/docker/cli/opts"
 	"github.com/pkg/errors"
@@ -207,3 +208,16 @@ func noDefaultLoad() bool {
 	}
 	return b
 }
+
+func nodeSchedulingStrategy() noderesolver.Strategy {
+	v, ok := os.LookupEnv("BUILDX_NODE_SCHEDULING")
+	if !ok {
+		return noderesolver.StrategyFirst
+	}
+	st, err := noderesolver.ParseStrategy(v)
+	if err != nil {
+		logrus.Warnf("invalid value for BUILDX_NODE_SCHEDULING: %s", v)
+		return noderesolver.StrategyFirst
+	}
+	return st
+}
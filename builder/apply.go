@@ -0,0 +1,333 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/docker/buildx/driver"
+	"github.com/docker/buildx/localstate"
+	"github.com/docker/buildx/store"
+	"github.com/docker/buildx/util/confutil"
+	"github.com/docker/buildx/util/platformutil"
+	"github.com/docker/cli/cli/command"
+	buildkitdconfig "github.com/moby/buildkit/cmd/buildkitd/config"
+	"github.com/pkg/errors"
+)
+
+type NodeAction string
+
+const (
+	NodeActionAdd       NodeAction = "add"
+	NodeActionUpdate    NodeAction = "update"
+	NodeActionLeave     NodeAction = "leave"
+	NodeActionUnchanged NodeAction = "unchanged"
+)
+
+// NodeChange is the change applied to a node of a builder instance.
+type NodeChange struct {
+	Name   string
+	Action NodeAction
+	// Fields are the settings of an updated node that changed.
+	Fields []string `json:",omitempty"`
+}
+
+// ApplyPlan holds the changes needed to make a builder instance match its
+// definition.
+type ApplyPlan struct {
+	Name   string
+	Driver string
+	// Create is true if the builder instance doesn't exist yet.
+	Create bool
+	Nodes  []NodeChange
+
+	current *store.NodeGroup
+	desired *store.NodeGroup
+}
+
+// Changed reports whether applying the plan changes the builder instance.
+func (p *ApplyPlan) Changed() bool {
+	if p.Create {
+		return true
+	}
+	for _, n := range p.Nodes {
+		if n.Action != NodeActionUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanApply compares the definition of a builder instance with the one in
+// the store and returns the changes needed to apply it. Nodes of the
+// instance that are not in the definition leave it.
+func PlanApply(ctx context.Context, txn *store.Txn, dockerCli command.Cli, bd BuilderDefinition) (*ApplyPlan, error) {
+	cur, err := txn.NodeGroupByName(bd.Name)
+	if err != nil {
+		if !os.IsNotExist(errors.Cause(err)) {
+			return nil, err
+		}
+		cur = nil
+	}
+
+	if cur == nil {
+		contexts, err := dockerCli.ContextStore().List()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range contexts {
+			if c.Name == bd.Name {
+				return nil, errors.Errorf("instance name %q already exists as context builder", bd.Name)
+			}
+		}
+	} else if cur.Dynamic {
+		return nil, errors.Errorf("dynamic builder %q can't be applied", bd.Name)
+	}
+
+	driverName := bd.Driver
+	if driverName == "" {
+		if cur != nil {
+			driverName = cur.Driver
+		} else {
+			f, err := driver.GetDefaultFactory(ctx, bd.Nodes[0].Endpoint, dockerCli.Client(), true, nil)
+			if err != nil {
+				return nil, err
+			}
+			if f == nil {
+				return nil, errors.Errorf("no valid drivers found")
+			}
+			driverName = f.Name()
+		}
+	}
+	if _, err := driver.GetFactory(driverName, true); err != nil {
+		return nil, err
+	}
+	if cur != nil && cur.Driver != driverName {
+		return nil, errors.Errorf("driver of builder %q can't be changed from %q to %q, remove the builder first", bd.Name, cur.Driver, driverName)
+	}
+
+	p := &ApplyPlan{
+		Name:    bd.Name,
+		Driver:  driverName,
+		Create:  cur == nil,
+		current: cur,
+		desired: &store.NodeGroup{
+			Name:   bd.Name,
+			Driver: driverName,
+		},
+	}
+
+	var curNodes []store.Node
+	if cur != nil {
+		curNodes = cur.Nodes
+	}
+	endpoints := map[string]string{}
+	for _, nd := range bd.Nodes {
+		var curNode *store.Node
+		if i := slices.IndexFunc(curNodes, func(n store.Node) bool { return n.Name == nd.Name }); i != -1 {
+			curNode = &curNodes[i]
+		}
+		n, err := definitionNode(txn, dockerCli, bd.Name, driverName, nd, curNode)
+		if err != nil {
+			return nil, errors.Wrapf(err, "builder %q: node %q", bd.Name, nd.Name)
+		}
+		if other, ok := endpoints[n.Endpoint]; ok {
+			return nil, errors.Errorf("builder %q: nodes %q and %q have the same endpoint %s", bd.Name, other, n.Name, n.Endpoint)
+		}
+		endpoints[n.Endpoint] = n.Name
+		p.desired.Nodes = append(p.desired.Nodes, *n)
+
+		switch {
+		case curNode == nil:
+			p.Nodes = append(p.Nodes, NodeChange{Name: n.Name, Action: NodeActionAdd})
+		default:
+			if fields := changedFields(curNode, n); len(fields) > 0 {
+				p.Nodes = append(p.Nodes, NodeChange{Name: n.Name, Action: NodeActionUpdate, Fields: fields})
+			} else {
+				p.Nodes = append(p.Nodes, NodeChange{Name: n.Name, Action: NodeActionUnchanged})
+			}
+		}
+	}
+	for _, n := range curNodes {
+		if !slices.ContainsFunc(bd.Nodes, func(nd NodeDefinition) bool { return nd.Name == n.Name }) {
+			p.Nodes = append(p.Nodes, NodeChange{Name: n.Name, Action: NodeActionLeave})
+		}
+	}
+	return p, nil
+}
+
+// ApplyOpts are the options of Apply.
+type ApplyOpts struct {
+	Timeout time.Duration
+}
+
+// Apply saves the builder instance of plan p to the store. The BuildKit
+// daemons of updated nodes are removed so they are recreated with the new
+// settings on the next boot, while keeping their state. Nodes where only
+// the platforms changed are left running. Nodes leaving the
+// instance are removed along with their state, like with "buildx rm".
+func Apply(ctx context.Context, txn *store.Txn, dockerCli command.Cli, p *ApplyPlan, opts ApplyOpts) (*Builder, error) {
+	restart := map[string]bool{}
+	for _, n := range p.Nodes {
+		switch n.Action {
+		case NodeActionUpdate:
+			// platforms are only used by buildx for scheduling
+			if slices.ContainsFunc(n.Fields, func(f string) bool { return f != "platforms" }) {
+				restart[n.Name] = false
+			}
+		case NodeActionLeave:
+			restart[n.Name] = true
+		}
+	}
+
+	// load the nodes to restart with their current settings before they
+	// are replaced in the store
+	var nodes []Node
+	if p.current != nil && len(restart) > 0 {
+		b, err := New(dockerCli,
+			WithName(p.Name),
+			WithStore(txn),
+			WithSkippedValidation(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err = b.LoadNodes(ctx, WithSkippedImageOpt())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := txn.Save(p.desired); err != nil {
+		return nil, err
+	}
+	b, err := loadSaved(ctx, txn, dockerCli, p.desired, p.current, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	ls, err := localstate.New(confutil.NewConfig(dockerCli))
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, node := range nodes {
+		rmState, ok := restart[node.Name]
+		if !ok {
+			continue
+		}
+		if rmState {
+			if err := ls.RemoveBuilderNode(p.Name, node.Name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if node.Driver == nil {
+			continue
+		}
+		if err := node.Driver.Stop(ctx, true); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to stop node %s", node.Name))
+		}
+		if err := node.Driver.Rm(ctx, true, rmState, true); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to remove node %s", node.Name))
+		}
+	}
+	if err := stderrors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// definitionNode returns the node of builder name defined by nd. The
+// endpoint of the current node cur is kept if nd doesn't set one.
+func definitionNode(txn *store.Txn, dockerCli command.Cli, name, driverName string, nd NodeDefinition, cur *store.Node) (*store.Node, error) {
+	pp, err := platformutil.Parse(nd.Platforms)
+	if err != nil {
+		return nil, err
+	}
+
+	var files map[string][]byte
+	switch {
+	case nd.BuildkitdConfig != "":
+		files, err = confutil.LoadConfigFiles(nd.BuildkitdConfig)
+		if err != nil {
+			return nil, err
+		}
+	case len(nd.Files) > 0:
+		files = make(map[string][]byte, len(nd.Files))
+		for k, v := range nd.Files {
+			files[k] = []byte(v)
+		}
+	default:
+		// if buildkit daemon config is not provided, check if the default one
+		// is available and use it
+		if f, ok := confutil.NewConfig(dockerCli).BuildKitConfigFile(); ok {
+			files, err = confutil.LoadConfigFiles(f)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var cfg *buildkitdconfig.Config
+	if dt, ok := files["buildkitd.toml"]; ok {
+		c, err := buildkitdconfig.Load(bytes.NewReader(dt))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load buildkitd config")
+		}
+		cfg = &c
+	}
+
+	var driverOpts map[string]string
+	if len(nd.DriverOpts) > 0 {
+		driverOpts = maps.Clone(nd.DriverOpts)
+	}
+
+	flags, err := parseBuildkitdFlagsWithConfig(nd.BuildkitdFlags, driverName, driverOpts, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ep, setEp, err := nodeEndpoint(txn, dockerCli, name, nd.Name, driverName, nd.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if !setEp && cur != nil {
+		ep = cur.Endpoint
+	}
+
+	return &store.Node{
+		Name:           nd.Name,
+		Endpoint:       ep,
+		Platforms:      pp,
+		DriverOpts:     driverOpts,
+		BuildkitdFlags: flags,
+		Files:          files,
+	}, nil
+}
+
+// changedFields returns the names of the settings that differ between the
+// nodes a and b.
+func changedFields(a, b *store.Node) []string {
+	var fields []string
+	if a.Endpoint != b.Endpoint {
+		fields = append(fields, "endpoint")
+	}
+	if !slices.Equal(platformutil.Format(a.Platforms), platformutil.Format(b.Platforms)) {
+		fields = append(fields, "platforms")
+	}
+	if !maps.Equal(a.DriverOpts, b.DriverOpts) {
+		fields = append(fields, "driver-opts")
+	}
+	if !slices.Equal(a.BuildkitdFlags, b.BuildkitdFlags) {
+		fields = append(fields, "buildkitd-flags")
+	}
+	if !maps.EqualFunc(a.Files, b.Files, bytes.Equal) {
+		fields = append(fields, "files")
+	}
+	return fields
+}
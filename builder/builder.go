@@ -169,6 +169,11 @@ func (b *Builder) Boot(ctx context.Context) (bool, error) {
 			toBoot = append(toBoot, idx)
 		}
 	}
+	return b.bootNodes(ctx, toBoot)
+}
+
+// bootNodes boots the nodes at the given indexes.
+func (b *Builder) bootNodes(ctx context.Context, toBoot []int) (bool, error) {
 	if len(toBoot) == 0 {
 		return false, nil
 	}
@@ -453,60 +458,9 @@ func Create(ctx context.Context, txn *store.Txn, dockerCli command.Cli, opts Cre
 		return nil, err
 	}
 
-	var ep string
-	var setEp bool
-	switch {
-	case driverName == "kubernetes":
-		if opts.Endpoint != "" {
-			return nil, errors.Errorf("kubernetes driver does not support endpoint args %q", opts.Endpoint)
-		}
-		// generate node name if not provided to avoid duplicated endpoint
-		// error: https://github.com/docker/setup-buildx-action/issues/215
-		nodeName := opts.NodeName
-		if nodeName == "" {
-			nodeName, err = k8sutil.GenerateNodeName(name, txn)
-			if err != nil {
-				return nil, err
-			}
-		}
-		// naming endpoint to make append works
-		ep = (&url.URL{
-			Scheme: driverName,
-			Path:   "/" + name,
-			RawQuery: (&url.Values{
-				"deployment": {nodeName},
-				"kubeconfig": {os.Getenv("KUBECONFIG")},
-			}).Encode(),
-		}).String()
-		setEp = false
-	case driverName == "remote":
-		if opts.Endpoint != "" {
-			ep = opts.Endpoint
-		} else if buildkitHost != "" {
-			ep = buildkitHost
-		} else {
-			return nil, errors.Errorf("no remote endpoint provided")
-		}
-		ep, err = validateBuildkitEndpoint(ep)
-		if err != nil {
-			return nil, err
-		}
-		setEp = true
-	case opts.Endpoint != "":
-		ep, err = validateEndpoint(dockerCli, opts.Endpoint)
-		if err != nil {
-			return nil, err
-		}
-		setEp = true
-	default:
-		if dockerCli.CurrentContext() == "default" && dockerCli.DockerEndpoint().TLSData != nil {
-			return nil, errors.Errorf("could not create a builder instance with TLS data loaded from environment. Please use `docker context create <context-name>` to create a context for current environment and then create a builder instance with context set to <context-name>")
-		}
-		ep, err = dockerutil.GetCurrentEndpoint(dockerCli)
-		if err != nil {
-			return nil, err
-		}
-		setEp = false
+	ep, setEp, err := nodeEndpoint(txn, dockerCli, name, opts.NodeName, driverName, opts.Endpoint)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := ng.Update(opts.NodeName, ep, opts.Platforms, setEp, opts.Append, buildkitdFlags, buildkitdConfigFile, driverOpts); err != nil {
@@ -517,6 +471,28 @@ func Create(ctx context.Context, txn *store.Txn, dockerCli command.Cli, opts Cre
 		return nil, err
 	}
 
+	b, err := loadSaved(ctx, txn, dockerCli, ng, ngOriginal, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Use && ep != "" {
+		current, err := dockerutil.GetCurrentEndpoint(dockerCli)
+		if err != nil {
+			return nil, err
+		}
+		if err := txn.SetCurrent(current, ng.Name, false, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// loadSaved loads the nodes of the node group ng that was just saved to the
+// store. If a node fails to initialize, the store is rolled back to
+// ngOriginal, or ng is removed if it didn't exist before.
+func loadSaved(ctx context.Context, txn *store.Txn, dockerCli command.Cli, ng, ngOriginal *store.NodeGroup, timeout time.Duration) (*Builder, error) {
 	b, err := New(dockerCli,
 		WithName(ng.Name),
 		WithStore(txn),
@@ -527,8 +503,8 @@ func Create(ctx context.Context, txn *store.Txn, dockerCli command.Cli, opts Cre
 	}
 
 	timeoutCtx, cancel := context.WithCancelCause(ctx)
-	if opts.Timeout > 0 {
-		timeoutCtx, _ = context.WithTimeoutCause(timeoutCtx, opts.Timeout, errors.WithStack(context.DeadlineExceeded)) //nolint:govet // no need to manually cancel this context as we already rely on parent
+	if timeout > 0 {
+		timeoutCtx, _ = context.WithTimeoutCause(timeoutCtx, timeout, errors.WithStack(context.DeadlineExceeded)) //nolint:govet // no need to manually cancel this context as we already rely on parent
 	}
 	defer func() { cancel(errors.WithStack(context.Canceled)) }()
 
@@ -552,18 +528,66 @@ func Create(ctx context.Context, txn *store.Txn, dockerCli command.Cli, opts Cre
 			return nil, err
 		}
 	}
+	return b, nil
+}
 
-	if opts.Use && ep != "" {
-		current, err := dockerutil.GetCurrentEndpoint(dockerCli)
+// nodeEndpoint returns the endpoint of a node of builder name using
+// driverName. setEp is false if the endpoint is not explicitly set and must
+// not replace the one of an existing node.
+func nodeEndpoint(txn *store.Txn, dockerCli command.Cli, name, nodeName, driverName, endpoint string) (ep string, setEp bool, err error) {
+	switch {
+	case driverName == "kubernetes":
+		if endpoint != "" {
+			return "", false, errors.Errorf("kubernetes driver does not support endpoint args %q", endpoint)
+		}
+		// generate node name if not provided to avoid duplicated endpoint
+		// error: https://github.com/docker/setup-buildx-action/issues/215
+		if nodeName == "" {
+			nodeName, err = k8sutil.GenerateNodeName(name, txn)
+			if err != nil {
+				return "", false, err
+			}
+		}
+		// naming endpoint to make append works
+		ep = (&url.URL{
+			Scheme: driverName,
+			Path:   "/" + name,
+			RawQuery: (&url.Values{
+				"deployment": {nodeName},
+				"kubeconfig": {os.Getenv("KUBECONFIG")},
+			}).Encode(),
+		}).String()
+		setEp = false
+	case driverName == "remote":
+		if endpoint != "" {
+			ep = endpoint
+		} else if buildkitHost := os.Getenv("BUILDKIT_HOST"); buildkitHost != "" {
+			ep = buildkitHost
+		} else {
+			return "", false, errors.Errorf("no remote endpoint provided")
+		}
+		ep, err = validateBuildkitEndpoint(ep)
 		if err != nil {
-			return nil, err
+			return "", false, err
 		}
-		if err := txn.SetCurrent(current, ng.Name, false, false); err != nil {
-			return nil, err
+		setEp = true
+	case endpoint != "":
+		ep, err = validateEndpoint(dockerCli, endpoint)
+		if err != nil {
+			return "", false, err
 		}
+		setEp = true
+	default:
+		if dockerCli.CurrentContext() == "default" && dockerCli.DockerEndpoint().TLSData != nil {
+			return "", false, errors.Errorf("could not create a builder instance with TLS data loaded from environment. Please use `docker context create <context-name>` to create a context for current environment and then create a builder instance with context set to <context-name>")
+		}
+		ep, err = dockerutil.GetCurrentEndpoint(dockerCli)
+		if err != nil {
+			return "", false, err
+		}
+		setEp = false
 	}
-
-	return b, nil
+	return ep, setEp, nil
 }
 
 type LeaveOpts struct {
@@ -648,7 +672,21 @@ func validateBuildkitEndpoint(ep string) (string, error) {
 }
 
 // parseBuildkitdFlags parses buildkit flags
-func parseBuildkitdFlags(inp string, driver string, driverOpts map[string]string, buildkitdConfigFile string) (res []string, err error) {
+func parseBuildkitdFlags(inp string, driver string, driverOpts map[string]string, buildkitdConfigFile string) ([]string, error) {
+	var cfg *buildkitdconfig.Config
+	if buildkitdConfigFile != "" {
+		c, err := buildkitdconfig.LoadFile(buildkitdConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg = &c
+	}
+	return parseBuildkitdFlagsWithConfig(inp, driver, driverOpts, cfg)
+}
+
+// parseBuildkitdFlagsWithConfig parses buildkit flags for a daemon using the
+// already loaded config cfg, which may be nil.
+func parseBuildkitdFlagsWithConfig(inp string, driver string, driverOpts map[string]string, cfg *buildkitdconfig.Config) (res []string, err error) {
 	if inp != "" {
 		res, err = shlex.Split(inp)
 		if err != nil {
@@ -664,16 +702,7 @@ func parseBuildkitdFlags(inp string, driver string, driverOpts map[string]string
 
 	hasNetworkHostEntitlement := slices.Contains(allowInsecureEntitlements, "network.host")
 
-	var hasNetworkHostEntitlementInConf bool
-	if buildkitdConfigFile != "" {
-		cfg, err := buildkitdconfig.LoadFile(buildkitdConfigFile)
-		if err != nil {
-			return nil, err
-		}
-		if slices.Contains(cfg.Entitlements, "network.host") {
-			hasNetworkHostEntitlementInConf = true
-		}
-	}
+	hasNetworkHostEntitlementInConf := cfg != nil && slices.Contains(cfg.Entitlements, "network.host")
 
 	if v, ok := driverOpts["network"]; ok && v == "host" && !hasNetworkHostEntitlement && driver == "docker-container" {
 		// always set network.host entitlement if user has set network=host
@@ -0,0 +1,199 @@
+package builder
+
+import (
+	"bytes"
+	"maps"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/docker/buildx/bake/hclparser"
+	"github.com/docker/buildx/store"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+// Definition describes builder instances declaratively. It is the format
+// read by "buildx builder apply" and written by "buildx builder export".
+type Definition struct {
+	Builders []BuilderDefinition `yaml:"builders" hcl:"builder,block"`
+}
+
+// BuilderDefinition describes a builder instance and all its nodes.
+type BuilderDefinition struct {
+	Name   string           `yaml:"name" hcl:"name,label"`
+	Driver string           `yaml:"driver,omitempty" hcl:"driver,optional"`
+	Nodes  []NodeDefinition `yaml:"nodes" hcl:"node,block"`
+}
+
+// NodeDefinition describes a node of a builder instance. The BuildKit
+// daemon config is either read from the BuildkitdConfig file, like with
+// "buildx create --buildkitd-config", or set inline with Files, which maps
+// the paths of the files in the config directory of the daemon to their
+// content.
+type NodeDefinition struct {
+	Name            string            `yaml:"name" hcl:"name,label"`
+	Endpoint        string            `yaml:"endpoint,omitempty" hcl:"endpoint,optional"`
+	Platforms       []string          `yaml:"platforms,omitempty" hcl:"platforms,optional"`
+	DriverOpts      map[string]string `yaml:"driver-opts,omitempty" hcl:"driver-opts,optional"`
+	BuildkitdFlags  string            `yaml:"buildkitd-flags,omitempty" hcl:"buildkitd-flags,optional"`
+	BuildkitdConfig string            `yaml:"buildkitd-config,omitempty" hcl:"buildkitd-config,optional"`
+	Files           map[string]string `yaml:"files,omitempty" hcl:"files,optional"`
+}
+
+// ParseDefinition parses the builder definition file fn with content dt.
+// Files with the .hcl extension are parsed as HCL, others as YAML. Relative
+// buildkitd config paths are resolved against the directory of fn.
+func ParseDefinition(dt []byte, fn string) (*Definition, error) {
+	var def Definition
+	if strings.EqualFold(filepath.Ext(fn), ".hcl") {
+		f, diags := hclsyntax.ParseConfig(dt, fn, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		ectx := &hcl.EvalContext{
+			Functions: hclparser.Stdlib(),
+		}
+		if diags := gohcl.DecodeBody(f.Body, ectx, &def); diags.HasErrors() {
+			return nil, diags
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(dt))
+		dec.KnownFields(true)
+		if err := dec.Decode(&def); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse builder definition %s", fn)
+		}
+	}
+
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+
+	if fn != "" && fn != "-" {
+		dir := filepath.Dir(fn)
+		for i := range def.Builders {
+			for j, n := range def.Builders[i].Nodes {
+				if n.BuildkitdConfig != "" && !filepath.IsAbs(n.BuildkitdConfig) {
+					def.Builders[i].Nodes[j].BuildkitdConfig = filepath.Join(dir, n.BuildkitdConfig)
+				}
+			}
+		}
+	}
+	return &def, nil
+}
+
+func (def *Definition) validate() error {
+	if len(def.Builders) == 0 {
+		return errors.New("no builders defined")
+	}
+	builders := map[string]struct{}{}
+	for i := range def.Builders {
+		b := &def.Builders[i]
+		if b.Name == "" {
+			return errors.New("builder name is required")
+		}
+		name, err := store.ValidateName(b.Name)
+		if err != nil {
+			return err
+		}
+		if name == "default" {
+			return errors.Errorf("default is a reserved name and cannot be used to identify builder instance")
+		}
+		b.Name = name
+		if _, ok := builders[b.Name]; ok {
+			return errors.Errorf("builder %q is defined more than once", b.Name)
+		}
+		builders[b.Name] = struct{}{}
+
+		if len(b.Nodes) == 0 {
+			return errors.Errorf("builder %q has no nodes", b.Name)
+		}
+		nodes := map[string]struct{}{}
+		for j := range b.Nodes {
+			n := &b.Nodes[j]
+			if n.Name == "" {
+				return errors.Errorf("builder %q: node name is required", b.Name)
+			}
+			name, err := store.ValidateName(n.Name)
+			if err != nil {
+				return errors.Wrapf(err, "builder %q", b.Name)
+			}
+			n.Name = name
+			if _, ok := nodes[n.Name]; ok {
+				return errors.Errorf("builder %q: node %q is defined more than once", b.Name, n.Name)
+			}
+			nodes[n.Name] = struct{}{}
+			if n.BuildkitdConfig != "" && len(n.Files) > 0 {
+				return errors.Errorf("builder %q: node %q can't set both buildkitd-config and files", b.Name, n.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Marshal returns the YAML encoding of the definition.
+func (def *Definition) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(def); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DefinitionFromNodeGroup returns the definition of the builder instance
+// stored as ng. BuildKit daemon config files are set inline as they can't
+// be mapped back to the original config file.
+func DefinitionFromNodeGroup(ng *store.NodeGroup) (*BuilderDefinition, error) {
+	if ng.Dynamic {
+		return nil, errors.Errorf("dynamic builder %q can't be exported", ng.Name)
+	}
+	bd := &BuilderDefinition{
+		Name:   ng.Name,
+		Driver: ng.Driver,
+	}
+	for _, n := range ng.Nodes {
+		nd := NodeDefinition{
+			Name:           n.Name,
+			BuildkitdFlags: joinFlags(n.BuildkitdFlags),
+		}
+		// the endpoint of kubernetes nodes is generated from the node name
+		if ng.Driver != "kubernetes" {
+			nd.Endpoint = n.Endpoint
+		}
+		for _, p := range n.Platforms {
+			nd.Platforms = append(nd.Platforms, platforms.Format(p))
+		}
+		if len(n.DriverOpts) > 0 {
+			nd.DriverOpts = maps.Clone(n.DriverOpts)
+		}
+		if len(n.Files) > 0 {
+			nd.Files = make(map[string]string, len(n.Files))
+			for k, v := range n.Files {
+				nd.Files[k] = string(v)
+			}
+		}
+		bd.Nodes = append(bd.Nodes, nd)
+	}
+	return bd, nil
+}
+
+// joinFlags joins flags into a string that can be split back with shlex.
+func joinFlags(flags []string) string {
+	quoted := make([]string, 0, len(flags))
+	for _, f := range flags {
+		if f != "" && !strings.ContainsAny(f, " \t\n'\"\\#") {
+			quoted = append(quoted, f)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(f, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
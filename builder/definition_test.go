@@ -0,0 +1,182 @@
+package builder
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/buildx/store"
+	"github.com/docker/buildx/util/platformutil"
+	"github.com/google/shlex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefinition(t *testing.T) {
+	yml := `
+builders:
+  - name: MyBuilder
+    driver: docker-container
+    nodes:
+      - name: amd64
+        endpoint: ssh://amd64-host
+        platforms: [linux/amd64]
+        driver-opts:
+          network: host
+        buildkitd-flags: --debug
+        buildkitd-config: buildkitd.toml
+      - name: arm64
+        endpoint: ssh://arm64-host
+        platforms: [linux/arm64]
+`
+	hcl := `
+builder "MyBuilder" {
+  driver = "docker-container"
+  node "amd64" {
+    endpoint = "ssh://amd64-host"
+    platforms = ["linux/amd64"]
+    driver-opts = {
+      network = "host"
+    }
+    buildkitd-flags = "--debug"
+    buildkitd-config = "buildkitd.toml"
+  }
+  node "arm64" {
+    endpoint = "ssh://arm64-host"
+    platforms = ["linux/arm64"]
+  }
+}
+`
+	for fn, dt := range map[string]string{
+		"/defs/builders.yaml": yml,
+		"/defs/builders.hcl":  hcl,
+	} {
+		t.Run(filepath.Ext(fn), func(t *testing.T) {
+			def, err := ParseDefinition([]byte(dt), fn)
+			require.NoError(t, err)
+			require.Equal(t, &Definition{
+				Builders: []BuilderDefinition{
+					{
+						Name:   "mybuilder",
+						Driver: "docker-container",
+						Nodes: []NodeDefinition{
+							{
+								Name:            "amd64",
+								Endpoint:        "ssh://amd64-host",
+								Platforms:       []string{"linux/amd64"},
+								DriverOpts:      map[string]string{"network": "host"},
+								BuildkitdFlags:  "--debug",
+								BuildkitdConfig: filepath.Join("/defs", "buildkitd.toml"),
+							},
+							{
+								Name:      "arm64",
+								Endpoint:  "ssh://arm64-host",
+								Platforms: []string{"linux/arm64"},
+							},
+						},
+					},
+				},
+			}, def)
+		})
+	}
+}
+
+func TestParseDefinitionInvalid(t *testing.T) {
+	for name, dt := range map[string]string{
+		"empty":          `builders: []`,
+		"unknown field":  "builders:\n  - name: b\n    nodes: [{name: n}]\n    foo: bar\n",
+		"reserved name":  "builders:\n  - name: default\n    nodes: [{name: n}]\n",
+		"no nodes":       "builders:\n  - name: b\n",
+		"no node name":   "builders:\n  - name: b\n    nodes: [{endpoint: foo}]\n",
+		"duplicate node": "builders:\n  - name: b\n    nodes: [{name: n}, {name: N}]\n",
+		"duplicate":      "builders:\n  - name: b\n    nodes: [{name: n}]\n  - name: b\n    nodes: [{name: n}]\n",
+		"config and files": "builders:\n  - name: b\n    nodes:\n      - name: n\n" +
+			"        buildkitd-config: buildkitd.toml\n        files: {buildkitd.toml: debug = true}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseDefinition([]byte(dt), "builders.yaml")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDefinitionFromNodeGroup(t *testing.T) {
+	pp, err := platformutil.Parse([]string{"linux/amd64", "linux/arm64"})
+	require.NoError(t, err)
+	ng := &store.NodeGroup{
+		Name:   "mybuilder",
+		Driver: "docker-container",
+		Nodes: []store.Node{
+			{
+				Name:           "mybuilder0",
+				Endpoint:       "unix:///var/run/docker.sock",
+				Platforms:      pp,
+				DriverOpts:     map[string]string{"image": "moby/buildkit:latest"},
+				BuildkitdFlags: []string{"--debug", "--allow-insecure-entitlement=network.host", "--oci-worker-gc-keepstorage=10 GB"},
+				Files: map[string][]byte{
+					"buildkitd.toml": []byte("debug = true\n"),
+				},
+			},
+		},
+	}
+
+	bd, err := DefinitionFromNodeGroup(ng)
+	require.NoError(t, err)
+	dt, err := (&Definition{Builders: []BuilderDefinition{*bd}}).Marshal()
+	require.NoError(t, err)
+
+	def, err := ParseDefinition(dt, "builders.yaml")
+	require.NoError(t, err)
+	require.Len(t, def.Builders, 1)
+	require.Equal(t, *bd, def.Builders[0])
+
+	nd := def.Builders[0].Nodes[0]
+	require.Equal(t, []string{"linux/amd64", "linux/arm64"}, nd.Platforms)
+	require.Equal(t, "debug = true\n", nd.Files["buildkitd.toml"])
+	flags, err := shlex.Split(nd.BuildkitdFlags)
+	require.NoError(t, err)
+	require.Equal(t, ng.Nodes[0].BuildkitdFlags, flags)
+}
+
+func TestJoinFlags(t *testing.T) {
+	for _, flags := range [][]string{
+		nil,
+		{"--debug"},
+		{"--foo", "bar baz", "it's", `a"b`, ""},
+	} {
+		s := joinFlags(flags)
+		res, err := shlex.Split(s)
+		require.NoError(t, err)
+		if len(flags) == 0 {
+			require.Empty(t, res)
+			continue
+		}
+		require.Equal(t, flags, res, s)
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	pp, err := platformutil.Parse([]string{"linux/amd64"})
+	require.NoError(t, err)
+	a := &store.Node{
+		Name:           "n",
+		Endpoint:       "ssh://host",
+		Platforms:      pp,
+		DriverOpts:     map[string]string{"network": "host"},
+		BuildkitdFlags: []string{"--debug"},
+		Files:          map[string][]byte{"buildkitd.toml": []byte("debug = true")},
+	}
+	require.Empty(t, changedFields(a, a))
+
+	b := *a
+	b.DriverOpts = map[string]string{"network": "bridge"}
+	b.Files = map[string][]byte{"buildkitd.toml": []byte("debug = false")}
+	require.Equal(t, []string{"driver-opts", "files"}, changedFields(a, &b))
+
+	c := *a
+	c.Platforms = nil
+	c.BuildkitdFlags = nil
+	require.Equal(t, []string{"platforms", "buildkitd-flags"}, changedFields(a, &c))
+
+	// empty and unset settings are the same
+	d := &store.Node{Name: "n", DriverOpts: map[string]string{}}
+	require.Empty(t, changedFields(d, &store.Node{Name: "n"}))
+}
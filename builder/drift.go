@@ -0,0 +1,176 @@
+package builder
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/docker/buildx/driver"
+	"github.com/docker/buildx/util/platformutil"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// NodeDrift holds the settings of the BuildKit daemon of a node that differ
+// from the ones stored for the node.
+type NodeDrift struct {
+	Name   string
+	Drifts []driver.Drift
+}
+
+type DriftOption func(*driftOptions)
+
+type driftOptions struct {
+	runningOnly bool
+}
+
+// WithRunningOnly only checks the drift of running nodes, so the drift of a
+// builder can be checked without booting or inspecting stopped nodes.
+func WithRunningOnly() DriftOption {
+	return func(o *driftOptions) {
+		o.runningOnly = true
+	}
+}
+
+// Drift compares the BuildKit daemons of the nodes of the builder with their
+// stored driver options, BuildKit daemon flags and config files, and checks
+// that the BuildKit version and workers match the image and platforms of
+// the nodes. Nodes must be loaded with WithData. Only nodes with drift are
+// returned.
+func (b *Builder) Drift(ctx context.Context, opts ...DriftOption) ([]NodeDrift, error) {
+	var do driftOptions
+	for _, opt := range opts {
+		opt(&do)
+	}
+	res := make([]NodeDrift, len(b.nodes))
+	seen := map[string]struct{}{}
+	eg, ctx := errgroup.WithContext(ctx)
+	for i, n := range b.nodes {
+		if n.Err != nil || n.Driver == nil {
+			continue
+		}
+		if do.runningOnly && (n.DriverInfo == nil || n.DriverInfo.Status != driver.Running) {
+			continue
+		}
+		// dynamic nodes share the driver of their store node
+		if _, ok := seen[n.Name]; ok {
+			continue
+		}
+		seen[n.Name] = struct{}{}
+		eg.Go(func() error {
+			drifts, err := n.Driver.Drift(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check drift of node %s", n.Name)
+			}
+			if n.DriverInfo != nil && n.DriverInfo.Status == driver.Running {
+				ds := driver.DriftSet(drifts)
+				if err := n.runtimeDrift(ctx, &ds); err != nil {
+					return errors.Wrapf(err, "failed to check drift of node %s", n.Name)
+				}
+				drifts = ds
+			}
+			res[i] = NodeDrift{Name: n.Name, Drifts: drifts}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(res, func(nd NodeDrift) bool {
+		return len(nd.Drifts) == 0
+	}), nil
+}
+
+// runtimeDrift checks the BuildKit version against the tag of the image of
+// the node and the fixed platforms of the node against the workers.
+func (n *Node) runtimeDrift(ctx context.Context, drifts *driver.DriftSet) error {
+	if v := imageVersion(n.DriverOpts["image"]); v != nil && n.Version != "" {
+		if bv, err := semver.NewVersion(n.Version); err == nil && !bv.Equal(v) {
+			*drifts = append(*drifts, driver.Drift{Field: "version", Expected: v.Original(), Actual: n.Version})
+		}
+	}
+
+	if len(n.Node.Platforms) == 0 {
+		return nil
+	}
+	c, err := n.Driver.Client(ctx)
+	if err != nil {
+		return err
+	}
+	workers, err := c.ListWorkers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing workers")
+	}
+	var supported []ocispecs.Platform
+	for _, w := range workers {
+		supported = append(supported, w.Platforms...)
+	}
+	matcher := platforms.Any(supported...)
+	if slices.ContainsFunc(n.Node.Platforms, func(p ocispecs.Platform) bool { return !matcher.Match(p) }) {
+		*drifts = append(*drifts, driver.Drift{
+			Field:    "platforms",
+			Expected: strings.Join(platformutil.Format(n.Node.Platforms), ","),
+			Actual:   strings.Join(platformutil.Format(platformutil.Dedupe(supported)), ","),
+		})
+	}
+	return nil
+}
+
+// imageVersion returns the BuildKit version of a BuildKit image reference
+// tagged with a release, e.g. moby/buildkit:v0.20.0-rootless.
+func imageVersion(image string) *semver.Version {
+	if image == "" {
+		return nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil
+	}
+	tagged, ok := named.(reference.Tagged)
+	if !ok {
+		return nil
+	}
+	tag, _ := strings.CutSuffix(tagged.Tag(), "-rootless")
+	if !strings.HasPrefix(tag, "v") {
+		return nil
+	}
+	if _, err := semver.StrictNewVersion(strings.TrimPrefix(tag, "v")); err != nil {
+		return nil
+	}
+	return semver.MustParse(tag)
+}
+
+// Reconcile recreates the BuildKit daemons of the nodes of the builder that
+// drifted from their stored settings. The state of the daemons is kept.
+// The drift of the recreated nodes is returned.
+func (b *Builder) Reconcile(ctx context.Context) ([]NodeDrift, error) {
+	if _, err := b.LoadNodes(ctx, WithData()); err != nil {
+		return nil, err
+	}
+	drifts, err := b.Drift(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var toBoot []int
+	for _, nd := range drifts {
+		idx := slices.IndexFunc(b.nodes, func(n Node) bool { return n.Name == nd.Name })
+		n := b.nodes[idx]
+		if err := n.Driver.Stop(ctx, true); err != nil {
+			return nil, errors.Wrapf(err, "failed to stop node %s", n.Name)
+		}
+		if err := n.Driver.Rm(ctx, true, false, true); err != nil {
+			return nil, errors.Wrapf(err, "failed to remove node %s", n.Name)
+		}
+		b.nodes[idx].DriverInfo = &driver.Info{Status: driver.Inactive}
+		toBoot = append(toBoot, idx)
+	}
+	if _, err := b.bootNodes(ctx, toBoot); err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
@@ -0,0 +1,33 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageVersion(t *testing.T) {
+	for image, expected := range map[string]string{
+		"moby/buildkit:v0.20.0":             "v0.20.0",
+		"moby/buildkit:v0.20.0-rootless":    "v0.20.0",
+		"docker.io/moby/buildkit:v0.21.1":   "v0.21.1",
+		"moby/buildkit:v0.21.0-rc1":         "v0.21.0-rc1",
+		"moby/buildkit:buildx-stable-1":     "",
+		"moby/buildkit:latest":              "",
+		"moby/buildkit":                     "",
+		"moby/buildkit@sha256:" + digestHex: "",
+		"":                                  "",
+	} {
+		t.Run(image, func(t *testing.T) {
+			v := imageVersion(image)
+			if expected == "" {
+				require.Nil(t, v)
+				return
+			}
+			require.NotNil(t, v)
+			require.Equal(t, expected, v.Original())
+		})
+	}
+}
+
+const digestHex = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
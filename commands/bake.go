@@ -9,13 +9,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"path/filepath"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"text/tabwriter"
+	"time"
 
 	"github.com/containerd/console"
 	"github.com/containerd/containerd/v2/pkg/epoch"
@@ -25,6 +28,8 @@ import (
 	"github.com/docker/buildx/build"
 	"github.com/docker/buildx/builder"
 	"github.com/docker/buildx/localstate"
+	"github.com/docker/buildx/store"
+	"github.com/docker/buildx/store/storeutil"
 	"github.com/docker/buildx/util/buildflags"
 	"github.com/docker/buildx/util/cobrautil"
 	"github.com/docker/buildx/util/cobrautil/completion"
@@ -32,6 +37,9 @@ import (
 	"github.com/docker/buildx/util/desktop"
 	"github.com/docker/buildx/util/dockerutil"
 	"github.com/docker/buildx/util/dockerutil/dockerconfig"
+	"github.com/docker/buildx/util/gitutil"
+	"github.com/docker/buildx/util/imagetools"
+	"github.com/docker/buildx/util/lintreport"
 	"github.com/docker/buildx/util/osutil"
 	"github.com/docker/buildx/util/progress"
 	"github.com/docker/buildx/util/tracing"
@@ -44,6 +52,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/tonistiigi/go-csvvalue"
 	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -56,6 +65,7 @@ type bakeOptions struct {
 	files     []string
 	overrides []string
 	vars      []string
+	profiles  []string
 
 	sbom       string
 	provenance string
@@ -71,6 +81,13 @@ type bakeOptions struct {
 	print bool
 	list  string
 
+	lock   bool
+	locked bool
+
+	changedSince   string
+	shard          string
+	shardDurations string
+
 	// TODO: remove deprecated flags
 	listTargets bool
 	listVars    bool
@@ -154,9 +171,66 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 	if in.print && in.list != "" {
 		return errors.New("--print and --list are mutually exclusive")
 	}
+	if in.lock && in.locked {
+		return errors.New("--lock and --locked are mutually exclusive")
+	}
+	if in.lock && (in.print || in.list != "") {
+		return errors.New("--lock cannot be used with --print or --list")
+	}
+	if in.changedSince != "" && url != "" {
+		return errors.New("--changed-since cannot be used with a remote bake definition")
+	}
+
+	var list listEntry
+	if in.list != "" {
+		if list, err = parseList(in.list); err != nil {
+			return err
+		}
+	}
+	var shardIndex, shardTotal int
+	if in.shard != "" {
+		if in.list != "" {
+			return errors.New("--shard cannot be used with --list")
+		}
+		if shardIndex, shardTotal, err = bake.ParseShard(in.shard); err != nil {
+			return err
+		}
+	}
+	// durations of a file shared by all the jobs override the build history,
+	// so every job computes the same partition
+	var durations map[string]time.Duration
+	if in.shardDurations != "" {
+		if in.shard == "" && list.Type != "shards" {
+			return errors.New("--shard-durations requires --shard or --list=shards")
+		}
+		if durations, err = bake.ReadDurations(in.shardDurations); err != nil {
+			return err
+		}
+	}
+
+	var lock *bake.Lock
+	var remoteOpt bake.RemoteOpt
+	if in.locked {
+		if lock, err = bake.ReadLock(bake.LockFilename); err != nil {
+			return err
+		}
+		if url != "" {
+			if remoteOpt.Checksum, err = lock.Definition(url); err != nil {
+				return err
+			}
+		}
+	} else if in.lock {
+		// the lockfile is updated, so the pins of targets not built by this
+		// run are kept
+		if lock, err = bake.LoadLock(bake.LockFilename); err != nil {
+			return err
+		}
+		remoteOpt.ResolveChecksum = true
+	}
 
 	// instance only needed for reading remote bake files or building
 	var driverType string
+	var ng *store.NodeGroup
 	if url != "" || (!in.print && in.list == "") {
 		b, err := builder.New(dockerCli,
 			builder.WithName(in.builder),
@@ -175,6 +249,16 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 		progressConsoleDesc = fmt.Sprintf("%s:%s", b.Driver, b.Name)
 		progressTextDesc = fmt.Sprintf("building with %q instance using %s driver", b.Name, b.Driver)
 		driverType = b.Driver
+		ng = b.NodeGroup
+	} else if list.Type == "durations" || list.Type == "shards" {
+		// durations of targets are read from the build history of the
+		// instance if available
+		if b, err := builder.New(dockerCli,
+			builder.WithName(in.builder),
+			builder.WithContextPathHash(contextPathHash),
+		); err == nil {
+			nodes, _ = b.LoadNodes(ctx)
+		}
 	}
 
 	var term bool
@@ -208,7 +292,7 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 		return err
 	}
 
-	files, inp, err := readBakeFiles(ctx, nodes, url, in.files, dockerCli.In(), printer, filesFromEnv)
+	files, inp, err := readBakeFiles(ctx, nodes, url, in.files, remoteOpt, dockerCli.In(), printer, filesFromEnv)
 	if err != nil {
 		return err
 	}
@@ -231,11 +315,33 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 	if err != nil {
 		return err
 	}
+	loadNodes := func() ([]builder.Node, error) {
+		if nodes != nil {
+			return nodes, nil
+		}
+		b, err := builder.New(dockerCli,
+			builder.WithName(in.builder),
+			builder.WithContextPathHash(contextPathHash),
+		)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err = b.LoadNodes(ctx)
+		return nodes, err
+	}
+	// local files loaded by import blocks are definitions of the targets
+	// too, which is needed to detect changes with --changed-since
+	var importFiles []bake.File
 	parseOpt := bake.ParseOpt{
 		FileRelativePaths: fileRelativePaths,
+		Profiles:          in.profiles,
+		ReadImport:        bakeImportReader(ctx, loadNodes, lock, in.lock, printer),
+		OnImport: func(files []bake.File) {
+			importFiles = append(importFiles, files...)
+		},
 	}
 
-	if in.list != "" {
+	if in.list != "" && list.Type != "shards" && list.Type != "durations" {
 		cfg, pm, err := bake.ParseFiles(files, defaults, vars, parseOpt)
 		if err != nil {
 			return err
@@ -243,15 +349,13 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 		if err = printer.Wait(); err != nil {
 			return err
 		}
-		list, err := parseList(in.list)
-		if err != nil {
-			return err
-		}
 		switch list.Type {
 		case "targets":
 			return printTargetList(dockerCli.Out(), list.Format, cfg)
 		case "variables":
 			return printVars(dockerCli.Out(), list.Format, pm.AllVariables)
+		case "graph":
+			return printGraph(dockerCli.Out(), list.Format, cfg)
 		}
 	}
 
@@ -289,6 +393,53 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 		return err
 	}
 
+	if in.changedSince != "" {
+		if err := filterChangedTargets(ctx, in.changedSince, slices.Concat(files, importFiles), tgts, bo, printer); err != nil {
+			return err
+		}
+		if len(bo) == 0 {
+			if err = printer.Wait(); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(dockerCli.Err(), "No targets changed since %s\n", in.changedSince)
+			return nil
+		}
+	}
+
+	if list.Type == "durations" {
+		durations := bakeDurations(ctx, dockerCli, nodes, bo, printer)
+		if err = printer.Wait(); err != nil {
+			return err
+		}
+		return printDurations(dockerCli.Out(), list.Format, durations)
+	}
+	if shardTotal > 0 || list.Type == "shards" {
+		if durations == nil {
+			durations = bakeDurations(ctx, dockerCli, nodes, bo, printer)
+		}
+		shards := bake.Shards(bo, cmp.Or(shardTotal, list.Total), durations)
+		if list.Type == "shards" {
+			if err = printer.Wait(); err != nil {
+				return err
+			}
+			return printShards(dockerCli.Out(), list.Format, shards)
+		}
+		keep := shards[shardIndex-1].Targets
+		for name := range bo {
+			if !slices.Contains(keep, name) {
+				delete(bo, name)
+				delete(tgts, name)
+			}
+		}
+		if len(bo) == 0 {
+			if err = printer.Wait(); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(dockerCli.Err(), "No targets in shard %s\n", in.shard)
+			return nil
+		}
+	}
+
 	// make sure local credentials aren't loaded multiple times for different targets
 	authProvider := authprovider.NewDockerAuthProvider(authprovider.DockerAuthProviderConfig{
 		AuthConfigProvider: dockerconfig.LoadAuthConfig(dockerCli),
@@ -334,6 +485,12 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 		}
 	}
 
+	if lock != nil {
+		if err := bakeLock(ctx, dockerCli, ng, nodes, lock, in.lock, url, inp, bo, printer); err != nil {
+			return err
+		}
+	}
+
 	exp, err := ent.Validate(bo)
 	if err != nil {
 		return err
@@ -342,6 +499,12 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 		if exp.LocalOutputDelete {
 			return errors.Errorf("additional privileges requested: pass %q to grant requested privileges", "--allow="+string(bake.EntitlementKeyBuildxLocalDelete))
 		}
+		if exp.SecretCmd {
+			return errors.Errorf("additional privileges requested: pass %q to grant requested privileges", "--allow="+string(bake.EntitlementKeyBuildxSecretCmd))
+		}
+		if exp.SecretCredential {
+			return errors.Errorf("additional privileges requested: pass %q to grant requested privileges", "--allow="+string(bake.EntitlementKeyBuildxSecretCred))
+		}
 	} else {
 		if err := exp.Prompt(ctx, url != "", &syncWriter{w: dockerCli.Err(), wait: printer.Wait}); err != nil {
 			return err
@@ -392,9 +555,12 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 
 	var callFormatJSON bool
 	jsonResults := map[string]map[string]any{}
+	var lintReport bool
 	if callFunc != nil {
 		callFormatJSON = callFunc.Format == "json"
+		lintReport = lintreport.IsFormat(callFunc.Format)
 	}
+	var lintTargets []lintreport.Target
 	var sep bool
 	var exitCode int
 
@@ -426,7 +592,20 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 			res = sp.ExporterResponse
 		}
 
-		if callFormatJSON {
+		if lintReport {
+			if pf.Name == "lint" {
+				target, err := lintReportTarget(name, res, &req.Inputs)
+				if err != nil {
+					return err
+				}
+				lintTargets = append(lintTargets, target)
+			}
+			if code, err := printResult(io.Discard, pf, res, name, &req.Inputs); err != nil {
+				exitCode = 1
+			} else if code != 0 && exitCode == 0 {
+				exitCode = code
+			}
+		} else if callFormatJSON {
 			jsonResults[name] = map[string]any{}
 			buf := &bytes.Buffer{}
 			if code, err := printResult(buf, pf, res, name, &req.Inputs); err != nil {
@@ -463,7 +642,11 @@ func runBake(ctx context.Context, dockerCli command.Cli, targets []string, in ba
 			}
 		}
 	}
-	if callFormatJSON {
+	if lintReport {
+		if err := writeLintReport(dockerCli.Out(), callFunc, lintTargets); err != nil {
+			return err
+		}
+	} else if callFormatJSON {
 		out := struct {
 			Group  map[string]*bake.Group    `json:"group,omitempty"`
 			Target map[string]map[string]any `json:"target"`
@@ -554,9 +737,10 @@ func bakeCmd(dockerCli command.Cli, rootOpts *rootOptions) *cobra.Command {
 	flags.BoolVar(&options.exportPush, "push", false, `Shorthand for "--set=*.output=type=registry". Conditional.`)
 	flags.StringVar(&options.sbom, "sbom", "", `Shorthand for "--set=*.attest=type=sbom"`)
 	flags.StringVar(&options.provenance, "provenance", "", `Shorthand for "--set=*.attest=type=provenance"`)
-	flags.StringArrayVar(&options.policy, "policy", []string{}, `Global policy evaluation options (format: "[disabled=true|false][,strict=true|false][,log-level=level]")`)
+	flags.StringArrayVar(&options.policy, "policy", []string{}, `Global policy evaluation options (format: "[disabled=true|false][,strict=true|false][,log-level=level][,audit-log=path]")`)
 	flags.StringArrayVar(&options.overrides, "set", nil, `Override target value (e.g., "targetpattern.key=value")`)
 	flags.StringArrayVar(&options.vars, "var", nil, `Set a variable value (e.g., "name=value")`)
+	flags.StringArrayVar(&options.profiles, "profile", nil, "Enable a compose profile")
 	flags.StringVar(&options.callFunc, "call", "build", `Set method for evaluating build ("check", "outline", "targets")`)
 	flags.StringArrayVar(&options.allow, "allow", nil, "Allow build to access specified resources")
 
@@ -564,7 +748,12 @@ func bakeCmd(dockerCli command.Cli, rootOpts *rootOptions) *cobra.Command {
 	flags.Lookup("check").NoOptDefVal = "true"
 
 	flags.BoolVar(&options.print, "print", false, "Print the options without building")
-	flags.StringVar(&options.list, "list", "", "List targets or variables")
+	flags.StringVar(&options.list, "list", "", "List targets, variables, the dependency graph, shards or target durations")
+	flags.BoolVar(&options.lock, "lock", false, "Resolve remote definitions and images and write them to docker-bake.lock")
+	flags.BoolVar(&options.locked, "locked", false, "Use the remote definitions and images pinned in docker-bake.lock")
+	flags.StringVar(&options.changedSince, "changed-since", "", "Only build targets whose local inputs changed since the git ref")
+	flags.StringVar(&options.shard, "shard", "", `Only build the targets of a shard (format: "INDEX/TOTAL")`)
+	flags.StringVar(&options.shardDurations, "shard-durations", "", "Balance shards with the target durations of a JSON file")
 
 	// TODO: remove deprecated flags
 	flags.BoolVar(&options.listTargets, "list-targets", false, "List available targets")
@@ -593,7 +782,7 @@ func bakePolicyOverrides(in []string) ([]string, bool, error) {
 			return nil, false, errors.New(`--policy does not accept reset; define policy composition in the bake definition`)
 		}
 		if cfg.Disabled {
-			if cfg.Strict != nil || cfg.LogLevel != nil || len(configs) > 1 {
+			if cfg.Strict != nil || cfg.LogLevel != nil || cfg.AuditLog != "" || len(configs) > 1 {
 				return nil, false, errors.New("disabled policy cannot be combined with other policy flags")
 			}
 			return nil, true, nil
@@ -695,7 +884,184 @@ func bakeFileRelativePaths() (bool, error) {
 	return enabled, nil
 }
 
-func readBakeFiles(ctx context.Context, nodes []builder.Node, url string, names []string, stdin io.Reader, pw progress.Writer, filesFromEnv bool) (files []bake.File, inp *bake.Input, err error) {
+// filterChangedTargets removes the targets whose inputs didn't change since
+// the git ref from the targets and build options. Targets linked to changed
+// targets are kept. If a bake definition changed, all targets are kept.
+func filterChangedTargets(ctx context.Context, ref string, files []bake.File, tgts map[string]*bake.Target, bo map[string]build.Options, pw progress.Writer) error {
+	return progress.Wrap("[internal] detect targets changed since "+ref, pw.Write, func(sub progress.SubLogger) error {
+		gitc, err := gitutil.New()
+		if err != nil {
+			return err
+		}
+		if !gitc.IsInsideWorkTree(ctx) {
+			return errors.New("--changed-since requires a git work tree")
+		}
+		changed, err := gitc.ChangedFiles(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if name, ok, err := bake.ChangedDefinition(files, changed); err != nil {
+			return err
+		} else if ok {
+			sub.Log(1, fmt.Appendf(nil, "bake definition %s changed, building all targets\n", name))
+			return nil
+		}
+		affected, err := bake.ChangedTargets(bo, changed)
+		if err != nil {
+			return err
+		}
+		keep := bake.WithLinkedTargets(bo, affected)
+		for _, name := range slices.Sorted(maps.Keys(bo)) {
+			if slices.Contains(keep, name) {
+				continue
+			}
+			sub.Log(1, fmt.Appendf(nil, "skipping unchanged target %s\n", name))
+			delete(bo, name)
+			delete(tgts, name)
+		}
+		return nil
+	})
+}
+
+// bakeDurations returns the duration of the last successful build of each
+// target of the build options, read from the build history of the nodes.
+// Durations are best effort: targets never built on the builder, or built
+// from another context, are missing from the result.
+func bakeDurations(ctx context.Context, dockerCli command.Cli, nodes []builder.Node, bo map[string]build.Options, pw progress.Writer) map[string]time.Duration {
+	durations := map[string]time.Duration{}
+	_ = progress.Wrap("[internal] load build durations from history", pw.Write, func(sub progress.SubLogger) error {
+		ls, err := localstate.New(confutil.NewConfig(dockerCli))
+		if err != nil {
+			sub.Log(2, fmt.Appendf(nil, "failed to load local state: %v\n", err))
+			return nil
+		}
+		// context paths as saved in the local state of bake builds
+		contexts := make(map[string]string, len(bo))
+		for name, opt := range bo {
+			lp := opt.Inputs.ContextPath
+			if lp != "" && !urlutil.IsRemoteURL(lp) && lp != "-" {
+				if lp, err = filepath.Abs(lp); err != nil {
+					continue
+				}
+			}
+			contexts[name] = lp
+		}
+
+		var mu sync.Mutex
+		created := map[string]time.Time{}
+		eg, ctx := errgroup.WithContext(ctx)
+		for _, node := range nodes {
+			if node.Driver == nil || node.Err != nil {
+				continue
+			}
+			eg.Go(func() error {
+				if !node.Driver.HistoryAPISupported(ctx) {
+					return nil
+				}
+				c, err := node.Driver.Client(ctx)
+				if err != nil {
+					sub.Log(2, fmt.Appendf(nil, "failed to load build history of %s: %v\n", node.Name, err))
+					return nil
+				}
+				records, err := loadBuildRecords(ctx, c)
+				if err != nil {
+					sub.Log(2, fmt.Appendf(nil, "failed to load build history of %s: %v\n", node.Name, err))
+					return nil
+				}
+				for _, rec := range records {
+					if rec.Error != nil || rec.CreatedAt == nil || rec.CompletedAt == nil {
+						continue
+					}
+					st, _ := ls.ReadRef(node.Builder, node.Name, rec.Ref)
+					if st == nil || st.GroupRef == "" {
+						continue
+					}
+					if lp, ok := contexts[st.Target]; !ok || lp != st.LocalPath {
+						continue
+					}
+					t := rec.CreatedAt.AsTime()
+					mu.Lock()
+					if last, ok := created[st.Target]; !ok || t.After(last) {
+						created[st.Target] = t
+						durations[st.Target] = rec.CompletedAt.AsTime().Sub(t)
+					}
+					mu.Unlock()
+				}
+				return nil
+			})
+		}
+		_ = eg.Wait()
+		sub.Log(1, fmt.Appendf(nil, "found durations for %d of %d targets\n", len(durations), len(bo)))
+		return nil
+	})
+	return durations
+}
+
+// bakeLock pins the images of the build options to the digests of the lock.
+// If update is set, the lock is first resolved and written to the lockfile.
+func bakeLock(ctx context.Context, dockerCli command.Cli, ng *store.NodeGroup, nodes []builder.Node, lock *bake.Lock, update bool, url string, inp *bake.Input, bo map[string]build.Options, pw progress.Writer) error {
+	dockerfiles, err := bake.ReadRemoteDockerfiles(ctx, nodes, bo, pw)
+	if err != nil {
+		return err
+	}
+	if update {
+		if url != "" && inp != nil && inp.Checksum != "" {
+			lock.SetDefinition(url, inp.Checksum)
+		}
+		imageOpt, err := storeutil.GetImageConfig(dockerCli, ng)
+		if err != nil {
+			return err
+		}
+		if err := progress.Wrap("[internal] resolve images for "+bake.LockFilename, pw.Write, func(progress.SubLogger) error {
+			return lock.ResolveImages(ctx, bo, dockerfiles, imagetools.New(imageOpt))
+		}); err != nil {
+			return err
+		}
+		if err := lock.Write(bake.LockFilename); err != nil {
+			return err
+		}
+	}
+	return lock.Pin(bo, dockerfiles)
+}
+
+// bakeImportReader returns a reader for the remote sources imported by the
+// bake definition. The nodes of the builder are only loaded when a remote
+// source is imported. Sources are pinned with the lock if set.
+func bakeImportReader(ctx context.Context, loadNodes func() ([]builder.Node, error), lock *bake.Lock, update bool, pw progress.Writer) func(string) ([]bake.File, *bake.Input, error) {
+	type importSource struct {
+		files []bake.File
+		inp   *bake.Input
+	}
+	cache := map[string]importSource{}
+	return func(source string) ([]bake.File, *bake.Input, error) {
+		if src, ok := cache[source]; ok {
+			return src.files, src.inp, nil
+		}
+		nodes, err := loadNodes()
+		if err != nil {
+			return nil, nil, err
+		}
+		var remoteOpt bake.RemoteOpt
+		if lock != nil {
+			if update {
+				remoteOpt.ResolveChecksum = true
+			} else if remoteOpt.Checksum, err = lock.Definition(source); err != nil {
+				return nil, nil, err
+			}
+		}
+		files, inp, err := bake.ReadRemoteFiles(ctx, nodes, source, nil, remoteOpt, pw)
+		if err != nil {
+			return nil, nil, err
+		}
+		if update && inp != nil && inp.Checksum != "" {
+			lock.SetDefinition(source, inp.Checksum)
+		}
+		cache[source] = importSource{files: files, inp: inp}
+		return files, inp, nil
+	}
+}
+
+func readBakeFiles(ctx context.Context, nodes []builder.Node, url string, names []string, remoteOpt bake.RemoteOpt, stdin io.Reader, pw progress.Writer, filesFromEnv bool) (files []bake.File, inp *bake.Input, err error) {
 	var lnames []string // local
 	var rnames []string // remote
 	var anames []string // both
@@ -711,7 +1077,7 @@ func readBakeFiles(ctx context.Context, nodes []builder.Node, url string, names
 
 	if url != "" {
 		var rfiles []bake.File
-		rfiles, inp, err = bake.ReadRemoteFiles(ctx, nodes, url, rnames, pw)
+		rfiles, inp, err = bake.ReadRemoteFiles(ctx, nodes, url, rnames, remoteOpt, pw)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -744,6 +1110,7 @@ func readBakeFiles(ctx context.Context, nodes []builder.Node, url string, names
 type listEntry struct {
 	Type   string
 	Format string
+	Total  int
 }
 
 func parseList(input string) (listEntry, error) {
@@ -754,41 +1121,67 @@ func parseList(input string) (listEntry, error) {
 		return res, err
 	}
 
-	if len(fields) == 1 && fields[0] == input && !strings.HasPrefix(input, "type=") {
-		res.Type = input
+	if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+		// the type can be set without key, e.g. "shards,total=4"
+		res.Type = fields[0]
+		fields = fields[1:]
 	}
 
-	if res.Type == "" {
-		for _, field := range fields {
-			key, value, ok := strings.Cut(field, "=")
-			if !ok {
-				return res, errors.Errorf("invalid value %s", field)
-			}
-			key = strings.TrimSpace(strings.ToLower(key))
-			switch key {
-			case "type":
-				res.Type = value
-			case "format":
-				res.Format = value
-			default:
-				return res, errors.Errorf("unexpected key '%s' in '%s'", key, field)
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return res, errors.Errorf("invalid value %s", field)
+		}
+		key = strings.TrimSpace(strings.ToLower(key))
+		switch key {
+		case "type":
+			res.Type = value
+		case "format":
+			res.Format = value
+		case "total":
+			if res.Total, err = strconv.Atoi(value); err != nil {
+				return res, errors.Wrapf(err, "invalid total %q", value)
 			}
+		default:
+			return res, errors.Errorf("unexpected key '%s' in '%s'", key, field)
 		}
 	}
-	if res.Format == "" {
-		res.Format = "table"
-	}
-
 	switch res.Type {
 	case "targets", "variables":
+		if res.Format == "" {
+			res.Format = "table"
+		}
+		switch res.Format {
+		case "table", "json":
+		default:
+			return res, errors.Errorf("invalid list format %q", res.Format)
+		}
+	case "graph":
+		if res.Format == "" {
+			res.Format = "dot"
+		}
+		switch res.Format {
+		case "dot", "json":
+		default:
+			return res, errors.Errorf("invalid list format %q for graph", res.Format)
+		}
+	case "shards", "durations":
+		if res.Format == "" {
+			res.Format = "table"
+		}
+		switch res.Format {
+		case "table", "json":
+		default:
+			return res, errors.Errorf("invalid list format %q", res.Format)
+		}
+		if res.Total < 0 {
+			return res, errors.Errorf("invalid shards total %d", res.Total)
+		}
 	default:
 		return res, errors.Errorf("invalid list type %q", res.Type)
 	}
-
-	switch res.Format {
-	case "table", "json":
-	default:
-		return res, errors.Errorf("invalid list format %q", res.Format)
+	if res.Total != 0 && res.Type != "shards" {
+		return res, errors.New("total is only supported for the shards list type")
 	}
 
 	return res, nil
@@ -843,6 +1236,16 @@ func printVars(w io.Writer, format string, vars []*hclparser.Variable) error {
 	return nil
 }
 
+func printGraph(w io.Writer, format string, cfg *bake.Config) error {
+	g := cfg.Graph()
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	}
+	return g.WriteDOT(w)
+}
+
 func printTargetList(w io.Writer, format string, cfg *bake.Config) error {
 	type targetOrGroup struct {
 		name   string
@@ -912,6 +1315,64 @@ func printTargetList(w io.Writer, format string, cfg *bake.Config) error {
 	return nil
 }
 
+// printDurations prints the durations of targets. The json format is the
+// format read by --shard-durations.
+func printDurations(w io.Writer, format string, durations map[string]time.Duration) error {
+	if format == "json" {
+		out := make(map[string]string, len(durations))
+		for name, d := range durations {
+			out[name] = d.Round(time.Second).String()
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	defer tw.Flush()
+	tw.Write([]byte("TARGET\tDURATION\n"))
+	for _, name := range slices.Sorted(maps.Keys(durations)) {
+		fmt.Fprintf(tw, "%s\t%s\n", name, durations[name].Round(time.Second))
+	}
+	return nil
+}
+
+func printShards(w io.Writer, format string, shards []bake.Shard) error {
+	type shardList struct {
+		Shard    string   `json:"shard"`
+		Index    int      `json:"index"`
+		Targets  []string `json:"targets"`
+		Duration string   `json:"duration,omitempty"`
+	}
+	list := make([]shardList, 0, len(shards))
+	for _, s := range shards {
+		var duration string
+		if s.Duration > 0 {
+			duration = s.Duration.Round(time.Second).String()
+		}
+		list = append(list, shardList{
+			Shard:    fmt.Sprintf("%d/%d", s.Index, len(shards)),
+			Index:    s.Index,
+			Targets:  s.Targets,
+			Duration: duration,
+		})
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	defer tw.Flush()
+	tw.Write([]byte("SHARD\tTARGETS\tDURATION\n"))
+	for _, s := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Shard, strings.Join(s.Targets, ", "), s.Duration)
+	}
+	return nil
+}
+
 func bakeMetricAttributes(dockerCli command.Cli, driverType, url, cmdContext string, targets []string, options *bakeOptions) attribute.Set {
 	return attribute.NewSet(
 		commandNameAttribute.String("bake"),
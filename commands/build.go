@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
@@ -30,6 +31,7 @@ import (
 	"github.com/docker/buildx/util/dockerutil"
 	"github.com/docker/buildx/util/dockerutil/dockerconfig"
 	"github.com/docker/buildx/util/ioset"
+	"github.com/docker/buildx/util/lintreport"
 	"github.com/docker/buildx/util/metricutil"
 	"github.com/docker/buildx/util/osutil"
 	"github.com/docker/buildx/util/platformutil"
@@ -82,6 +84,7 @@ type buildOptions struct {
 	platforms      []string
 	policy         []string
 	callFunc       string
+	retry          string
 	secrets        []string
 	shmSize        dockeropts.MemBytes
 	ssh            []string
@@ -213,6 +216,11 @@ func (o *buildOptions) toOptions() (*BuildOptions, error) {
 		return nil, err
 	}
 
+	opts.Retry, err = buildflags.ParseRetryPolicy(o.retry)
+	if err != nil {
+		return nil, err
+	}
+
 	prm := confutil.MetadataProvenance()
 	if opts.CallFunc != nil || len(o.metadataFile) == 0 {
 		prm = confutil.MetadataProvenanceModeDisabled
@@ -450,6 +458,15 @@ func runBuild(ctx context.Context, dockerCli command.Cli, debugOpts debuggerOpti
 		}
 	}
 	if opts.CallFunc != nil {
+		if lintreport.IsFormat(opts.CallFunc.Format) {
+			target, err := lintReportTarget(cmp.Or(options.target, "default"), resp.ExporterResponse, inputs)
+			if err != nil {
+				return err
+			}
+			if err := writeLintReport(dockerCli.Out(), opts.CallFunc, []lintreport.Target{target}); err != nil {
+				return err
+			}
+		}
 		if exitCode, err := printResult(dockerCli.Out(), opts.CallFunc, resp.ExporterResponse, options.target, inputs); err != nil {
 			return err
 		} else if exitCode != 0 {
@@ -584,12 +601,14 @@ func buildCmd(dockerCli command.Cli, rootOpts *rootOptions, debugger debuggerOpt
 
 	flags.StringArrayVar(&options.platforms, "platform", platformsDefault, "Set target platform for build")
 
-	flags.StringArrayVar(&options.policy, "policy", []string{}, `Policy configuration (format: "filename=path[,filename=path][,reset=true|false][,disabled=true|false][,strict=true|false][,log-level=level]")`)
+	flags.StringArrayVar(&options.policy, "policy", []string{}, `Policy configuration (format: "filename=path[,filename=path][,reset=true|false][,disabled=true|false][,strict=true|false][,log-level=level][,audit-log=path]")`)
 
 	flags.BoolVar(&options.exportPush, "push", false, `Shorthand for "--output=type=registry,unpack=false"`)
 
 	flags.BoolVarP(&options.quiet, "quiet", "q", false, "Suppress the build output and print image ID on success")
 
+	flags.StringVar(&options.retry, "retry", "", `Retry policy for transient failures (format: "attempts=3,on=push|network|node")`)
+
 	flags.StringArrayVar(&options.secrets, "secret", []string{}, `Secret to expose to the build (format: "id=mysecret[,src=/local/secret]")`)
 
 	flags.Var(&options.shmSize, "shm-size", `Shared memory size for build containers`)
@@ -847,6 +866,11 @@ func printResult(w io.Writer, f *buildflags.CallFunc, res map[string]string, tar
 	case "subrequests.describe":
 		return 0, printValue(w, subrequests.PrintDescribe, subrequests.SubrequestsDescribeDefinition.Version, f.Format, res)
 	case "lint":
+		if lintreport.IsFormat(f.Format) {
+			// Reports are written by writeLintReport, only the status code
+			// is handled here.
+			break
+		}
 		lintResults := lint.LintResults{}
 		if result, ok := res["result.json"]; ok {
 			if err := json.Unmarshal([]byte(result), &lintResults); err != nil {
@@ -864,21 +888,7 @@ func printResult(w io.Writer, f *buildflags.CallFunc, res map[string]string, tar
 			}
 			fmt.Fprintf(w, "Check complete, %s\n", warningCountMsg)
 		}
-		sourceInfoMap := func(sourceInfo *solverpb.SourceInfo) *solverpb.SourceInfo {
-			if sourceInfo == nil || inp == nil {
-				return sourceInfo
-			}
-			if target == "" {
-				target = "default"
-			}
-
-			if inp.DockerfileMappingSrc != "" {
-				newSourceInfo := proto.Clone(sourceInfo).(*solverpb.SourceInfo)
-				newSourceInfo.Filename = inp.DockerfileMappingSrc
-				return newSourceInfo
-			}
-			return sourceInfo
-		}
+		sourceInfoMap := lintSourceInfoMap(inp)
 
 		printLintWarnings := func(dt []byte, w io.Writer) error {
 			return lintResults.PrintTo(w, sourceInfoMap)
@@ -922,6 +932,66 @@ func printResult(w io.Writer, f *buildflags.CallFunc, res map[string]string, tar
 	return 0, nil
 }
 
+// lintSourceInfoMap maps the Dockerfile reported by the frontend to its
+// location on the client.
+func lintSourceInfoMap(inp *build.Inputs) lint.SourceInfoMap {
+	return func(sourceInfo *solverpb.SourceInfo) *solverpb.SourceInfo {
+		if sourceInfo == nil || inp == nil {
+			return sourceInfo
+		}
+		if inp.DockerfileMappingSrc != "" {
+			newSourceInfo := proto.Clone(sourceInfo).(*solverpb.SourceInfo)
+			newSourceInfo.Filename = inp.DockerfileMappingSrc
+			return newSourceInfo
+		}
+		return sourceInfo
+	}
+}
+
+// lintReportTarget returns the check results of a target from the response
+// of the lint subrequest.
+func lintReportTarget(name string, res map[string]string, inp *build.Inputs) (lintreport.Target, error) {
+	results := &lint.LintResults{}
+	if dt, ok := res["result.json"]; ok {
+		if err := json.Unmarshal([]byte(dt), results); err != nil {
+			return lintreport.Target{}, errors.Wrapf(err, "failed to parse check results of %s", name)
+		}
+	}
+	return lintreport.Target{
+		Name:          name,
+		Results:       results,
+		SourceInfoMap: lintSourceInfoMap(inp),
+	}, nil
+}
+
+// writeLintReport writes the check results of targets in the report format
+// of f, to the output file of f or to w.
+func writeLintReport(w io.Writer, f *buildflags.CallFunc, targets []lintreport.Target) (err error) {
+	if f.Output != "" {
+		if err := os.MkdirAll(filepath.Dir(f.Output), 0o755); err != nil {
+			return err
+		}
+		out, err := os.Create(f.Output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err1 := out.Close(); err == nil {
+				err = err1
+			}
+		}()
+		w = out
+	}
+	switch f.Format {
+	case lintreport.FormatSARIF:
+		return lintreport.WriteSARIF(w, targets)
+	case lintreport.FormatJUnit:
+		return lintreport.WriteJUnit(w, targets)
+	default:
+		return errors.Errorf("unsupported report format %q", f.Format)
+	}
+}
+
 type callFunc func([]byte, io.Writer) error
 
 func printValue(w io.Writer, printer callFunc, version string, format string, res map[string]string) error {
@@ -1024,6 +1094,7 @@ type BuildOptions struct {
 	Annotations            []string
 	ProvenanceResponseMode string
 	Policy                 []buildflags.PolicyConfig
+	Retry                  *buildflags.RetryPolicy
 }
 
 // RunBuild runs the specified build and returns the result.
@@ -1073,11 +1144,12 @@ func RunBuild(ctx context.Context, dockerCli command.Cli, in *BuildOptions, inSt
 	}
 	opts.Platforms = platforms
 
+	authConfig := dockerconfig.LoadAuthConfig(dockerCli)
 	opts.Session = append(opts.Session, authprovider.NewDockerAuthProvider(authprovider.DockerAuthProviderConfig{
-		AuthConfigProvider: dockerconfig.LoadAuthConfig(dockerCli),
+		AuthConfigProvider: authConfig,
 	}))
 
-	secrets, err := build.CreateSecrets(in.Secrets)
+	secrets, err := build.CreateSecrets(in.Secrets, authConfig)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1166,6 +1238,7 @@ func RunBuild(ctx context.Context, dockerCli command.Cli, in *BuildOptions, inSt
 
 	opts.SourcePolicy = in.SourcePolicy
 	opts.Policy = in.Policy
+	opts.Retry = in.Retry
 
 	opts.Allow = allow
 
@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/buildx/builder"
+	"github.com/docker/buildx/store/storeutil"
+	"github.com/docker/buildx/util/cobrautil"
+	"github.com/docker/buildx/util/cobrautil/completion"
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func builderCmd(dockerCli command.Cli, rootOpts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "builder",
+		Short:             "Manage builder instances from definition files",
+		ValidArgsFunction: completion.Disable,
+
+		DisableFlagsInUseLine: true,
+	}
+
+	cmd.AddCommand(
+		builderApplyCmd(dockerCli),
+		builderExportCmd(dockerCli, rootOpts),
+	)
+	return cmd
+}
+
+type builderApplyOptions struct {
+	file      string
+	dryRun    bool
+	bootstrap bool
+	timeout   time.Duration
+}
+
+func runBuilderApply(ctx context.Context, dockerCli command.Cli, in builderApplyOptions) error {
+	var dt []byte
+	var err error
+	if in.file == "-" {
+		dt, err = io.ReadAll(dockerCli.In())
+	} else {
+		dt, err = os.ReadFile(in.file)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read builder definition")
+	}
+	def, err := builder.ParseDefinition(dt, in.file)
+	if err != nil {
+		return err
+	}
+
+	txn, release, err := storeutil.GetStore(dockerCli)
+	if err != nil {
+		return err
+	}
+	// Ensure the file lock gets released no matter what happens.
+	defer release()
+
+	plans := make([]*builder.ApplyPlan, 0, len(def.Builders))
+	for _, bd := range def.Builders {
+		p, err := builder.PlanApply(ctx, txn, dockerCli, bd)
+		if err != nil {
+			return err
+		}
+		plans = append(plans, p)
+	}
+
+	printApplyPlans(dockerCli.Out(), plans)
+	if in.dryRun {
+		return nil
+	}
+
+	var builders []*builder.Builder
+	for _, p := range plans {
+		if !p.Changed() {
+			continue
+		}
+		b, err := builder.Apply(ctx, txn, dockerCli, p, builder.ApplyOpts{
+			Timeout: in.timeout,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply builder %s", p.Name)
+		}
+		builders = append(builders, b)
+	}
+
+	// The store is no longer used from this point.
+	// Release it so we aren't holding the file lock during the boot.
+	release()
+
+	if in.bootstrap {
+		for _, b := range builders {
+			if _, err := b.Boot(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func printApplyPlans(w io.Writer, plans []*builder.ApplyPlan) {
+	tw := tabwriter.NewWriter(w, 1, 8, 2, ' ', 0)
+	defer tw.Flush()
+
+	for _, p := range plans {
+		status := "unchanged"
+		if p.Create {
+			status = "create"
+		} else if p.Changed() {
+			status = "update"
+		}
+		fmt.Fprintf(tw, "%s (%s)\t%s\t\n", p.Name, p.Driver, status)
+		for _, n := range p.Nodes {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", n.Name, n.Action, strings.Join(n.Fields, ", "))
+		}
+	}
+}
+
+func builderApplyCmd(dockerCli command.Cli) *cobra.Command {
+	var options builderApplyOptions
+
+	cmd := &cobra.Command{
+		Use:   "apply [OPTIONS]",
+		Short: "Create or update builder instances from a definition file",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.file == "" {
+				return errors.New("definition file is required, use --file to set it")
+			}
+			return runBuilderApply(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction:     completion.Disable,
+		DisableFlagsInUseLine: true,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&options.file, "file", "f", "", `Builder definition file (YAML or HCL), or "-" to read YAML from stdin`)
+	flags.BoolVar(&options.dryRun, "dry-run", false, "Print the changes without applying them")
+	flags.BoolVar(&options.bootstrap, "bootstrap", false, "Boot the created and updated builders")
+	setBuilderStatusTimeoutFlag(flags, &options.timeout)
+
+	// hide builder persistent flag for this command
+	cobrautil.HideInheritedFlags(cmd, "builder")
+
+	return cmd
+}
+
+type builderExportOptions struct {
+	builders []string
+	output   string
+}
+
+func runBuilderExport(dockerCli command.Cli, in builderExportOptions) error {
+	txn, release, err := storeutil.GetStore(dockerCli)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var def builder.Definition
+	for _, name := range in.builders {
+		b, err := builder.New(dockerCli,
+			builder.WithName(name),
+			builder.WithStore(txn),
+			builder.WithSkippedValidation(),
+		)
+		if err != nil {
+			return err
+		}
+		if b.DockerContext {
+			return errors.Errorf("context builder %s can't be exported", b.Name)
+		}
+		bd, err := builder.DefinitionFromNodeGroup(b.NodeGroup)
+		if err != nil {
+			return err
+		}
+		def.Builders = append(def.Builders, *bd)
+	}
+
+	dt, err := def.Marshal()
+	if err != nil {
+		return err
+	}
+	if in.output == "" || in.output == "-" {
+		_, err = dockerCli.Out().Write(dt)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(in.output), 0o755); err != nil {
+		return err
+	}
+	// the definition may contain registry keys of the buildkitd config
+	return os.WriteFile(in.output, dt, 0o600)
+}
+
+func builderExportCmd(dockerCli command.Cli, rootOpts *rootOptions) *cobra.Command {
+	var options builderExportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export [OPTIONS] [NAME...]",
+		Short: "Export builder instances to a definition file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.builders = []string{rootOpts.builder}
+			if len(args) > 0 {
+				options.builders = args
+			}
+			return runBuilderExport(dockerCli, options)
+		},
+		ValidArgsFunction:     completion.BuilderNames(dockerCli),
+		DisableFlagsInUseLine: true,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&options.output, "output", "o", "", "Write the definition to a file instead of stdout")
+
+	return cmd
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/docker/buildx/util/cobrautil/completion"
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -28,6 +29,7 @@ type createOptions struct {
 	buildkitdFlags      string
 	buildkitdConfigFile string
 	bootstrap           bool
+	reconcile           bool
 	timeout             time.Duration
 	// upgrade      bool // perform upgrade of the driver
 }
@@ -41,6 +43,9 @@ func runCreate(ctx context.Context, dockerCli command.Cli, in createOptions, arg
 	defer release()
 
 	if in.actionLeave {
+		if in.reconcile {
+			return errors.New("--reconcile cannot be used with --leave")
+		}
 		return builder.Leave(ctx, txn, dockerCli, builder.LeaveOpts{
 			Name:     in.name,
 			NodeName: in.nodeName,
@@ -52,19 +57,29 @@ func runCreate(ctx context.Context, dockerCli command.Cli, in createOptions, arg
 		ep = args[0]
 	}
 
-	b, err := builder.Create(ctx, txn, dockerCli, builder.CreateOpts{
-		Name:                in.name,
-		Driver:              in.driver,
-		NodeName:            in.nodeName,
-		Platforms:           in.platform,
-		DriverOpts:          in.driverOpts,
-		BuildkitdFlags:      in.buildkitdFlags,
-		BuildkitdConfigFile: in.buildkitdConfigFile,
-		Use:                 in.use,
-		Endpoint:            ep,
-		Append:              in.actionAppend,
-		Timeout:             in.timeout,
-	})
+	var b *builder.Builder
+	if in.reconcile && !in.changesBuilder(args) {
+		// only reconcile the existing builder
+		b, err = builder.New(dockerCli,
+			builder.WithName(in.name),
+			builder.WithStore(txn),
+			builder.WithSkippedValidation(),
+		)
+	} else {
+		b, err = builder.Create(ctx, txn, dockerCli, builder.CreateOpts{
+			Name:                in.name,
+			Driver:              in.driver,
+			NodeName:            in.nodeName,
+			Platforms:           in.platform,
+			DriverOpts:          in.driverOpts,
+			BuildkitdFlags:      in.buildkitdFlags,
+			BuildkitdConfigFile: in.buildkitdConfigFile,
+			Use:                 in.use,
+			Endpoint:            ep,
+			Append:              in.actionAppend,
+			Timeout:             in.timeout,
+		})
+	}
 	if err != nil {
 		return err
 	}
@@ -73,6 +88,16 @@ func runCreate(ctx context.Context, dockerCli command.Cli, in createOptions, arg
 	// Release it so we aren't holding the file lock during the boot.
 	release()
 
+	if in.reconcile {
+		drifts, err := b.Reconcile(ctx)
+		if err != nil {
+			return err
+		}
+		for _, nd := range drifts {
+			_, _ = fmt.Fprintf(dockerCli.Err(), "Recreated node %s\n", nd.Name)
+		}
+	}
+
 	if in.bootstrap {
 		if _, err = b.Boot(ctx); err != nil {
 			return err
@@ -83,6 +108,14 @@ func runCreate(ctx context.Context, dockerCli command.Cli, in createOptions, arg
 	return nil
 }
 
+// changesBuilder reports whether the options change the builder instead of
+// only reconciling it.
+func (in createOptions) changesBuilder(args []string) bool {
+	return len(args) > 0 || in.driver != "" || in.nodeName != "" || len(in.platform) > 0 ||
+		len(in.driverOpts) > 0 || in.buildkitdFlags != "" || in.buildkitdConfigFile != "" ||
+		in.actionAppend || in.use
+}
+
 func createCmd(dockerCli command.Cli) *cobra.Command {
 	var options createOptions
 
@@ -123,6 +156,7 @@ func createCmd(dockerCli command.Cli) *cobra.Command {
 	flags.BoolVar(&options.actionAppend, "append", false, "Append a node to builder instead of changing it")
 	flags.BoolVar(&options.actionLeave, "leave", false, "Remove a node from builder instead of changing it")
 	flags.BoolVar(&options.use, "use", false, "Set the current builder instance")
+	flags.BoolVar(&options.reconcile, "reconcile", false, "Recreate the nodes whose BuildKit daemon drifted from their configuration")
 	setBuilderStatusTimeoutFlag(flags, &options.timeout)
 
 	// hide builder persistent flag for this command
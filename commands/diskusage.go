@@ -1,21 +1,32 @@
 package commands
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/containerd/containerd/v2/core/content/proxy"
 	"github.com/docker/buildx/builder"
+	"github.com/docker/buildx/localstate"
 	"github.com/docker/buildx/util/cobrautil/completion"
+	"github.com/docker/buildx/util/confutil"
+	historyutil "github.com/docker/buildx/util/history"
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/cli/opts"
 	"github.com/docker/go-units"
+	controlapi "github.com/moby/buildkit/api/services/control"
 	"github.com/moby/buildkit/client"
+	dclient "github.com/moby/moby/client"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
 )
@@ -35,6 +46,14 @@ const (
 
 	duDefaultTableFormat = "table {{.ID}}\t{{.Reclaimable}}\t{{.Size}}\t{{.LastUsedAt}}"
 
+	duGroupByTarget     = "target"
+	duGroupByRepository = "repository"
+	duGroupByType       = "type"
+
+	// cacheBuildsLimit is the number of recent builds per node loaded to
+	// attribute cache records
+	cacheBuildsLimit = 100
+
 	duDefaultPrettyTemplate = `ID:           {{.ID}}
 {{- if .Parents }}
 Parents:
@@ -65,10 +84,25 @@ type duOptions struct {
 	filter  opts.FilterOpt
 	verbose bool
 	format  string
+	groupBy string
 	timeout time.Duration
 }
 
 func runDiskUsage(ctx context.Context, dockerCli command.Cli, opts duOptions) error {
+	switch opts.groupBy {
+	case "", duGroupByTarget, duGroupByRepository, duGroupByType:
+	default:
+		return errors.Errorf("invalid --group-by value %q, expected one of %s, %s, %s", opts.groupBy, duGroupByTarget, duGroupByRepository, duGroupByType)
+	}
+	if opts.groupBy != "" {
+		if opts.verbose {
+			return errors.New("--group-by and --verbose cannot be used together")
+		}
+		if opts.format != "" && opts.format != formatter.TableFormatKey && opts.format != formatter.JSONFormatKey {
+			return errors.Errorf("--group-by only supports %q and %q formats", formatter.TableFormatKey, formatter.JSONFormatKey)
+		}
+	}
+
 	if opts.format != "" && opts.verbose {
 		return errors.New("--format and --verbose cannot be used together")
 	} else if opts.format == "" {
@@ -83,7 +117,8 @@ func runDiskUsage(ctx context.Context, dockerCli command.Cli, opts duOptions) er
 		opts.format = duDefaultTableFormat
 	}
 
-	pi, err := toBuildkitPruneInfo(opts.filter.Value())
+	filters, targets := splitTargetFilter(opts.filter.Value())
+	pi, err := toBuildkitPruneInfo(filters)
 	if err != nil {
 		return err
 	}
@@ -109,7 +144,16 @@ func runDiskUsage(ctx context.Context, dockerCli command.Cli, opts duOptions) er
 		}
 	}
 
+	var ls *localstate.LocalState
+	if opts.groupBy == duGroupByTarget || opts.groupBy == duGroupByRepository || len(targets) > 0 {
+		ls, err = localstate.New(confutil.NewConfig(dockerCli))
+		if err != nil {
+			return err
+		}
+	}
+
 	out := make([][]*client.UsageInfo, len(nodes))
+	builds := make([][]historyutil.CacheBuild, len(nodes))
 
 	eg, ctx := errgroup.WithContext(ctx)
 	for i, node := range nodes {
@@ -124,6 +168,15 @@ func runDiskUsage(ctx context.Context, dockerCli command.Cli, opts duOptions) er
 					if err != nil {
 						return err
 					}
+					if ls != nil {
+						builds[i], err = loadCacheBuilds(ctx, c, node, ls)
+						if err != nil {
+							return err
+						}
+					}
+					if len(targets) > 0 {
+						du = filterCacheByTarget(du, builds[i], targets)
+					}
 					out[i] = du
 					return nil
 				}
@@ -136,6 +189,21 @@ func runDiskUsage(ctx context.Context, dockerCli command.Cli, opts duOptions) er
 		return err
 	}
 
+	if opts.groupBy != "" {
+		groups := groupDiskUsage(out, builds, opts.groupBy)
+		if opts.format == formatter.JSONFormatKey {
+			enc := json.NewEncoder(dockerCli.Out())
+			enc.SetIndent("", "  ")
+			return enc.Encode(groups)
+		}
+		printDiskUsageGroups(dockerCli.Out(), groups, opts.groupBy)
+		if len(opts.filter.Value()) == 0 {
+			fmt.Fprintln(dockerCli.Out())
+			printSummary(dockerCli.Out(), out)
+		}
+		return nil
+	}
+
 	fctx := formatter.Context{
 		Output: dockerCli.Out(),
 		Format: formatter.Format(opts.format),
@@ -204,6 +272,7 @@ func duCmd(dockerCli command.Cli, rootOpts *rootOptions) *cobra.Command {
 	flags.Var(&options.filter, "filter", "Provide filter values")
 	flags.BoolVar(&options.verbose, "verbose", false, `Shorthand for "--format=pretty"`)
 	flags.StringVar(&options.format, "format", "", "Format the output")
+	flags.StringVar(&options.groupBy, "group-by", "", `Group cache usage by build target, source repository or record type ("target", "repository", "type")`)
 	setBuilderStatusTimeoutFlag(flags, &options.timeout)
 
 	return cmd
@@ -302,3 +371,210 @@ func printSummary(w io.Writer, dus [][]*client.UsageInfo) {
 	fmt.Fprintf(tw, "Total:\t%s\n", units.HumanSize(float64(total)))
 	tw.Flush()
 }
+
+type duGroup struct {
+	Name        string `json:"name"`
+	Records     int    `json:"records"`
+	Size        int64  `json:"size"`
+	Reclaimable int64  `json:"reclaimable"`
+}
+
+// groupDiskUsage aggregates the cache records of each node by the key
+// selected with groupBy, sorted from the largest group.
+func groupDiskUsage(dus [][]*client.UsageInfo, builds [][]historyutil.CacheBuild, groupBy string) []duGroup {
+	idx := map[string]int{}
+	var groups []duGroup
+	for i, du := range dus {
+		var owners []int
+		if groupBy != duGroupByType {
+			owners = historyutil.AttributeCache(du, builds[i])
+		}
+		for j, di := range du {
+			var key string
+			switch groupBy {
+			case duGroupByType:
+				key = string(di.RecordType)
+			case duGroupByRepository:
+				key = historyutil.CacheRepository(di.Description)
+				if key == "" {
+					key = ownerKey(builds[i], owners[j], func(b historyutil.CacheBuild) string { return b.Repository })
+				}
+			case duGroupByTarget:
+				key = ownerKey(builds[i], owners[j], func(b historyutil.CacheBuild) string { return b.Target })
+			}
+			if key == "" {
+				key = "<none>"
+			}
+			k, ok := idx[key]
+			if !ok {
+				k = len(groups)
+				idx[key] = k
+				groups = append(groups, duGroup{Name: key})
+			}
+			groups[k].Records++
+			if di.Size > 0 {
+				groups[k].Size += di.Size
+				if !di.InUse {
+					groups[k].Reclaimable += di.Size
+				}
+			}
+		}
+	}
+	slices.SortFunc(groups, func(a, b duGroup) int {
+		return cmp.Or(cmp.Compare(b.Size, a.Size), cmp.Compare(a.Name, b.Name))
+	})
+	return groups
+}
+
+// ownerKey returns the group key of a cache record from the build owning it.
+func ownerKey(builds []historyutil.CacheBuild, owner int, key func(historyutil.CacheBuild) string) string {
+	switch owner {
+	case historyutil.NoOwner:
+		return ""
+	case historyutil.SharedOwner:
+		return "<shared>"
+	}
+	return key(builds[owner])
+}
+
+func printDiskUsageGroups(w io.Writer, groups []duGroup, groupBy string) {
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	fmt.Fprintf(tw, "%s\tRECORDS\tRECLAIMABLE\tSIZE\n", strings.ToUpper(groupBy))
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", g.Name, g.Records, units.HumanSize(float64(g.Reclaimable)), units.HumanSize(float64(g.Size)))
+	}
+	tw.Flush()
+}
+
+// splitTargetFilter removes the target filter from filters. Cache records
+// are attributed to targets by buildx from the build history, so this
+// filter can't be handled by BuildKit.
+func splitTargetFilter(filters dclient.Filters) (dclient.Filters, []string) {
+	targets := getFilter(filters, "target")
+	if len(targets) == 0 {
+		return filters, nil
+	}
+	filters = filters.Clone()
+	delete(filters, "target")
+	slices.Sort(targets)
+	return filters, targets
+}
+
+// filterCacheByTarget returns the cache records attributed to one of the
+// targets. Records shared with other targets are never returned.
+func filterCacheByTarget(du []*client.UsageInfo, builds []historyutil.CacheBuild, targets []string) []*client.UsageInfo {
+	owners := historyutil.AttributeCache(du, builds)
+	var out []*client.UsageInfo
+	for i, di := range du {
+		if owners[i] >= 0 && slices.Contains(targets, builds[owners[i]].Target) {
+			out = append(out, di)
+		}
+	}
+	return out
+}
+
+// loadBuildRecords returns the completed build records of the history.
+func loadBuildRecords(ctx context.Context, c *client.Client) ([]*controlapi.BuildHistoryRecord, error) {
+	serv, err := c.ControlClient().ListenBuildHistory(ctx, &controlapi.BuildHistoryRequest{
+		EarlyExit: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer serv.CloseSend()
+
+	var records []*controlapi.BuildHistoryRecord
+	for {
+		he, err := serv.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if he.Type != controlapi.BuildHistoryEventType_COMPLETE || he.Record == nil {
+			continue
+		}
+		records = append(records, he.Record)
+	}
+	return records, nil
+}
+
+// loadCacheBuilds returns the most recent completed builds of a node with
+// the vertices they ran and the digests of their materials, used to
+// attribute cache records to builds.
+func loadCacheBuilds(ctx context.Context, c *client.Client, node builder.Node, ls *localstate.LocalState) ([]historyutil.CacheBuild, error) {
+	if !node.Driver.HistoryAPISupported(ctx) {
+		return nil, nil
+	}
+	records, err := loadBuildRecords(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(records, func(a, b *controlapi.BuildHistoryRecord) int {
+		return b.CreatedAt.AsTime().Compare(a.CreatedAt.AsTime())
+	})
+	if len(records) > cacheBuildsLimit {
+		records = records[:cacheBuildsLimit]
+	}
+
+	store := proxy.NewContentStore(c.ContentClient())
+	builds := make([]historyutil.CacheBuild, len(records))
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(8)
+	for i, rec := range records {
+		eg.Go(func() error {
+			st, _ := ls.ReadRef(node.Builder, node.Name, rec.Ref)
+			target := historyutil.BuildName(rec.FrontendAttrs, st)
+			if st != nil && st.GroupRef != "" && st.Target != "" {
+				// bake target
+				target = st.Target
+			}
+			vertices, err := loadBuildVertices(ctx, c, rec.Ref)
+			if err != nil {
+				logrus.Debugf("failed to load vertices of build %s: %v", rec.Ref, err)
+			}
+			digests, err := historyutil.MaterialDigests(ctx, store, rec)
+			if err != nil {
+				logrus.Debugf("failed to load materials of build %s: %v", rec.Ref, err)
+			}
+			builds[i] = historyutil.CacheBuild{
+				Target:     target,
+				Repository: rec.FrontendAttrs["vcs:source"],
+				Vertices:   vertices,
+				Digests:    digests,
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return builds, nil
+}
+
+func loadBuildVertices(ctx context.Context, c *client.Client, ref string) ([]string, error) {
+	cl, err := c.ControlClient().Status(ctx, &controlapi.StatusRequest{
+		Ref: ref,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cl.CloseSend()
+
+	var vertices []string
+	for {
+		ev, err := cl.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return vertices, nil
+			}
+			return vertices, err
+		}
+		for _, v := range ev.Vertexes {
+			if v.Name != "" && !slices.Contains(vertices, v.Name) {
+				vertices = append(vertices, v.Name)
+			}
+		}
+	}
+}
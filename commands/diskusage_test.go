@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"testing"
+
+	historyutil "github.com/docker/buildx/util/history"
+	"github.com/moby/buildkit/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupDiskUsage(t *testing.T) {
+	dus := [][]*client.UsageInfo{
+		{
+			{ID: "a", Size: 100, RecordType: client.UsageRecordTypeRegular, Description: "mount / from exec /bin/sh -c make"},
+			{ID: "b", Size: 50, RecordType: client.UsageRecordTypeRegular, Parents: []string{"a"}, InUse: true},
+			{ID: "c", Size: 30, RecordType: client.UsageRecordTypeRegular, Description: "pulled from docker.io/library/alpine:latest"},
+			{ID: "d", Size: 10, RecordType: client.UsageRecordTypeCacheMount, Description: "cached mount /root/.cache from exec /bin/sh -c make"},
+		},
+	}
+	builds := [][]historyutil.CacheBuild{
+		{
+			{Target: "app", Repository: "https://github.com/docker/buildx.git", Vertices: []string{"[build 2/2] RUN --mount=type=cache,target=/root/.cache make"}},
+		},
+	}
+
+	require.Equal(t, []duGroup{
+		{Name: "app", Records: 3, Size: 160, Reclaimable: 110},
+		{Name: "<none>", Records: 1, Size: 30, Reclaimable: 30},
+	}, groupDiskUsage(dus, builds, duGroupByTarget))
+
+	require.Equal(t, []duGroup{
+		{Name: "https://github.com/docker/buildx.git", Records: 3, Size: 160, Reclaimable: 110},
+		{Name: "alpine", Records: 1, Size: 30, Reclaimable: 30},
+	}, groupDiskUsage(dus, builds, duGroupByRepository))
+
+	require.Equal(t, []duGroup{
+		{Name: "regular", Records: 3, Size: 180, Reclaimable: 130},
+		{Name: "exec.cachemount", Records: 1, Size: 10, Reclaimable: 10},
+	}, groupDiskUsage(dus, builds, duGroupByType))
+
+	require.Equal(t, []*client.UsageInfo{dus[0][0], dus[0][1], dus[0][3]}, filterCacheByTarget(dus[0], builds[0], []string{"app"}))
+	require.Empty(t, filterCacheByTarget(dus[0], builds[0], []string{"other"}))
+}
+
+func TestCombineIDFilter(t *testing.T) {
+	du := []*client.UsageInfo{{ID: "a"}, {ID: "b"}}
+	require.Equal(t, []string{"id==a", "id==b"}, combineIDFilter(nil, du))
+	require.Equal(t, []string{"id==a", "id==b"}, combineIDFilter([]string{""}, du))
+	require.Equal(t, []string{
+		"type==regular,id==a",
+		"description~=npm,id==a",
+		"type==regular,id==b",
+		"description~=npm,id==b",
+	}, combineIDFilter([]string{"type==regular", "description~=npm"}, du))
+	require.Empty(t, combineIDFilter([]string{"type==regular"}, nil))
+}
@@ -0,0 +1,435 @@
+package history
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/content/proxy"
+	"github.com/docker/buildx/builder"
+	"github.com/docker/buildx/localstate"
+	"github.com/docker/buildx/util/cobrautil/completion"
+	"github.com/docker/buildx/util/confutil"
+	historyutil "github.com/docker/buildx/util/history"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+)
+
+type diffOptions struct {
+	builder string
+	refs    [2]string
+	format  string
+}
+
+type changeT string
+
+const (
+	changeAdded   changeT = "added"
+	changeRemoved changeT = "removed"
+	changeChanged changeT = "changed"
+)
+
+type diffOutput struct {
+	Ref1 diffRecordOutput
+	Ref2 diffRecordOutput
+
+	BuildArgs     []valueDiffOutput `json:",omitempty"`
+	FrontendAttrs []valueDiffOutput `json:",omitempty"`
+	Materials     []valueDiffOutput `json:",omitempty"`
+	Outputs       []valueDiffOutput `json:",omitempty"`
+	Steps         []stepDiffOutput  `json:",omitempty"`
+}
+
+type diffRecordOutput struct {
+	Name           string        `json:",omitempty"`
+	Ref            string        `json:",omitempty"`
+	Status         statusT       `json:",omitempty"`
+	Duration       time.Duration `json:",omitempty"`
+	NumTotalSteps  int32
+	NumCachedSteps int32
+}
+
+type valueDiffOutput struct {
+	Name   string
+	Change changeT
+	Ref1   string `json:",omitempty"`
+	Ref2   string `json:",omitempty"`
+}
+
+type stepDiffOutput struct {
+	Name   string
+	Change changeT     `json:",omitempty"`
+	Ref1   *stepOutput `json:",omitempty"`
+	Ref2   *stepOutput `json:",omitempty"`
+}
+
+type stepOutput struct {
+	Duration time.Duration `json:",omitempty"`
+	Cached   bool          `json:",omitempty"`
+	Error    string        `json:",omitempty"`
+}
+
+// diffRecord holds the properties of a build record that are compared.
+type diffRecord struct {
+	summary   diffRecordOutput
+	buildArgs map[string]string
+	attrs     map[string]string
+	materials map[string]string
+	outputs   map[string]string
+	steps     []namedStep
+}
+
+type namedStep struct {
+	name    string
+	started time.Time
+	stepOutput
+}
+
+func runDiff(ctx context.Context, dockerCli command.Cli, opts diffOptions) error {
+	nodes, err := loadNodes(ctx, dockerCli, opts.builder)
+	if err != nil {
+		return err
+	}
+
+	var recs [2]*diffRecord
+	for i, ref := range opts.refs {
+		r, err := loadDiffRecord(ctx, dockerCli, ref, nodes)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load build record %s", ref)
+		}
+		recs[i] = r
+	}
+
+	out := diffRecords(recs[0], recs[1])
+
+	if opts.format == formatter.JSONFormatKey {
+		enc := json.NewEncoder(dockerCli.Out())
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	} else if opts.format != formatter.PrettyFormatKey {
+		return errors.Errorf("unsupported format %q", opts.format)
+	}
+
+	printDiff(dockerCli.Out(), out)
+	return nil
+}
+
+func loadDiffRecord(ctx context.Context, dockerCli command.Cli, ref string, nodes []builder.Node) (*diffRecord, error) {
+	recs, err := queryRecords(ctx, ref, nodes, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, errors.Errorf("no record found for ref %q", ref)
+	}
+	rec := &recs[0]
+
+	c, err := rec.node.Driver.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := proxy.NewContentStore(c.ContentClient())
+
+	var st *localstate.State
+	if ls, err := localstate.New(confutil.NewConfig(dockerCli)); err == nil {
+		st, _ = ls.ReadRef(rec.node.Builder, rec.node.Name, rec.Ref)
+	}
+
+	out := &diffRecord{
+		summary: diffRecordOutput{
+			Name:           historyutil.BuildName(rec.FrontendAttrs, st),
+			Ref:            rec.Ref,
+			Status:         recordStatus(rec.BuildHistoryRecord),
+			NumTotalSteps:  rec.NumTotalSteps,
+			NumCachedSteps: rec.NumCachedSteps,
+		},
+		buildArgs: map[string]string{},
+		attrs:     map[string]string{},
+		materials: map[string]string{},
+		outputs:   map[string]string{},
+	}
+	if rec.CreatedAt != nil && rec.CompletedAt != nil {
+		out.summary.Duration = rec.CompletedAt.AsTime().Sub(rec.CreatedAt.AsTime())
+	}
+
+	for k, v := range rec.FrontendAttrs {
+		if k == "frontend.caps" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(k, "build-arg:"); ok {
+			out.buildArgs[name] = v
+			continue
+		}
+		out.attrs[k] = v
+	}
+
+	attachments, err := allAttachments(ctx, store, *rec)
+	if err != nil {
+		return nil, err
+	}
+	materials, err := loadMaterials(ctx, store, attachments)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range materials {
+		digests := slices.Clone(m.Digests)
+		slices.Sort(digests)
+		out.materials[m.URI] = strings.Join(digests, ", ")
+	}
+
+	for k, v := range rec.ExporterResponse {
+		if strings.HasSuffix(k, ".digest") {
+			out.outputs[k] = v
+		}
+	}
+	if rec.Result != nil {
+		for i, r := range rec.Result.Results {
+			out.outputs[fmt.Sprintf("result-%d", i)] = r.Digest
+		}
+	}
+	for p, ri := range rec.Results {
+		for i, r := range ri.Results {
+			out.outputs[fmt.Sprintf("%s/result-%d", p, i)] = r.Digest
+		}
+	}
+
+	out.steps, err = loadSteps(ctx, c, rec.Ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load build steps")
+	}
+	return out, nil
+}
+
+func recordStatus(rec *controlapi.BuildHistoryRecord) statusT {
+	if rec.CompletedAt == nil {
+		return statusRunning
+	}
+	if rec.Error != nil {
+		if codes.Code(rec.Error.Code) == codes.Canceled {
+			return statusCanceled
+		}
+		return statusError
+	}
+	return statusComplete
+}
+
+// loadSteps reads the vertexes of a build from its status stream. Steps are
+// returned in the order they were started.
+func loadSteps(ctx context.Context, c *client.Client, ref string) ([]namedStep, error) {
+	st, err := c.ControlClient().Status(ctx, &controlapi.StatusRequest{
+		Ref: ref,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer st.CloseSend()
+
+	vertexes := map[digest.Digest]*client.Vertex{}
+	for {
+		ev, err := st.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		for _, v := range client.NewSolveStatus(ev).Vertexes {
+			vertexes[v.Digest] = v
+		}
+	}
+
+	steps := make([]namedStep, 0, len(vertexes))
+	for _, v := range vertexes {
+		if v.Started == nil {
+			continue
+		}
+		s := namedStep{
+			name:    v.Name,
+			started: *v.Started,
+			stepOutput: stepOutput{
+				Cached: v.Cached,
+				Error:  v.Error,
+			},
+		}
+		if v.Completed != nil {
+			s.Duration = v.Completed.Sub(*v.Started)
+		}
+		steps = append(steps, s)
+	}
+	slices.SortFunc(steps, func(a, b namedStep) int {
+		return cmp.Or(a.started.Compare(b.started), cmp.Compare(a.name, b.name))
+	})
+	return steps, nil
+}
+
+func diffRecords(r1, r2 *diffRecord) diffOutput {
+	return diffOutput{
+		Ref1:          r1.summary,
+		Ref2:          r2.summary,
+		BuildArgs:     diffValues(r1.buildArgs, r2.buildArgs),
+		FrontendAttrs: diffValues(r1.attrs, r2.attrs),
+		Materials:     diffValues(r1.materials, r2.materials),
+		Outputs:       diffValues(r1.outputs, r2.outputs),
+		Steps:         diffSteps(r1.steps, r2.steps),
+	}
+}
+
+func diffValues(m1, m2 map[string]string) []valueDiffOutput {
+	var out []valueDiffOutput
+	keys := slices.Sorted(maps.Keys(m1))
+	for k := range maps.Keys(m2) {
+		if _, ok := m1[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		v1, ok1 := m1[k]
+		v2, ok2 := m2[k]
+		switch {
+		case !ok1:
+			out = append(out, valueDiffOutput{Name: k, Change: changeAdded, Ref2: v2})
+		case !ok2:
+			out = append(out, valueDiffOutput{Name: k, Change: changeRemoved, Ref1: v1})
+		case v1 != v2:
+			out = append(out, valueDiffOutput{Name: k, Change: changeChanged, Ref1: v1, Ref2: v2})
+		}
+	}
+	return out
+}
+
+// diffSteps matches steps of both builds by name. Vertex digests can't be
+// used for matching as they change with any input of a step.
+func diffSteps(s1, s2 []namedStep) []stepDiffOutput {
+	byName := make(map[string][]namedStep, len(s1))
+	for _, s := range s1 {
+		byName[s.name] = append(byName[s.name], s)
+	}
+
+	var out []stepDiffOutput
+	for _, s := range s2 {
+		step2 := s.stepOutput
+		prev, ok := byName[s.name]
+		if !ok || len(prev) == 0 {
+			out = append(out, stepDiffOutput{Name: s.name, Change: changeAdded, Ref2: &step2})
+			continue
+		}
+		step1 := prev[0].stepOutput
+		byName[s.name] = prev[1:]
+		d := stepDiffOutput{Name: s.name, Ref1: &step1, Ref2: &step2}
+		if step1.Cached != step2.Cached || step1.Error != step2.Error {
+			d.Change = changeChanged
+		}
+		out = append(out, d)
+	}
+	for _, s := range s1 {
+		if rest := byName[s.name]; len(rest) > 0 {
+			step1 := rest[0].stepOutput
+			byName[s.name] = rest[1:]
+			out = append(out, stepDiffOutput{Name: s.name, Change: changeRemoved, Ref1: &step1})
+		}
+	}
+	return out
+}
+
+func printDiff(w io.Writer, out diffOutput) {
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	fmt.Fprintf(tw, "\tREF1\tREF2\n")
+	fmt.Fprintf(tw, "Name:\t%s\t%s\n", out.Ref1.Name, out.Ref2.Name)
+	fmt.Fprintf(tw, "Ref:\t%s\t%s\n", out.Ref1.Ref, out.Ref2.Ref)
+	fmt.Fprintf(tw, "Status:\t%s\t%s\n", out.Ref1.Status, out.Ref2.Status)
+	fmt.Fprintf(tw, "Duration:\t%s\t%s (%s)\n", formatDuration(out.Ref1.Duration), formatDuration(out.Ref2.Duration), formatDurationDelta(out.Ref2.Duration-out.Ref1.Duration))
+	fmt.Fprintf(tw, "Cached Steps:\t%d/%d\t%d/%d\n", out.Ref1.NumCachedSteps, out.Ref1.NumTotalSteps, out.Ref2.NumCachedSteps, out.Ref2.NumTotalSteps)
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	printValueDiff(w, out.BuildArgs, "Build Arg")
+	printValueDiff(w, out.FrontendAttrs, "Frontend Attr")
+	printValueDiff(w, out.Materials, "Material")
+	printValueDiff(w, out.Outputs, "Output")
+
+	if len(out.Steps) > 0 {
+		tw = tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+		fmt.Fprintf(tw, "STEP\tREF1\tREF2\tDELTA\n")
+		for _, s := range out.Steps {
+			var d1, d2 time.Duration
+			if s.Ref1 != nil {
+				d1 = s.Ref1.Duration
+			}
+			if s.Ref2 != nil {
+				d2 = s.Ref2.Duration
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, formatStep(s.Ref1), formatStep(s.Ref2), formatDurationDelta(d2-d1))
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+}
+
+func printValueDiff(w io.Writer, diffs []valueDiffOutput, title string) {
+	if len(diffs) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	fmt.Fprintf(tw, "%s\tCHANGE\tREF1\tREF2\n", strings.ToUpper(title))
+	for _, d := range diffs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Name, d.Change, d.Ref1, d.Ref2)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+func formatStep(s *stepOutput) string {
+	switch {
+	case s == nil:
+		return "-"
+	case s.Error != "":
+		return "ERROR"
+	case s.Cached:
+		return "CACHED"
+	default:
+		return formatDuration(s.Duration)
+	}
+}
+
+func formatDurationDelta(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	return "+" + formatDuration(d)
+}
+
+func diffCmd(dockerCli command.Cli, rootOpts RootOptions) *cobra.Command {
+	var options diffOptions
+
+	cmd := &cobra.Command{
+		Use:   "diff [OPTIONS] REF1 REF2",
+		Short: "Compare two build records",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.refs = [2]string{args[0], args[1]}
+			options.builder = *rootOpts.Builder
+			return runDiff(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction:     completion.Disable,
+		DisableFlagsInUseLine: true,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.format, "format", formatter.PrettyFormatKey, "Format the output")
+
+	return cmd
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffValues(t *testing.T) {
+	out := diffValues(map[string]string{
+		"a": "1",
+		"b": "2",
+		"c": "3",
+	}, map[string]string{
+		"a": "1",
+		"b": "changed",
+		"d": "4",
+	})
+	require.Equal(t, []valueDiffOutput{
+		{Name: "b", Change: changeChanged, Ref1: "2", Ref2: "changed"},
+		{Name: "c", Change: changeRemoved, Ref1: "3"},
+		{Name: "d", Change: changeAdded, Ref2: "4"},
+	}, out)
+
+	require.Empty(t, diffValues(map[string]string{"a": "1"}, map[string]string{"a": "1"}))
+}
+
+func TestDiffSteps(t *testing.T) {
+	s1 := []namedStep{
+		{name: "load", stepOutput: stepOutput{Duration: time.Second}},
+		{name: "RUN make", stepOutput: stepOutput{Cached: true}},
+		{name: "RUN old", stepOutput: stepOutput{Duration: time.Second}},
+	}
+	s2 := []namedStep{
+		{name: "load", stepOutput: stepOutput{Duration: 2 * time.Second}},
+		{name: "RUN make", stepOutput: stepOutput{Duration: 10 * time.Second}},
+		{name: "RUN new", stepOutput: stepOutput{Duration: time.Second}},
+	}
+
+	out := diffSteps(s1, s2)
+	require.Len(t, out, 4)
+
+	require.Equal(t, "load", out[0].Name)
+	require.Empty(t, out[0].Change)
+	require.Equal(t, time.Second, out[0].Ref1.Duration)
+	require.Equal(t, 2*time.Second, out[0].Ref2.Duration)
+
+	require.Equal(t, "RUN make", out[1].Name)
+	require.Equal(t, changeChanged, out[1].Change)
+	require.True(t, out[1].Ref1.Cached)
+	require.False(t, out[1].Ref2.Cached)
+
+	require.Equal(t, "RUN new", out[2].Name)
+	require.Equal(t, changeAdded, out[2].Change)
+	require.Nil(t, out[2].Ref1)
+
+	require.Equal(t, "RUN old", out[3].Name)
+	require.Equal(t, changeRemoved, out[3].Change)
+	require.Nil(t, out[3].Ref2)
+}
@@ -95,6 +95,8 @@ type inspectOutput struct {
 	Materials   []materialOutput   `json:",omitempty"`
 	Attachments []attachmentOutput `json:",omitempty"`
 
+	PolicyDecisions []policyDecisionOutput `json:",omitempty"`
+
 	Errors []string `json:",omitempty"`
 }
 
@@ -136,6 +138,15 @@ type attachmentOutput struct {
 	Type     string `json:",omitempty"`
 }
 
+type policyDecisionOutput struct {
+	Source       string   `json:",omitempty"`
+	Kind         string   `json:",omitempty"`
+	Platform     string   `json:",omitempty"`
+	Action       string   `json:",omitempty"`
+	Update       string   `json:",omitempty"`
+	DenyMessages []string `json:",omitempty"`
+}
+
 type errorOutput struct {
 	Code    int      `json:",omitempty"`
 	Message string   `json:",omitempty"`
@@ -430,34 +441,9 @@ workers0:
 		return err
 	}
 
-	provIndex := slices.IndexFunc(attachments, func(a attachment) bool {
-		return strings.HasPrefix(descrType(a.descr), "https://slsa.dev/provenance/")
-	})
-	if provIndex != -1 {
-		prov := attachments[provIndex]
-		predType := descrType(prov.descr)
-		dt, err := content.ReadBlob(ctx, store, prov.descr)
-		if err != nil {
-			return errors.Errorf("failed to read provenance %s: %v", prov.descr.Digest, err)
-		}
-		var pred *provenancetypes.ProvenancePredicateSLSA1
-		if predType == slsa02.PredicateSLSAProvenance {
-			var pred02 *provenancetypes.ProvenancePredicateSLSA02
-			if err := json.Unmarshal(dt, &pred02); err != nil {
-				return errors.Errorf("failed to unmarshal provenance %s: %v", prov.descr.Digest, err)
-			}
-			pred = pred02.ConvertToSLSA1()
-		} else if err := json.Unmarshal(dt, &pred); err != nil {
-			return errors.Errorf("failed to unmarshal provenance %s: %v", prov.descr.Digest, err)
-		}
-		if pred != nil {
-			for _, m := range pred.BuildDefinition.ResolvedDependencies {
-				out.Materials = append(out.Materials, materialOutput{
-					URI:     m.URI,
-					Digests: digestSetToDigests(m.Digest),
-				})
-			}
-		}
+	out.Materials, err = loadMaterials(ctx, store, attachments)
+	if err != nil {
+		return err
 	}
 
 	if len(attachments) > 0 {
@@ -474,6 +460,12 @@ workers0:
 		}
 	}
 
+	if st != nil {
+		for _, d := range st.PolicyDecisions {
+			out.PolicyDecisions = append(out.PolicyDecisions, policyDecisionOutput(d))
+		}
+	}
+
 	if opts.format == formatter.JSONFormatKey {
 		enc := json.NewEncoder(dockerCli.Out())
 		enc.SetIndent("", "  ")
@@ -651,6 +643,21 @@ workers0:
 		fmt.Fprintln(dockerCli.Out())
 	}
 
+	if len(out.PolicyDecisions) > 0 {
+		fmt.Fprintln(dockerCli.Out(), "Policy Decisions:")
+		tw = tabwriter.NewWriter(dockerCli.Out(), 1, 8, 1, '\t', 0)
+		fmt.Fprintf(tw, "SOURCE\tPLATFORM\tDECISION\tMESSAGES\n")
+		for _, d := range out.PolicyDecisions {
+			decision := d.Action
+			if d.Update != "" {
+				decision += " -> " + d.Update
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Source, d.Platform, decision, strings.Join(d.DenyMessages, "; "))
+		}
+		tw.Flush()
+		fmt.Fprintln(dockerCli.Out())
+	}
+
 	if out.Error != nil {
 		if out.Error.Sources != nil {
 			fmt.Fprint(dockerCli.Out(), string(out.Error.Sources))
@@ -774,6 +781,44 @@ loop0:
 	return name, logs, nil
 }
 
+// loadMaterials returns the resolved dependencies recorded in the provenance
+// attestation of a build record, if any.
+func loadMaterials(ctx context.Context, store content.Store, attachments []attachment) ([]materialOutput, error) {
+	provIndex := slices.IndexFunc(attachments, func(a attachment) bool {
+		return strings.HasPrefix(descrType(a.descr), "https://slsa.dev/provenance/")
+	})
+	if provIndex == -1 {
+		return nil, nil
+	}
+	prov := attachments[provIndex]
+	predType := descrType(prov.descr)
+	dt, err := content.ReadBlob(ctx, store, prov.descr)
+	if err != nil {
+		return nil, errors.Errorf("failed to read provenance %s: %v", prov.descr.Digest, err)
+	}
+	var pred *provenancetypes.ProvenancePredicateSLSA1
+	if predType == slsa02.PredicateSLSAProvenance {
+		var pred02 *provenancetypes.ProvenancePredicateSLSA02
+		if err := json.Unmarshal(dt, &pred02); err != nil {
+			return nil, errors.Errorf("failed to unmarshal provenance %s: %v", prov.descr.Digest, err)
+		}
+		pred = pred02.ConvertToSLSA1()
+	} else if err := json.Unmarshal(dt, &pred); err != nil {
+		return nil, errors.Errorf("failed to unmarshal provenance %s: %v", prov.descr.Digest, err)
+	}
+	if pred == nil {
+		return nil, nil
+	}
+	var out []materialOutput
+	for _, m := range pred.BuildDefinition.ResolvedDependencies {
+		out = append(out, materialOutput{
+			URI:     m.URI,
+			Digests: digestSetToDigests(m.Digest),
+		})
+	}
+	return out, nil
+}
+
 type attachment struct {
 	platform *ocispecs.Platform
 	descr    ocispecs.Descriptor
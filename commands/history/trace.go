@@ -30,6 +30,10 @@ type traceOptions struct {
 	ref     string
 	addr    string
 	compare string
+
+	exports []string
+	all     bool
+	filters []string
 }
 
 func loadTrace(ctx context.Context, ref string, nodes []builder.Node) (string, []byte, error) {
@@ -46,10 +50,37 @@ func loadTrace(ctx context.Context, ref string, nodes []builder.Node) (string, [
 		}
 		return "", nil, errors.Errorf("no record found for ref %q", ref)
 	}
-	rec := &recs[0]
 
+	spans, err := loadSpans(ctx, &recs[0])
+	if err != nil {
+		return "", nil, err
+	}
+
+	wrapper := struct {
+		Data []jaeger.Trace `json:"data"`
+	}{
+		Data: spans.JaegerData().Data,
+	}
+
+	if len(wrapper.Data) == 0 {
+		return "", nil, errors.New("no trace data")
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(wrapper); err != nil {
+		return "", nil, err
+	}
+
+	return string(wrapper.Data[0].TraceID), buf.Bytes(), nil
+}
+
+// loadSpans reads the trace spans of a completed build record. If the trace
+// of the record has not been written yet, the record is finalized first.
+func loadSpans(ctx context.Context, rec *historyRecord) (otelutil.Spans, error) {
 	if rec.CompletedAt == nil {
-		return "", nil, errors.Errorf("build %q is not completed, only completed builds can be traced", rec.Ref)
+		return nil, errors.Errorf("build %q is not completed, only completed builds can be traced", rec.Ref)
 	}
 
 	if rec.Trace == nil {
@@ -58,29 +89,29 @@ func loadTrace(ctx context.Context, ref string, nodes []builder.Node) (string, [
 
 		err := finalizeRecord(ctx, rec.Ref, *rec.node)
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
 		recs, err := queryRecords(ctx, rec.Ref, []builder.Node{*rec.node}, &queryOptions{
 			CompletedOnly: true,
 		})
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
 		if len(recs) == 0 {
-			return "", nil, errors.Errorf("build record %q was deleted", rec.Ref)
+			return nil, errors.Errorf("build record %q was deleted", rec.Ref)
 		}
 
 		rec = &recs[0]
 		if rec.Trace == nil {
-			return "", nil, errors.Errorf("build record %q is missing a trace", rec.Ref)
+			return nil, errors.Errorf("build record %q is missing a trace", rec.Ref)
 		}
 	}
 
 	c, err := rec.node.Driver.Client(ctx)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
 	store := proxy.NewContentStore(c.ContentClient())
@@ -91,40 +122,35 @@ func loadTrace(ctx context.Context, ref string, nodes []builder.Node) (string, [
 		Size:      rec.Trace.Size,
 	})
 	if err != nil {
-		return "", nil, err
-	}
-
-	spans, err := otelutil.ParseSpanStubs(io.NewSectionReader(ra, 0, ra.Size()))
-	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
+	defer ra.Close()
 
-	wrapper := struct {
-		Data []jaeger.Trace `json:"data"`
-	}{
-		Data: spans.JaegerData().Data,
-	}
+	return otelutil.ParseSpanStubs(io.NewSectionReader(ra, 0, ra.Size()))
+}
 
-	if len(wrapper.Data) == 0 {
-		return "", nil, errors.New("no trace data")
+func runTrace(ctx context.Context, dockerCli command.Cli, opts traceOptions) error {
+	if len(opts.exports) == 0 && (opts.all || len(opts.filters) > 0) {
+		return errors.New("--all and --filter can only be used with --export")
 	}
-
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(wrapper); err != nil {
-		return "", nil, err
+	if len(opts.exports) > 0 {
+		if opts.compare != "" {
+			return errors.New("--compare can't be used with --export")
+		}
+		if opts.ref != "" && (opts.all || len(opts.filters) > 0) {
+			return errors.New("a build record ref can't be used with --all or --filter")
+		}
 	}
 
-	return string(wrapper.Data[0].TraceID), buf.Bytes(), nil
-}
-
-func runTrace(ctx context.Context, dockerCli command.Cli, opts traceOptions) error {
 	nodes, err := loadNodes(ctx, dockerCli, opts.builder)
 	if err != nil {
 		return err
 	}
 
+	if len(opts.exports) > 0 {
+		return runTraceExport(ctx, dockerCli, nodes, opts)
+	}
+
 	traceID, data, err := loadTrace(ctx, opts.ref, nodes)
 	if err != nil {
 		return err
@@ -207,6 +233,9 @@ func traceCmd(dockerCli command.Cli, rootOpts RootOptions) *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVar(&options.addr, "addr", "127.0.0.1:0", "Address to bind the UI server")
 	flags.StringVar(&options.compare, "compare", "", "Compare with another build record")
+	flags.StringArrayVar(&options.exports, "export", nil, `Export traces instead of opening the UI (e.g., "otlp=grpc://localhost:4317", "otlp+http=https://collector:4318", "file=traces.jsonl")`)
+	flags.BoolVar(&options.all, "all", false, "Export the traces of all build records")
+	flags.StringArrayVar(&options.filters, "filter", nil, `Export the traces of build records matching filters (e.g., "status=error")`)
 
 	return cmd
 }
@@ -0,0 +1,221 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/docker/buildx/builder"
+	"github.com/docker/cli/cli/command"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	traceExportOTLP     = "otlp"
+	traceExportOTLPHTTP = "otlp+http"
+	traceExportFile     = "file"
+
+	otlpTracesPath = "/v1/traces"
+)
+
+// traceExport is a destination of exported traces, e.g.
+// "otlp=grpc://localhost:4317" or "file=traces.jsonl".
+type traceExport struct {
+	Type  string
+	Value string
+}
+
+func (e traceExport) String() string {
+	return e.Type + "=" + e.Value
+}
+
+func parseTraceExports(in []string) ([]traceExport, error) {
+	out := make([]traceExport, 0, len(in))
+	for _, v := range in {
+		typ, value, ok := strings.Cut(v, "=")
+		if !ok || value == "" {
+			return nil, errors.Errorf("invalid trace export %q, expected TYPE=DEST", v)
+		}
+		switch typ {
+		case traceExportOTLP, traceExportOTLPHTTP, traceExportFile:
+		default:
+			return nil, errors.Errorf("unsupported trace export type %q", typ)
+		}
+		out = append(out, traceExport{Type: typ, Value: value})
+	}
+	return out, nil
+}
+
+// newTraceExporter creates the exporter of a trace export destination. OTLP
+// exporters are also configured by the standard OTEL_EXPORTER_OTLP_*
+// environment variables, e.g. for headers or certificates.
+func newTraceExporter(ctx context.Context, dockerCli command.Cli, e traceExport) (tracesdk.SpanExporter, error) {
+	switch e.Type {
+	case traceExportOTLP:
+		endpoint, insecure, err := parseOTLPEndpoint(e.Value, "grpc", "grpcs")
+		if err != nil {
+			return nil, err
+		}
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint.Host)}
+		if insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case traceExportOTLPHTTP:
+		endpoint, insecure, err := parseOTLPEndpoint(e.Value, "http", "https")
+		if err != nil {
+			return nil, err
+		}
+		urlPath := endpoint.Path
+		if urlPath == "" || urlPath == "/" {
+			urlPath = otlpTracesPath
+		}
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint.Host),
+			otlptracehttp.WithURLPath(urlPath),
+		}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case traceExportFile:
+		var w io.WriteCloser
+		if e.Value == "-" {
+			w = nopWriteCloser{dockerCli.Out()}
+		} else {
+			f, err := os.Create(e.Value)
+			if err != nil {
+				return nil, err
+			}
+			w = f
+		}
+		return otlptrace.New(ctx, &otlpFileClient{w: w})
+	default:
+		return nil, errors.Errorf("unsupported trace export type %q", e.Type)
+	}
+}
+
+// parseOTLPEndpoint parses the endpoint of an OTLP collector. The endpoint is
+// insecure if its scheme is "http" or the given insecure scheme. Endpoints
+// without a scheme use TLS.
+func parseOTLPEndpoint(v, insecureScheme, secureScheme string) (*url.URL, bool, error) {
+	if !strings.Contains(v, "://") {
+		v = secureScheme + "://" + v
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "invalid OTLP endpoint %q", v)
+	}
+	if u.Host == "" {
+		return nil, false, errors.Errorf("invalid OTLP endpoint %q, missing host", v)
+	}
+	switch u.Scheme {
+	case insecureScheme, "http":
+		return u, true, nil
+	case secureScheme, "https":
+		return u, false, nil
+	default:
+		return nil, false, errors.Errorf("unsupported OTLP endpoint scheme %q", u.Scheme)
+	}
+}
+
+// otlpFileClient writes traces in the OTLP JSON file format, one TracesData
+// object per line.
+type otlpFileClient struct {
+	w  io.WriteCloser
+	mu sync.Mutex
+}
+
+func (c *otlpFileClient) Start(context.Context) error {
+	return nil
+}
+
+func (c *otlpFileClient) Stop(context.Context) error {
+	return c.w.Close()
+}
+
+func (c *otlpFileClient) UploadTraces(_ context.Context, spans []*tracepb.ResourceSpans) error {
+	dt, err := protojson.Marshal(&tracepb.TracesData{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = fmt.Fprintf(c.w, "%s\n", dt)
+	return err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func runTraceExport(ctx context.Context, dockerCli command.Cli, nodes []builder.Node, opts traceOptions) (retErr error) {
+	exports, err := parseTraceExports(opts.exports)
+	if err != nil {
+		return err
+	}
+
+	recs, err := queryRecords(ctx, opts.ref, nodes, &queryOptions{
+		CompletedOnly: true,
+		Filters:       opts.filters,
+	})
+	if err != nil {
+		return err
+	}
+	if len(recs) == 0 {
+		if opts.ref == "" {
+			return errors.New("no records found")
+		}
+		return errors.Errorf("no record found for ref %q", opts.ref)
+	}
+	if !opts.all && len(opts.filters) == 0 {
+		recs = recs[:1]
+	}
+
+	exporters := make([]tracesdk.SpanExporter, 0, len(exports))
+	defer func() {
+		for _, exp := range exporters {
+			if err := exp.Shutdown(context.WithoutCancel(ctx)); err != nil && retErr == nil {
+				retErr = errors.Wrap(err, "failed to flush traces")
+			}
+		}
+	}()
+	for _, e := range exports {
+		exp, err := newTraceExporter(ctx, dockerCli, e)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create trace exporter for %s", e)
+		}
+		exporters = append(exporters, exp)
+	}
+
+	var nspans int
+	for _, rec := range recs {
+		spans, err := loadSpans(ctx, &rec)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load trace for %s", rec.Ref)
+		}
+		roSpans := spans.ReadOnlySpans()
+		for i, exp := range exporters {
+			if err := exp.ExportSpans(ctx, roSpans); err != nil {
+				return errors.Wrapf(err, "failed to export trace for %s to %s", rec.Ref, exports[i])
+			}
+		}
+		nspans += len(roSpans)
+	}
+	fmt.Fprintf(dockerCli.Err(), "Exported %d traces (%d spans)\n", len(recs), nspans)
+	return nil
+}
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/buildx/util/otelutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestParseTraceExports(t *testing.T) {
+	exports, err := parseTraceExports([]string{
+		"otlp=grpc://localhost:4317",
+		"otlp+http=https://collector:4318",
+		"file=traces.jsonl",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []traceExport{
+		{Type: traceExportOTLP, Value: "grpc://localhost:4317"},
+		{Type: traceExportOTLPHTTP, Value: "https://collector:4318"},
+		{Type: traceExportFile, Value: "traces.jsonl"},
+	}, exports)
+
+	for _, v := range []string{"otlp", "otlp=", "jaeger=localhost:14268"} {
+		_, err := parseTraceExports([]string{v})
+		require.Error(t, err, v)
+	}
+}
+
+func TestParseOTLPEndpoint(t *testing.T) {
+	tcs := []struct {
+		value    string
+		host     string
+		path     string
+		insecure bool
+		err      bool
+	}{
+		{value: "grpc://localhost:4317", host: "localhost:4317", insecure: true},
+		{value: "grpcs://collector:4317", host: "collector:4317"},
+		{value: "http://localhost:4317", host: "localhost:4317", insecure: true},
+		{value: "collector:4317", host: "collector:4317"},
+		{value: "https://collector:4318/custom/traces", host: "collector:4318", path: "/custom/traces"},
+		{value: "ftp://collector:4317", err: true},
+		{value: "grpc://", err: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.value, func(t *testing.T) {
+			u, insecure, err := parseOTLPEndpoint(tc.value, "grpc", "grpcs")
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.host, u.Host)
+			require.Equal(t, tc.path, u.Path)
+			require.Equal(t, tc.insecure, insecure)
+		})
+	}
+}
+
+func TestOTLPFileClient(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "util", "otelutil", "fixtures", "bktraces.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	spans, err := otelutil.ParseSpanStubs(f)
+	require.NoError(t, err)
+	require.NotEmpty(t, spans)
+
+	out := filepath.Join(t.TempDir(), "traces.jsonl")
+	w, err := os.Create(out)
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+	exp, err := otlptrace.New(ctx, &otlpFileClient{w: w})
+	require.NoError(t, err)
+	require.NoError(t, exp.ExportSpans(ctx, spans.ReadOnlySpans()))
+	require.NoError(t, exp.Shutdown(ctx))
+
+	rf, err := os.Open(out)
+	require.NoError(t, err)
+	defer rf.Close()
+
+	var lines, nspans int
+	sc := bufio.NewScanner(rf)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		var td tracepb.TracesData
+		require.NoError(t, protojson.Unmarshal(sc.Bytes(), &td))
+		for _, rs := range td.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				nspans += len(ss.Spans)
+			}
+		}
+		lines++
+	}
+	require.NoError(t, sc.Err())
+	require.Equal(t, 1, lines)
+	require.Equal(t, len(spans), nspans)
+}
@@ -21,7 +21,9 @@ func RootCmd(rootcmd *cobra.Command, dockerCli command.Cli, opts RootOptions) *c
 
 	cmd.AddCommand(
 		createCmd(dockerCli, opts),
+		diffCmd(dockerCli, opts),
 		inspectCmd(dockerCli, opts),
+		syncCmd(dockerCli, opts),
 	)
 
 	return cmd
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/docker/buildx/builder"
+	"github.com/docker/buildx/util/cobrautil/completion"
+	"github.com/docker/buildx/util/imagetools"
+	"github.com/docker/buildx/util/progress"
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/buildkit/util/progress/progressui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+type syncOptions struct {
+	builder     string
+	file        string
+	dryrun      bool
+	concurrency int
+	progress    string
+}
+
+func runSync(ctx context.Context, dockerCli command.Cli, in syncOptions) error {
+	if in.file == "" {
+		return errors.Errorf("no sync configuration specified, please set --file")
+	}
+	if in.concurrency < 1 {
+		return errors.Errorf("invalid concurrency %d, must be at least 1", in.concurrency)
+	}
+
+	var dt []byte
+	var err error
+	if in.file == "-" {
+		dt, err = io.ReadAll(dockerCli.In())
+	} else {
+		dt, err = os.ReadFile(in.file)
+	}
+	if err != nil {
+		return err
+	}
+	cfg, err := imagetools.ParseSyncConfig(dt)
+	if err != nil {
+		return err
+	}
+
+	b, err := builder.New(dockerCli, builder.WithName(in.builder))
+	if err != nil {
+		return err
+	}
+	imageopt, err := b.ImageOpt()
+	if err != nil {
+		return err
+	}
+
+	r := imagetools.New(imageopt)
+	jobs, err := r.PlanSync(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx2, cancel := context.WithCancelCause(context.TODO())
+	defer func() { cancel(errors.WithStack(context.Canceled)) }()
+	progressMode := in.progress
+	if progressMode == "none" {
+		progressMode = "quiet"
+	}
+	printer, err := progress.NewPrinter(ctx2, os.Stderr, progressui.DisplayMode(progressMode))
+	if err != nil {
+		return err
+	}
+	pw := progress.WithPrefix(printer, "internal", true)
+
+	syncer := &imagetools.Syncer{
+		Resolver: r,
+		DryRun:   in.dryrun,
+	}
+	ctx = withMediaTypeKeyPrefix(ctx)
+	results := make([]imagetools.SyncResult, len(jobs))
+	eg, _ := errgroup.WithContext(ctx)
+	eg.SetLimit(in.concurrency)
+	for i, job := range jobs {
+		eg.Go(func() error {
+			name := fmt.Sprintf("syncing %s to %s", job.Source, job.Destination)
+			if in.dryrun {
+				name = fmt.Sprintf("checking %s in %s", job.Source, job.Destination)
+			}
+			return progress.Wrap(name, pw.Write, func(sub progress.SubLogger) error {
+				results[i] = syncer.Sync(ctx, job, func(msg string) {
+					sub.Log(1, []byte(msg+"\n"))
+				})
+				if results[i].Status == imagetools.SyncStatusFailed {
+					return errors.New(results[i].Error)
+				}
+				return nil
+			})
+		})
+	}
+	// failures are reported in the summary, other jobs keep running
+	_ = eg.Wait()
+	if err := printer.Wait(); err != nil {
+		return err
+	}
+
+	return printSyncSummary(dockerCli.Out(), results, in.dryrun)
+}
+
+func printSyncSummary(w io.Writer, results []imagetools.SyncResult, dryrun bool) error {
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "SOURCE\tDESTINATION\tDIGEST\tSTATUS")
+	counts := map[imagetools.SyncStatus]int{}
+	for _, res := range results {
+		counts[res.Status]++
+		status := string(res.Status)
+		if res.Error != "" {
+			status += ": " + res.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", res.Source, res.Destination, res.Digest, status)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	if dryrun {
+		fmt.Fprintf(w, "%d to copy, %d up-to-date, %d failed\n", counts[imagetools.SyncStatusPending], counts[imagetools.SyncStatusUpToDate], counts[imagetools.SyncStatusFailed])
+	} else {
+		fmt.Fprintf(w, "%d copied, %d up-to-date, %d failed\n", counts[imagetools.SyncStatusCopied], counts[imagetools.SyncStatusUpToDate], counts[imagetools.SyncStatusFailed])
+	}
+	if n := counts[imagetools.SyncStatusFailed]; n > 0 {
+		return errors.Errorf("failed to sync %d of %d images", n, len(results))
+	}
+	return nil
+}
+
+func syncCmd(dockerCli command.Cli, opts RootOptions) *cobra.Command {
+	var options syncOptions
+
+	cmd := &cobra.Command{
+		Use:   "sync [OPTIONS]",
+		Short: "Mirror repositories and tag sets between registries",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.builder = *opts.Builder
+			return runSync(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction:     completion.Disable,
+		DisableFlagsInUseLine: true,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&options.file, "file", "f", "", "Sync configuration file")
+	flags.BoolVar(&options.dryrun, "dry-run", false, "Show what would be copied without pushing")
+	flags.IntVar(&options.concurrency, "concurrency", 4, "Maximum number of images copied concurrently")
+	flags.StringVar(&options.progress, "progress", "auto", `Set type of progress output ("auto", "none", "plain", "rawjson", "tty")`)
+
+	return cmd
+}
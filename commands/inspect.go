@@ -22,9 +22,10 @@ import (
 )
 
 type inspectOptions struct {
-	bootstrap bool
-	builder   string
-	timeout   time.Duration
+	bootstrap  bool
+	builder    string
+	timeout    time.Duration
+	checkDrift bool
 }
 
 func runInspect(ctx context.Context, dockerCli command.Cli, in inspectOptions) error {
@@ -54,6 +55,18 @@ func runInspect(ctx context.Context, dockerCli command.Cli, in inspectOptions) e
 		}
 	}
 
+	var drifts map[string][]driver.Drift
+	var driftErr error
+	if in.checkDrift && err == nil {
+		var nds []builder.NodeDrift
+		if nds, driftErr = b.Drift(timeoutCtx); driftErr == nil {
+			drifts = make(map[string][]driver.Drift, len(nds))
+			for _, nd := range nds {
+				drifts[nd.Name] = nd.Drifts
+			}
+		}
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 	fmt.Fprintf(w, "Name:\t%s\n", b.Name)
 	fmt.Fprintf(w, "Driver:\t%s\n", b.Driver)
@@ -162,12 +175,24 @@ func runInspect(ctx context.Context, dockerCli command.Cli, in inspectOptions) e
 						fmt.Fprintf(w, "\t> %s\n", line)
 					}
 				}
+				if ds := drifts[n.Name]; len(ds) > 0 {
+					fmt.Fprintf(w, "Drift:\n")
+					for _, d := range ds {
+						fmt.Fprintf(w, "\t%s:\texpected %q, got %q\n", d.Field, d.Expected, d.Actual)
+					}
+				}
 			}
 		}
 	}
 
 	w.Flush()
 
+	if driftErr != nil {
+		return driftErr
+	}
+	if len(drifts) > 0 {
+		return errors.Errorf("builder %s has drifted from its configuration, run `docker buildx create --reconcile --name %s` to recreate the drifted nodes", b.Name, b.Name)
+	}
 	return nil
 }
 
@@ -191,6 +216,7 @@ func inspectCmd(dockerCli command.Cli, rootOpts *rootOptions) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.BoolVar(&options.bootstrap, "bootstrap", false, "Ensure builder has booted before inspecting")
+	flags.BoolVar(&options.checkDrift, "check-drift", false, "Compare the stored configuration of the nodes with their running BuildKit daemons")
 	setBuilderStatusTimeoutFlag(flags, &options.timeout)
 
 	return cmd
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/containerd/platforms"
@@ -66,11 +68,22 @@ func runLs(ctx context.Context, dockerCli command.Cli, in lsOptions) error {
 	}
 	defer func() { cancel(errors.WithStack(context.Canceled)) }()
 
+	drifts := make(map[*builder.Builder][]builder.NodeDrift, len(builders))
+	var driftsMu sync.Mutex
 	eg, _ := errgroup.WithContext(timeoutCtx)
 	for _, b := range builders {
 		func(b *builder.Builder) {
 			eg.Go(func() error {
-				_, _ = b.LoadNodes(timeoutCtx, builder.WithData())
+				if _, err := b.LoadNodes(timeoutCtx, builder.WithData()); err != nil {
+					return nil
+				}
+				// drift is only reported as a warning for running nodes,
+				// errors are ignored
+				if nds, err := b.Drift(timeoutCtx, builder.WithRunningOnly()); err == nil && len(nds) > 0 {
+					driftsMu.Lock()
+					drifts[b] = nds
+					driftsMu.Unlock()
+				}
 				return nil
 			})
 		}(b)
@@ -97,6 +110,21 @@ func runLs(ctx context.Context, dockerCli command.Cli, in lsOptions) error {
 		}
 	}
 
+	if len(drifts) > 0 {
+		_, _ = fmt.Fprintf(dockerCli.Err(), "\n")
+		for _, b := range builders {
+			for _, nd := range drifts[b] {
+				fields := make([]string, 0, len(nd.Drifts))
+				for _, d := range nd.Drifts {
+					if !slices.Contains(fields, d.Field) {
+						fields = append(fields, d.Field)
+					}
+				}
+				_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: node %s of builder %s has drifted from its configuration (%s), run `docker buildx create --reconcile --name %s` to recreate it\n", nd.Name, b.Name, strings.Join(fields, ", "), b.Name)
+			}
+		}
+	}
+
 	return nil
 }
 
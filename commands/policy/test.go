@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -23,8 +24,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+type testOpts struct {
+	policy.TestOptions
+	coverageOutput string
+	minCoverage    float64
+}
+
 func testCmd(dockerCli command.Cli, rootOpts RootOptions) *cobra.Command {
-	var opts policy.TestOptions
+	var opts testOpts
 	cmd := &cobra.Command{
 		Use:                   "test <path>",
 		Short:                 "Run policy tests",
@@ -39,10 +46,20 @@ func testCmd(dockerCli command.Cli, rootOpts RootOptions) *cobra.Command {
 	}
 	cmd.Flags().StringVar(&opts.Run, "run", "", "Run only tests with name containing this substring")
 	cmd.Flags().StringVar(&opts.Filename, "filename", "Dockerfile", "Name of the Dockerfile to validate")
+	cmd.Flags().BoolVar(&opts.Coverage, "coverage", false, "Report line coverage of the policy")
+	cmd.Flags().StringVar(&opts.coverageOutput, "coverage-output", "", "Write the coverage report as JSON to a file")
+	cmd.Flags().Float64Var(&opts.minCoverage, "min-coverage", 0, "Fail if the policy coverage percentage is below this value")
 	return cmd
 }
 
-func runTest(ctx context.Context, out io.Writer, path string, opts policy.TestOptions) error {
+func runTest(ctx context.Context, out io.Writer, path string, opts testOpts) error {
+	if opts.minCoverage < 0 || opts.minCoverage > 100 {
+		return errors.Errorf("invalid --min-coverage value %v, must be between 0 and 100", opts.minCoverage)
+	}
+	if opts.coverageOutput != "" || opts.minCoverage > 0 {
+		opts.Coverage = true
+	}
+
 	root := os.DirFS(".")
 	statFS, ok := root.(fs.StatFS)
 	if !ok {
@@ -50,7 +67,7 @@ func runTest(ctx context.Context, out io.Writer, path string, opts policy.TestOp
 	}
 	opts.Root = statFS
 
-	summary, err := policy.RunPolicyTests(ctx, path, opts)
+	summary, err := policy.RunPolicyTests(ctx, path, opts.TestOptions)
 	if err != nil {
 		return err
 	}
@@ -92,12 +109,49 @@ func runTest(ctx context.Context, out io.Writer, path string, opts policy.TestOp
 		}
 	}
 
+	if summary.Coverage != nil {
+		printCoverage(out, summary.Coverage)
+		if opts.coverageOutput != "" {
+			dt, err := json.MarshalIndent(summary.Coverage, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(opts.coverageOutput, append(dt, '\n'), 0644); err != nil {
+				return errors.Wrap(err, "failed to write coverage report")
+			}
+		}
+	}
+
 	if summary.Failed > 0 {
 		return cobrautil.ExitCodeError(1)
 	}
+	if summary.Coverage != nil && summary.Coverage.Coverage < opts.minCoverage {
+		return errors.Errorf("policy coverage %.1f%% is below the minimum of %.1f%%", summary.Coverage.Coverage, opts.minCoverage)
+	}
 	return nil
 }
 
+func printCoverage(out io.Writer, report *policy.CoverageReport) {
+	_, _ = fmt.Fprintf(out, "coverage: %.1f%% (%d/%d lines)\n", report.Coverage, report.CoveredLines, report.CoveredLines+report.NotCoveredLines)
+	for _, f := range report.Files {
+		_, _ = fmt.Fprintf(out, "%s: %.1f%% (%d/%d lines)", f.Filename, f.Coverage, f.CoveredLines, f.CoveredLines+f.NotCoveredLines)
+		if len(f.NotCovered) > 0 {
+			lines := make([]string, len(f.NotCovered))
+			for i, r := range f.NotCovered {
+				lines[i] = strconv.Itoa(r.Start)
+				if r.End != r.Start {
+					lines[i] += "-" + strconv.Itoa(r.End)
+				}
+			}
+			_, _ = fmt.Fprintf(out, ", not covered: %s", strings.Join(lines, ", "))
+		}
+		_, _ = fmt.Fprintln(out)
+		for _, r := range f.Rules {
+			_, _ = fmt.Fprintf(out, "  %s: %.1f%% (%d/%d lines)\n", r.Name, r.Coverage, r.CoveredLines, r.CoveredLines+r.NotCoveredLines)
+		}
+	}
+}
+
 func writeJSON(out io.Writer, label string, v any) {
 	dt, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"github.com/docker/buildx/builder"
+	"github.com/docker/buildx/localstate"
 	"github.com/docker/buildx/util/cobrautil/completion"
+	"github.com/docker/buildx/util/confutil"
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/opts"
@@ -45,7 +47,7 @@ const (
 )
 
 func runPrune(ctx context.Context, dockerCli command.Cli, opts pruneOptions) error {
-	pruneFilters := command.PruneFilters(dockerCli, opts.filter.Value())
+	pruneFilters, targets := splitTargetFilter(command.PruneFilters(dockerCli, opts.filter.Value()))
 	pi, err := toBuildkitPruneInfo(pruneFilters)
 	if err != nil {
 		return err
@@ -85,6 +87,14 @@ func runPrune(ctx context.Context, dockerCli command.Cli, opts pruneOptions) err
 		}
 	}
 
+	var ls *localstate.LocalState
+	if len(targets) > 0 {
+		ls, err = localstate.New(confutil.NewConfig(dockerCli))
+		if err != nil {
+			return err
+		}
+	}
+
 	ch := make(chan client.UsageInfo)
 	printed := make(chan struct{})
 
@@ -129,9 +139,21 @@ func runPrune(ctx context.Context, dockerCli command.Cli, opts pruneOptions) err
 						}
 					}
 
+					filter := pi.Filter
+					if len(targets) > 0 {
+						filter, err = targetPruneFilter(ctx, c, node, ls, pi.Filter, targets)
+						if err != nil {
+							return err
+						}
+						if len(filter) == 0 {
+							// no cache record attributed to the targets on this node
+							return nil
+						}
+					}
+
 					popts := []client.PruneOption{
 						client.WithKeepOpt(pi.KeepDuration, opts.reservedSpace.Value(), opts.maxUsedSpace.Value(), opts.minFreeSpace.Value()),
-						client.WithFilter(pi.Filter),
+						client.WithFilter(filter),
 					}
 					if opts.all {
 						popts = append(popts, client.PruneAll)
@@ -155,6 +177,42 @@ func runPrune(ctx context.Context, dockerCli command.Cli, opts pruneOptions) err
 	return nil
 }
 
+// targetPruneFilter returns a filter matching the cache records of a node
+// attributed to one of the targets, combined with each of the base filters.
+func targetPruneFilter(ctx context.Context, c *client.Client, node builder.Node, ls *localstate.LocalState, base []string, targets []string) ([]string, error) {
+	du, err := c.DiskUsage(ctx, client.WithFilter(base))
+	if err != nil {
+		return nil, err
+	}
+	builds, err := loadCacheBuilds(ctx, c, node, ls)
+	if err != nil {
+		return nil, err
+	}
+	return combineIDFilter(base, filterCacheByTarget(du, builds, targets)), nil
+}
+
+// combineIDFilter returns a filter matching the records that match one of
+// the base filters and have one of the IDs of du. BuildKit ORs the entries
+// of a filter list while the comma-separated conditions of an entry are
+// ANDed, so every base entry is combined with every ID.
+func combineIDFilter(base []string, du []*client.UsageInfo) []string {
+	var filter []string
+	for _, di := range du {
+		f := "id==" + di.ID
+		added := false
+		for _, b := range base {
+			if b != "" {
+				filter = append(filter, b+","+f)
+				added = true
+			}
+		}
+		if !added {
+			filter = append(filter, f)
+		}
+	}
+	return filter
+}
+
 func loadLLBCaps(ctx context.Context, c *client.Client) (apicaps.CapSet, error) {
 	var caps apicaps.CapSet
 	_, err := c.Build(ctx, client.SolveOpt{
@@ -131,6 +131,9 @@ func (d *Adapter[C]) Initialize(c Context, req *dap.InitializeRequest, resp *dap
 
 	// Set capabilities.
 	resp.Body.SupportsConfigurationDoneRequest = true
+	resp.Body.SupportsConditionalBreakpoints = true
+	resp.Body.SupportsHitConditionalBreakpoints = true
+	resp.Body.SupportsLogPoints = true
 	return nil
 }
 
@@ -239,7 +242,7 @@ func (d *Adapter[C]) launch(c Context) {
 			started := c.Go(func(c Context) {
 				defer d.deleteThread(c, t)
 				defer close(req.errCh)
-				req.errCh <- t.Evaluate(c, req.c, req.ref, req.meta, req.inputs, d.cfg)
+				req.errCh <- t.Evaluate(c, req.c, req.ref, req.meta, req.inputs, req.buildArgs, d.cfg)
 			})
 
 			if !started {
@@ -312,43 +315,45 @@ func (d *Adapter[T]) getThreadByFrameID(id int) (t *thread) {
 }
 
 type evaluateRequest struct {
-	name   string
-	c      gateway.Client
-	ref    gateway.Reference
-	meta   map[string][]byte
-	inputs build.Inputs
-	errCh  chan<- error
+	name      string
+	c         gateway.Client
+	ref       gateway.Reference
+	meta      map[string][]byte
+	inputs    build.Inputs
+	buildArgs map[string]string
+	errCh     chan<- error
 }
 
-func (d *Adapter[C]) EvaluateResult(ctx context.Context, name string, c gateway.Client, res *gateway.Result, inputs build.Inputs) error {
+func (d *Adapter[C]) EvaluateResult(ctx context.Context, name string, c gateway.Client, res *gateway.Result, opt build.Options) error {
 	eg, _ := errgroup.WithContext(ctx)
 	if res.Ref != nil {
 		eg.Go(func() error {
-			return d.evaluateRef(ctx, name, c, res.Ref, res.Metadata, inputs)
+			return d.evaluateRef(ctx, name, c, res.Ref, res.Metadata, opt)
 		})
 	}
 
 	for k, ref := range res.Refs {
 		refName := fmt.Sprintf("%s (%s)", name, k)
 		eg.Go(func() error {
-			return d.evaluateRef(ctx, refName, c, ref, res.Metadata, inputs)
+			return d.evaluateRef(ctx, refName, c, ref, res.Metadata, opt)
 		})
 	}
 	return eg.Wait()
 }
 
-func (d *Adapter[C]) evaluateRef(ctx context.Context, name string, c gateway.Client, ref gateway.Reference, meta map[string][]byte, inputs build.Inputs) error {
+func (d *Adapter[C]) evaluateRef(ctx context.Context, name string, c gateway.Client, ref gateway.Reference, meta map[string][]byte, opt build.Options) error {
 	errCh := make(chan error, 1)
 
 	// Send a solve request to the launch routine
 	// which will perform the solve in the context of the server.
 	ereq := &evaluateRequest{
-		name:   name,
-		c:      c,
-		ref:    ref,
-		meta:   meta,
-		inputs: inputs,
-		errCh:  errCh,
+		name:      name,
+		c:         c,
+		ref:       ref,
+		meta:      meta,
+		inputs:    opt.Inputs,
+		buildArgs: opt.BuildArgs,
+		errCh:     errCh,
 	}
 	select {
 	case d.evaluateReqCh <- ereq:
@@ -437,7 +442,7 @@ func (d *Adapter[C]) evaluate(ctx context.Context, name string, c gateway.Client
 
 	started := d.srv.Go(func(ctx Context) {
 		defer close(errCh)
-		errCh <- d.EvaluateResult(ctx, name, c, res, opt.Inputs)
+		errCh <- d.EvaluateResult(ctx, name, c, res, opt)
 	})
 	if !started {
 		return context.Canceled
@@ -566,6 +571,7 @@ func (s *sourceMap) Get(fname string) ([]byte, bool) {
 
 type breakpointMap struct {
 	byPath map[string][]dap.Breakpoint
+	conds  map[int]*breakpointCondition
 	mu     sync.RWMutex
 
 	nextID atomic.Int64
@@ -574,6 +580,7 @@ type breakpointMap struct {
 func newBreakpointMap() *breakpointMap {
 	return &breakpointMap{
 		byPath: make(map[string][]dap.Breakpoint),
+		conds:  make(map[int]*breakpointCondition),
 	}
 }
 
@@ -588,6 +595,13 @@ func (b *breakpointMap) Set(fname string, sbps []dap.SourceBreakpoint) (breakpoi
 	// Use lowercase paths to normalize the case. We can only know the correct casing after
 	// we intersect the breakpoint map. When we report the pending breakpoint to the editor,
 	prev := b.getByPath(fname)
+	prevConds := make(map[int]*breakpointCondition, len(prev))
+	for _, bp := range prev {
+		prevConds[bp.Id] = b.conds[bp.Id]
+		delete(b.conds, bp.Id)
+	}
+
+	saved := []dap.Breakpoint{}
 	for _, sbp := range sbps {
 		index := slices.IndexFunc(prev, func(e dap.Breakpoint) bool {
 			return sbp.Line >= e.Line && sbp.Line <= e.EndLine && sbp.Column >= e.Column && sbp.Column <= e.EndColumn
@@ -610,12 +624,38 @@ func (b *breakpointMap) Set(fname string, sbps []dap.SourceBreakpoint) (breakpoi
 				Reason: "pending",
 			}
 		}
+
+		cond, err := newBreakpointCondition(sbp)
+		if err != nil {
+			// Report the invalid breakpoint to the editor but do not keep it.
+			bp.Verified = false
+			bp.Reason = "failed"
+			bp.Message = err.Error()
+			breakpoints = append(breakpoints, bp)
+			continue
+		}
+		if cond != nil {
+			// Keep counting hits when the breakpoint is sent again.
+			if prev := prevConds[bp.Id]; prev != nil {
+				cond.hits = prev.hits
+			}
+			b.conds[bp.Id] = cond
+		}
 		breakpoints = append(breakpoints, bp)
+		saved = append(saved, bp)
 	}
-	b.setByPath(fname, breakpoints)
+	b.setByPath(fname, saved)
 	return breakpoints
 }
 
+// Hit evaluates the conditions of the breakpoint with the given id when a
+// thread reaches it. See breakpointCondition.Hit.
+func (b *breakpointMap) Hit(id int, sc *stepContext) (stop bool, msg string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conds[id].Hit(sc)
+}
+
 func (b *breakpointMap) Intersect(ctx Context, src *pb.Source) map[digest.Digest]int {
 	b.mu.Lock()
 	defer b.mu.Unlock()
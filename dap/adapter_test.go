@@ -48,6 +48,9 @@ func TestLaunch(t *testing.T) {
 		})
 		assert.True(t, initializeResp.Success)
 		assert.True(t, initializeResp.Body.SupportsConfigurationDoneRequest)
+		assert.True(t, initializeResp.Body.SupportsConditionalBreakpoints)
+		assert.True(t, initializeResp.Body.SupportsHitConditionalBreakpoints)
+		assert.True(t, initializeResp.Body.SupportsLogPoints)
 
 		launchResp := <-daptest.DoRequest[*dap.LaunchResponse](t, client, &dap.LaunchRequest{
 			Request: dap.Request{Command: "launch"},
@@ -0,0 +1,284 @@
+package dap
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/docker/buildx/bake/hclparser"
+	"github.com/google/go-dap"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// breakpointCondition holds the conditions of a source breakpoint. A
+// breakpoint without any condition always stops.
+type breakpointCondition struct {
+	condition    hcl.Expression
+	hitCondition *hitCondition
+	logMessage   []logSegment
+
+	// hits is the number of times the breakpoint was reached with its
+	// condition evaluating to true.
+	hits int
+}
+
+func newBreakpointCondition(sbp dap.SourceBreakpoint) (*breakpointCondition, error) {
+	if sbp.Condition == "" && sbp.HitCondition == "" && sbp.LogMessage == "" {
+		return nil, nil
+	}
+
+	c := &breakpointCondition{}
+	if sbp.Condition != "" {
+		expr, err := parseExpression(sbp.Condition)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid condition")
+		}
+		c.condition = expr
+	}
+	if sbp.HitCondition != "" {
+		hc, err := parseHitCondition(sbp.HitCondition)
+		if err != nil {
+			return nil, err
+		}
+		c.hitCondition = hc
+	}
+	if sbp.LogMessage != "" {
+		segs, err := parseLogMessage(sbp.LogMessage)
+		if err != nil {
+			return nil, err
+		}
+		c.logMessage = segs
+	}
+	return c, nil
+}
+
+// Hit records that the breakpoint was reached and reports whether the
+// thread should stop. For logpoints, the interpolated message is returned
+// instead and the thread never stops.
+func (c *breakpointCondition) Hit(sc *stepContext) (stop bool, msg string, err error) {
+	if c == nil {
+		return true, "", nil
+	}
+
+	ectx := sc.EvalContext()
+	if c.condition != nil {
+		ok, err := evalBool(c.condition, ectx)
+		if err != nil {
+			return true, "", errors.Wrap(err, "failed to evaluate breakpoint condition")
+		}
+		if !ok {
+			return false, "", nil
+		}
+	}
+
+	c.hits++
+	if c.hitCondition != nil && !c.hitCondition.Match(c.hits) {
+		return false, "", nil
+	}
+
+	if c.logMessage != nil {
+		msg, err := formatLogMessage(c.logMessage, ectx)
+		if err != nil {
+			return true, "", errors.Wrap(err, "failed to evaluate log message")
+		}
+		return false, msg, nil
+	}
+	return true, "", nil
+}
+
+// stepContext holds the values conditions and log messages of a breakpoint
+// can refer to.
+type stepContext struct {
+	// Name is the name of the step, as displayed in the stack trace.
+	Name string
+	// Stage is the name of the build stage of the step.
+	Stage string
+	// Platform is the platform of the step.
+	Platform string
+	// Op is the type of the LLB operation of the step.
+	Op string
+	// Args are the build arguments.
+	Args map[string]string
+	// ExitCode is the exit code of the last executed step. It is 0 unless
+	// the step failed.
+	ExitCode int
+}
+
+func (sc *stepContext) EvalContext() *hcl.EvalContext {
+	args := cty.MapValEmpty(cty.String)
+	if len(sc.Args) > 0 {
+		m := make(map[string]cty.Value, len(sc.Args))
+		for k, v := range sc.Args {
+			m[k] = cty.StringVal(v)
+		}
+		args = cty.MapVal(m)
+	}
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"name":     cty.StringVal(sc.Name),
+			"stage":    cty.StringVal(sc.Stage),
+			"platform": cty.StringVal(sc.Platform),
+			"op":       cty.StringVal(sc.Op),
+			"args":     args,
+			"exitcode": cty.NumberIntVal(int64(sc.ExitCode)),
+		},
+		Functions: hclparser.Stdlib(),
+	}
+}
+
+// stageFromName extracts the build stage from the name of a step generated
+// by the Dockerfile frontend, e.g. "[linux/amd64 build 2/4] RUN make".
+func stageFromName(name string) string {
+	if !strings.HasPrefix(name, "[") {
+		return ""
+	}
+	end := strings.IndexByte(name, ']')
+	if end < 0 {
+		return ""
+	}
+	fields := strings.Fields(name[1:end])
+	if len(fields) < 2 || !strings.Contains(fields[len(fields)-1], "/") {
+		return ""
+	}
+	// Platforms always contain a slash while stage names can't.
+	if stage := fields[len(fields)-2]; !strings.Contains(stage, "/") {
+		return stage
+	}
+	return ""
+}
+
+func parseExpression(s string) (hcl.Expression, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(s), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return expr, nil
+}
+
+func evalBool(expr hcl.Expression, ectx *hcl.EvalContext) (bool, error) {
+	v, diags := expr.Value(ectx)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	v, err := convert.Convert(v, cty.Bool)
+	if err != nil {
+		return false, errors.Wrap(err, "condition must be a boolean")
+	}
+	if v.IsNull() || !v.IsKnown() {
+		return false, nil
+	}
+	return v.True(), nil
+}
+
+// hitCondition is the hit count condition of a breakpoint. It is an integer
+// optionally prefixed by an operator: "==", "!=", ">", ">=", "<", "<=" or
+// "%". A bare integer is the same as "==".
+type hitCondition struct {
+	op string
+	n  int
+}
+
+func parseHitCondition(cond string) (*hitCondition, error) {
+	s := strings.TrimSpace(cond)
+	hc := &hitCondition{op: "=="}
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<", "%"} {
+		if v, ok := strings.CutPrefix(s, op); ok {
+			hc.op, s = op, strings.TrimSpace(v)
+			break
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || (hc.op == "%" && n == 0) {
+		return nil, errors.Errorf("invalid hit condition %q", cond)
+	}
+	hc.n = n
+	return hc, nil
+}
+
+func (hc *hitCondition) Match(hits int) bool {
+	switch hc.op {
+	case "!=":
+		return hits != hc.n
+	case ">":
+		return hits > hc.n
+	case ">=":
+		return hits >= hc.n
+	case "<":
+		return hits < hc.n
+	case "<=":
+		return hits <= hc.n
+	case "%":
+		return hits%hc.n == 0
+	default:
+		return hits == hc.n
+	}
+}
+
+// logSegment is either a literal part of a log message or an expression
+// enclosed in curly braces.
+type logSegment struct {
+	text string
+	expr hcl.Expression
+}
+
+func parseLogMessage(s string) ([]logSegment, error) {
+	segs := []logSegment{}
+	for s != "" {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			segs = append(segs, logSegment{text: s})
+			break
+		}
+		if start > 0 {
+			segs = append(segs, logSegment{text: s[:start]})
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return nil, errors.Errorf("invalid log message: unterminated expression %q", s[start:])
+		}
+		expr, err := parseExpression(s[start+1 : start+end])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid log message")
+		}
+		segs = append(segs, logSegment{expr: expr})
+		s = s[start+end+1:]
+	}
+	return segs, nil
+}
+
+func formatLogMessage(segs []logSegment, ectx *hcl.EvalContext) (string, error) {
+	var sb strings.Builder
+	for _, seg := range segs {
+		if seg.expr == nil {
+			sb.WriteString(seg.text)
+			continue
+		}
+		v, diags := seg.expr.Value(ectx)
+		if diags.HasErrors() {
+			return "", diags
+		}
+		sb.WriteString(formatValue(v))
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+func formatValue(v cty.Value) string {
+	if v.IsNull() {
+		return "null"
+	}
+	if !v.IsKnown() {
+		return "(unknown)"
+	}
+	if s, err := convert.Convert(v, cty.String); err == nil {
+		return s.AsString()
+	}
+	if dt, err := ctyjson.Marshal(v, v.Type()); err == nil {
+		return string(dt)
+	}
+	return v.GoString()
+}
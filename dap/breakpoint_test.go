@@ -0,0 +1,146 @@
+package dap
+
+import (
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakpointCondition(t *testing.T) {
+	sc := &stepContext{
+		Name:     "[linux/arm64 build 2/4] RUN make",
+		Stage:    "build",
+		Platform: "linux/arm64",
+		Op:       "exec",
+		Args:     map[string]string{"TARGETARCH": "arm64"},
+	}
+
+	type hit struct {
+		stop bool
+		msg  string
+	}
+	tcs := []struct {
+		name string
+		sbp  dap.SourceBreakpoint
+		hits []hit
+	}{
+		{
+			name: "plain",
+			sbp:  dap.SourceBreakpoint{Line: 1},
+			hits: []hit{{stop: true}, {stop: true}},
+		},
+		{
+			name: "condition true",
+			sbp:  dap.SourceBreakpoint{Condition: `platform == "linux/arm64" && stage == "build"`},
+			hits: []hit{{stop: true}},
+		},
+		{
+			name: "condition false",
+			sbp:  dap.SourceBreakpoint{Condition: `platform == "linux/amd64"`},
+			hits: []hit{{stop: false}},
+		},
+		{
+			name: "condition on build args",
+			sbp:  dap.SourceBreakpoint{Condition: `lookup(args, "TARGETARCH", "") == "arm64" && op == "exec"`},
+			hits: []hit{{stop: true}},
+		},
+		{
+			name: "hit count",
+			sbp:  dap.SourceBreakpoint{HitCondition: "2"},
+			hits: []hit{{stop: false}, {stop: true}, {stop: false}},
+		},
+		{
+			name: "hit count operator",
+			sbp:  dap.SourceBreakpoint{HitCondition: ">= 2"},
+			hits: []hit{{stop: false}, {stop: true}, {stop: true}},
+		},
+		{
+			name: "hit count modulo",
+			sbp:  dap.SourceBreakpoint{HitCondition: "%2"},
+			hits: []hit{{stop: false}, {stop: true}, {stop: false}, {stop: true}},
+		},
+		{
+			name: "hit count not incremented by false condition",
+			sbp:  dap.SourceBreakpoint{Condition: `stage == "final"`, HitCondition: "1"},
+			hits: []hit{{stop: false}, {stop: false}},
+		},
+		{
+			name: "logpoint",
+			sbp:  dap.SourceBreakpoint{LogMessage: "{stage} on {platform} exited with {exitcode}"},
+			hits: []hit{{msg: "build on linux/arm64 exited with 0\n"}},
+		},
+		{
+			name: "logpoint with hit count",
+			sbp:  dap.SourceBreakpoint{LogMessage: "hit", HitCondition: "> 1"},
+			hits: []hit{{}, {msg: "hit\n"}},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := newBreakpointCondition(tc.sbp)
+			require.NoError(t, err)
+			for i, h := range tc.hits {
+				stop, msg, err := c.Hit(sc)
+				require.NoError(t, err)
+				require.Equal(t, h.stop, stop, "hit %d", i+1)
+				require.Equal(t, h.msg, msg, "hit %d", i+1)
+			}
+		})
+	}
+}
+
+func TestBreakpointConditionInvalid(t *testing.T) {
+	for _, sbp := range []dap.SourceBreakpoint{
+		{Condition: `stage ==`},
+		{HitCondition: "foo"},
+		{HitCondition: "%0"},
+		{LogMessage: "{stage"},
+	} {
+		_, err := newBreakpointCondition(sbp)
+		require.Error(t, err)
+	}
+
+	c, err := newBreakpointCondition(dap.SourceBreakpoint{Condition: `args.MISSING == "1"`})
+	require.NoError(t, err)
+	stop, _, err := c.Hit(&stepContext{})
+	require.Error(t, err)
+	require.True(t, stop)
+}
+
+func TestStageFromName(t *testing.T) {
+	for name, stage := range map[string]string{
+		"[linux/amd64 build 2/4] RUN make": "build",
+		"[build  2/10] RUN make":           "build",
+		"[linux/amd64 2/4] RUN make":       "",
+		"[2/4] RUN make":                   "",
+		"[internal] load build context":    "",
+		"RUN make":                         "",
+	} {
+		require.Equal(t, stage, stageFromName(name), name)
+	}
+}
+
+func TestBreakpointMapSet(t *testing.T) {
+	b := newBreakpointMap()
+	bps := b.Set("/src/Dockerfile", []dap.SourceBreakpoint{
+		{Line: 1, HitCondition: "2"},
+		{Line: 2, Condition: "stage =="},
+	})
+	require.Len(t, bps, 2)
+	require.Empty(t, bps[0].Message)
+	require.Equal(t, "failed", bps[1].Reason)
+	require.NotEmpty(t, bps[1].Message)
+	require.Len(t, b.getByPath("/src/Dockerfile"), 1)
+
+	stop, _, err := b.Hit(bps[0].Id, &stepContext{})
+	require.NoError(t, err)
+	require.False(t, stop)
+
+	// Sending the breakpoint again keeps its hit count.
+	bps2 := b.Set("/src/Dockerfile", []dap.SourceBreakpoint{{Line: 1, HitCondition: "2"}})
+	require.Equal(t, bps[0].Id, bps2[0].Id)
+	stop, _, err = b.Hit(bps[0].Id, &stepContext{})
+	require.NoError(t, err)
+	require.True(t, stop)
+}
@@ -2,12 +2,14 @@ package dap
 
 import (
 	"context"
+	"fmt"
 	"maps"
 	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
 
+	"github.com/containerd/platforms"
 	"github.com/docker/buildx/build"
 	"github.com/docker/buildx/dap/common"
 	"github.com/google/go-dap"
@@ -17,6 +19,7 @@ import (
 	"github.com/moby/buildkit/solver/errdefs"
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/tonistiigi/fsutil/types"
 	"golang.org/x/sync/errgroup"
@@ -35,6 +38,7 @@ type thread struct {
 	c             gateway.Client
 	ref           gateway.Reference
 	meta          map[string][]byte
+	buildArgs     map[string]string
 	sourceInfoMap func(*pb.Source) *pb.Source
 
 	// LLB state for the evaluate call.
@@ -66,8 +70,8 @@ const (
 	stepOut
 )
 
-func (t *thread) Evaluate(ctx Context, c gateway.Client, headRef gateway.Reference, meta map[string][]byte, inputs build.Inputs, cfg common.Config) error {
-	if err := t.init(ctx, c, headRef, meta, inputs); err != nil {
+func (t *thread) Evaluate(ctx Context, c gateway.Client, headRef gateway.Reference, meta map[string][]byte, inputs build.Inputs, buildArgs map[string]string, cfg common.Config) error {
+	if err := t.init(ctx, c, headRef, meta, inputs, buildArgs); err != nil {
 		return err
 	}
 	defer t.reset()
@@ -86,7 +90,7 @@ func (t *thread) Evaluate(ctx Context, c gateway.Client, headRef gateway.Referen
 		err  error
 	)
 	for {
-		event := t.needsDebug(next, action, err)
+		event := t.needsDebug(ctx, next, action, err)
 		if event.Reason != "" {
 			select {
 			case action = <-t.pause(ctx, k, refs, err, next, event):
@@ -108,10 +112,11 @@ func (t *thread) Evaluate(ctx Context, c gateway.Client, headRef gateway.Referen
 	return nil
 }
 
-func (t *thread) init(ctx Context, c gateway.Client, ref gateway.Reference, meta map[string][]byte, inputs build.Inputs) error {
+func (t *thread) init(ctx Context, c gateway.Client, ref gateway.Reference, meta map[string][]byte, inputs build.Inputs, buildArgs map[string]string) error {
 	t.c = c
 	t.ref = ref
 	t.meta = meta
+	t.buildArgs = buildArgs
 	t.sourceInfoMap = func(s *pb.Source) *pb.Source {
 		s = s.CloneVT()
 		for _, sinfo := range s.Infos {
@@ -338,17 +343,26 @@ func (t *thread) reset() {
 	t.c = nil
 	t.ref = nil
 	t.meta = nil
+	t.buildArgs = nil
 	t.ops = nil
 }
 
-func (t *thread) needsDebug(cur *step, step stepType, err error) (e dap.StoppedEventBody) {
+func (t *thread) needsDebug(ctx Context, cur *step, step stepType, err error) (e dap.StoppedEventBody) {
 	if err != nil {
 		e.Reason = "exception"
 		e.Description = "Encountered an error during result evaluation"
+
+		// Always stop on errors but still evaluate the breakpoint of the
+		// failed step so logpoints can report its exit code.
+		if cur != nil {
+			if id, ok := t.bps[cur.dgst]; ok && t.hitBreakpoint(ctx, id, cur, err) {
+				e.HitBreakpointIds = []int{id}
+			}
+		}
 	} else if cur != nil {
 		if step != stepContinue {
 			e.Reason = "step"
-		} else if id, ok := t.bps[cur.dgst]; ok {
+		} else if id, ok := t.bps[cur.dgst]; ok && t.hitBreakpoint(ctx, id, cur, nil) {
 			e.Reason = "breakpoint"
 			e.Description = "Paused on breakpoint"
 			e.HitBreakpointIds = []int{id}
@@ -357,6 +371,79 @@ func (t *thread) needsDebug(cur *step, step stepType, err error) (e dap.StoppedE
 	return
 }
 
+// hitBreakpoint evaluates the conditions of a breakpoint reached by the
+// thread and reports whether it should stop. Logpoints print their message
+// to the debug console instead of stopping.
+func (t *thread) hitBreakpoint(ctx Context, id int, cur *step, stepErr error) bool {
+	stop, msg, err := t.breakpointMap.Hit(id, t.stepContext(cur, stepErr))
+	if err != nil {
+		msg = fmt.Sprintf("Breakpoint %d: %s\n", id, err)
+	}
+	if msg != "" {
+		out := dap.OutputEventBody{
+			Category: "console",
+			Output:   msg,
+		}
+		if f := cur.frame; f != nil && f.Source != nil {
+			out.Source = f.Source
+			out.Line = f.Line
+			out.Column = f.Column
+		}
+		ctx.C() <- &dap.OutputEvent{
+			Event: dap.Event{Event: "output"},
+			Body:  out,
+		}
+	}
+	return stop
+}
+
+func (t *thread) stepContext(cur *step, err error) *stepContext {
+	sc := &stepContext{
+		Args: t.buildArgs,
+	}
+	if err != nil {
+		sc.ExitCode = gwpb.UnknownExitStatus
+		var exitErr *gwpb.ExitError
+		if errors.As(err, &exitErr) {
+			sc.ExitCode = int(exitErr.ExitCode)
+		}
+	}
+	if f := cur.frame; f != nil {
+		sc.Name = f.Name
+		sc.Stage = stageFromName(f.Name)
+	}
+	if op := t.ops[cur.dgst]; op != nil {
+		if p := op.Platform; p != nil {
+			sc.Platform = platforms.Format(ocispecs.Platform{
+				OS:           p.OS,
+				Architecture: p.Architecture,
+				Variant:      p.Variant,
+			})
+		}
+		sc.Op = opType(op)
+	}
+	return sc
+}
+
+func opType(op *pb.Op) string {
+	switch op.Op.(type) {
+	case *pb.Op_Exec:
+		return "exec"
+	case *pb.Op_File:
+		return "file"
+	case *pb.Op_Source:
+		return "source"
+	case *pb.Op_Build:
+		return "build"
+	case *pb.Op_Merge:
+		return "merge"
+	case *pb.Op_Diff:
+		return "diff"
+	default:
+		return ""
+	}
+}
+
 func (t *thread) pause(c Context, k string, refs map[string]gateway.Reference, err error, pos *step, event dap.StoppedEventBody) <-chan stepType {
 	t.mu.Lock()
 	defer t.mu.Unlock()
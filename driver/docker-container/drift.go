@@ -0,0 +1,151 @@
+package docker
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/docker/buildx/driver"
+	"github.com/docker/buildx/driver/bkimage"
+	"github.com/docker/buildx/util/confutil"
+	"github.com/moby/moby/api/types/container"
+	dockerclient "github.com/moby/moby/client"
+	"github.com/pkg/errors"
+)
+
+var _ driver.DriftDriver = &Driver{}
+
+// Drift compares the container of the BuildKit daemon with the driver
+// options, BuildKit daemon flags and config files of the node.
+func (d *Driver) Drift(ctx context.Context) ([]driver.Drift, error) {
+	res, err := d.DockerAPI.ContainerInspect(ctx, d.Name, dockerclient.ContainerInspectOptions{})
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ctr := res.Container
+	if ctr.Config == nil || ctr.HostConfig == nil {
+		return nil, errors.Errorf("failed to inspect container %s", d.Name)
+	}
+
+	var drifts driver.DriftSet
+
+	imageName := bkimage.DefaultImage
+	if d.image != "" {
+		imageName = d.image
+	}
+	if !sameImage(imageName, ctr.Config.Image) {
+		drifts.Compare("image", imageName, ctr.Config.Image)
+	}
+
+	drifts.CompareSlices("buildkitd-flags", getBuildkitFlags(d.InitConfig), ctr.Config.Cmd)
+
+	var missingEnv []string
+	for _, env := range d.env {
+		if !slices.Contains(ctr.Config.Env, env) {
+			missingEnv = append(missingEnv, env)
+		}
+	}
+	if len(missingEnv) > 0 {
+		drifts = append(drifts, driver.Drift{Field: "env", Expected: strings.Join(missingEnv, " ")})
+	}
+
+	hc := ctr.HostConfig
+	drifts.Compare("network", networkMode(d.netMode), networkMode(string(hc.NetworkMode)))
+	drifts.Compare("restart-policy", restartPolicy(d.restartPolicy), restartPolicy(hc.RestartPolicy))
+	drifts.Compare("memory", formatInt(int64(d.memory)), formatInt(hc.Memory))
+	if d.memorySwap != 0 {
+		drifts.Compare("memory-swap", formatInt(int64(d.memorySwap)), formatInt(hc.MemorySwap))
+	}
+	drifts.Compare("cpu-quota", formatInt(d.cpuQuota), formatInt(hc.CPUQuota))
+	drifts.Compare("cpu-period", formatInt(d.cpuPeriod), formatInt(hc.CPUPeriod))
+	drifts.Compare("cpu-shares", formatInt(d.cpuShares), formatInt(hc.CPUShares))
+	drifts.Compare("cpuset-cpus", d.cpusetCpus, hc.CpusetCpus)
+	drifts.Compare("cpuset-mems", d.cpusetMems, hc.CpusetMems)
+	if d.cgroupParent != "" {
+		drifts.Compare("cgroup-parent", d.cgroupParent, hc.CgroupParent)
+	}
+
+	if len(d.Files) > 0 {
+		files, err := d.containerFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range slices.Sorted(maps.Keys(d.Files)) {
+			if dt, ok := files[name]; !ok {
+				drifts = append(drifts, driver.Drift{Field: "files", Expected: name, Actual: "missing"})
+			} else if string(dt) != string(d.Files[name]) {
+				drifts = append(drifts, driver.Drift{Field: "files", Expected: name, Actual: "modified"})
+			}
+		}
+	}
+
+	return drifts, nil
+}
+
+// containerFiles returns the files of the BuildKit config directory of the
+// container.
+func (d *Driver) containerFiles(ctx context.Context) (map[string][]byte, error) {
+	res, err := d.DockerAPI.CopyFromContainer(ctx, d.Name, dockerclient.CopyFromContainerOptions{
+		SourcePath: confutil.DefaultBuildKitConfigDir,
+	})
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer res.Content.Close()
+	return untarConfigFiles(res.Content)
+}
+
+// sameImage reports whether a container created from the image reference
+// expected can run the image actual. The image of the container may have
+// been pinned to a digest when it was created.
+func sameImage(expected, actual string) bool {
+	e, err := reference.ParseNormalizedNamed(expected)
+	if err != nil {
+		return expected == actual
+	}
+	a, err := reference.ParseNormalizedNamed(actual)
+	if err != nil {
+		return false
+	}
+	if e.Name() != a.Name() {
+		return false
+	}
+	if ed, ok := e.(reference.Digested); ok {
+		ad, ok := a.(reference.Digested)
+		return ok && ad.Digest() == ed.Digest()
+	}
+	et, ok := reference.TagNameOnly(e).(reference.Tagged)
+	if !ok {
+		return false
+	}
+	at, ok := reference.TagNameOnly(a).(reference.Tagged)
+	return ok && at.Tag() == et.Tag()
+}
+
+func networkMode(mode string) string {
+	if mode == "" || mode == "default" {
+		return "bridge"
+	}
+	return mode
+}
+
+func restartPolicy(rp container.RestartPolicy) string {
+	if rp.MaximumRetryCount > 0 {
+		return string(rp.Name) + ":" + strconv.Itoa(rp.MaximumRetryCount)
+	}
+	return string(rp.Name)
+}
+
+func formatInt(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
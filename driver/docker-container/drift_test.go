@@ -0,0 +1,26 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSameImage(t *testing.T) {
+	const dgst = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	for _, tc := range []struct {
+		expected string
+		actual   string
+		same     bool
+	}{
+		{"moby/buildkit:buildx-stable-1", "moby/buildkit:buildx-stable-1", true},
+		{"moby/buildkit:buildx-stable-1", "docker.io/moby/buildkit:buildx-stable-1@" + dgst, true},
+		{"moby/buildkit", "moby/buildkit:latest", true},
+		{"moby/buildkit:v0.20.0", "moby/buildkit:v0.19.0", false},
+		{"moby/buildkit:v0.20.0", "example.com/buildkit:v0.20.0", false},
+		{"moby/buildkit@" + dgst, "moby/buildkit:latest@" + dgst, true},
+		{"moby/buildkit@" + dgst, "moby/buildkit:latest", false},
+	} {
+		require.Equal(t, tc.same, sameImage(tc.expected, tc.actual), "%s %s", tc.expected, tc.actual)
+	}
+}
@@ -122,3 +122,31 @@ func writeConfigTar(tw *tar.Writer, dirs []string, entries []configTarEntry) err
 	}
 	return nil
 }
+
+// untarConfigFiles reads the config files from an archive of the config
+// directory, as returned by tarConfigFiles or copied from the container.
+func untarConfigFiles(r io.Reader) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// strip the config directory
+		_, name, ok := strings.Cut(path.Clean(hdr.Name), "/")
+		if !ok {
+			continue
+		}
+		dt, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = dt
+	}
+}
@@ -159,3 +159,19 @@ func readTarEntries(t *testing.T, r io.Reader) (map[string]tarEntry, []string) {
 	}
 	return entries, names
 }
+
+func TestUntarConfigFiles(t *testing.T) {
+	files := map[string][]byte{
+		"buildkitd.toml":           []byte("debug = true\n"),
+		"certs/example.com/ca.pem": []byte("certificate"),
+		"empty":                    {},
+	}
+
+	rc, err := tarConfigFiles(files)
+	require.NoError(t, err)
+	defer rc.Close()
+
+	res, err := untarConfigFiles(rc)
+	require.NoError(t, err)
+	require.Equal(t, files, res)
+}
@@ -0,0 +1,57 @@
+package driver
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// Drift is a setting of a BuildKit daemon that differs from the one stored
+// for its node, e.g. because the node was updated after the daemon was
+// created.
+type Drift struct {
+	Field    string
+	Expected string
+	Actual   string
+}
+
+// DriftDriver is implemented by drivers that can compare the settings of
+// their BuildKit daemon with the ones they were configured with.
+type DriftDriver interface {
+	// Drift returns the settings of the daemon that differ from the driver
+	// config. It returns no drift if the daemon doesn't exist.
+	Drift(ctx context.Context) ([]Drift, error)
+}
+
+// DriftSupported reports whether the driver can detect drift.
+func (d *DriverHandle) DriftSupported() bool {
+	_, ok := d.Driver.(DriftDriver)
+	return ok
+}
+
+// Drift returns the drift of the BuildKit daemon of the driver, or nothing
+// if the driver doesn't support drift detection.
+func (d *DriverHandle) Drift(ctx context.Context) ([]Drift, error) {
+	if dd, ok := d.Driver.(DriftDriver); ok {
+		return dd.Drift(ctx)
+	}
+	return nil, nil
+}
+
+// DriftSet accumulates the drift of a daemon.
+type DriftSet []Drift
+
+// Compare adds a drift for field if expected and actual differ.
+func (s *DriftSet) Compare(field, expected, actual string) {
+	if expected != actual {
+		*s = append(*s, Drift{Field: field, Expected: expected, Actual: actual})
+	}
+}
+
+// CompareSlices adds a drift for field if the values of expected and actual
+// differ.
+func (s *DriftSet) CompareSlices(field string, expected, actual []string) {
+	if !slices.Equal(expected, actual) {
+		*s = append(*s, Drift{Field: field, Expected: strings.Join(expected, " "), Actual: strings.Join(actual, " ")})
+	}
+}
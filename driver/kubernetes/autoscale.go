@@ -0,0 +1,482 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/buildx/driver/kubernetes/execconn"
+	"github.com/docker/buildx/driver/kubernetes/podchooser"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultScaleDownIdle = 10 * time.Minute
+	podLoadTimeout       = 20 * time.Second
+	// podLoadCacheTTL is how long the active builds read from a pod are
+	// reused, so the pods aren't dialed again for every connection to the
+	// builder, like for each target of a bake run.
+	podLoadCacheTTL = 5 * time.Second
+
+	// annotationPodDeletionCost makes the ReplicaSet controller remove idle
+	// pods first when a Deployment is scaled down.
+	annotationPodDeletionCost = "controller.kubernetes.io/pod-deletion-cost"
+	// annotationLastActivity is the last time a client chose the pod for a
+	// build. It keeps a pod from being scaled down by another client before
+	// the build shows up as active on it.
+	annotationLastActivity = "buildx.docker.com/last-activity"
+)
+
+type autoscaleOpt struct {
+	MaxReplicas   int32
+	ScaleDownIdle time.Duration
+}
+
+// autoscaler is a pod chooser that scales the replicas of the builder
+// between the configured replicas and MaxReplicas depending on the number
+// of active builds seen on each pod.
+type autoscaler struct {
+	d   *Driver
+	opt autoscaleOpt
+}
+
+type scalePlan struct {
+	Replicas int32
+	// Remove are the idle pods expected to be removed by a scale down
+	Remove []*corev1.Pod
+}
+
+func (a *autoscaler) ChoosePod(ctx context.Context) (*corev1.Pod, error) {
+	d := a.d
+	pods, err := podchooser.ListRunningPods(ctx, d.podClient, d.deployment, d.statefulSet)
+	if err != nil {
+		return nil, err
+	}
+	loads := podchooser.LoadPods(ctx, pods, d.podLoad)
+
+	pod, err := a.choose(ctx, pods, loads)
+	if err != nil {
+		return nil, err
+	}
+	a.markActive(ctx, pod)
+	return pod, nil
+}
+
+func (a *autoscaler) choose(ctx context.Context, pods []*corev1.Pod, loads []podchooser.PodLoad) (*corev1.Pod, error) {
+	d := a.d
+	replicas, err := a.replicas(ctx)
+	if err != nil {
+		logrus.Warnf("failed to get replicas for autoscaling: %v", err)
+		return podchooser.LeastBusy(pods, loads)
+	}
+	plan := planScale(pods, loads, replicas, int32(d.minReplicas), a.opt.MaxReplicas, a.opt.ScaleDownIdle, time.Now(), d.statefulSet != nil, 1)
+
+	switch {
+	case plan.Replicas > replicas:
+		logrus.Infof("all %d buildkit pods are busy, scaling up to %d replicas", len(pods), plan.Replicas)
+		pod, err := a.scaleUp(ctx, plan.Replicas, pods)
+		if err == nil {
+			return pod, nil
+		}
+		logrus.Warnf("failed to scale up buildkit pods: %v", err)
+	case plan.Replicas < replicas:
+		removed, err := a.scaleDown(ctx, plan, replicas, a.opt.ScaleDownIdle)
+		if err != nil {
+			logrus.Warnf("failed to scale down buildkit pods: %v", err)
+			break
+		}
+		pods, loads = withoutPods(pods, loads, removed)
+	}
+	return podchooser.LeastBusy(pods, loads)
+}
+
+// scaleDownIdle removes every idle pod above the configured replicas. It is
+// used when the builder is stopped so an idle builder doesn't keep the
+// replicas added for past builds.
+func (a *autoscaler) scaleDownIdle(ctx context.Context) error {
+	d := a.d
+	pods, err := podchooser.ListRunningPods(ctx, d.podClient, d.deployment, d.statefulSet)
+	if err != nil {
+		return err
+	}
+	replicas, err := a.replicas(ctx)
+	if err != nil {
+		return err
+	}
+	loads := podchooser.LoadPods(ctx, pods, d.podLoad)
+	plan := planScale(pods, loads, replicas, int32(d.minReplicas), replicas, 0, time.Now(), d.statefulSet != nil, 0)
+	if plan.Replicas >= replicas {
+		return nil
+	}
+	_, err = a.scaleDown(ctx, plan, replicas, 0)
+	return err
+}
+
+// markActive records that the pod has been chosen for a build.
+func (a *autoscaler) markActive(ctx context.Context, pod *corev1.Pod) {
+	patch := fmt.Appendf(nil, `{"metadata":{"annotations":{%q:%q}}}`, annotationLastActivity, time.Now().UTC().Format(time.RFC3339Nano))
+	if _, err := a.d.podClient.Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		logrus.Debugf("failed to mark pod %q as active: %v", pod.Name, err)
+	}
+}
+
+func (a *autoscaler) replicas(ctx context.Context) (int32, error) {
+	var spec *int32
+	if a.d.deployment != nil {
+		depl, err := a.d.deploymentClient.Get(ctx, a.d.deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, err
+		}
+		spec = depl.Spec.Replicas
+	} else if a.d.statefulSet != nil {
+		stat, err := a.d.statefulSetClient.Get(ctx, a.d.statefulSet.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, err
+		}
+		spec = stat.Spec.Replicas
+	}
+	if spec == nil {
+		return 1, nil
+	}
+	return *spec, nil
+}
+
+func (a *autoscaler) scale(ctx context.Context, replicas int32) error {
+	patch := fmt.Appendf(nil, `{"spec":{"replicas":%d}}`, replicas)
+	if a.d.deployment != nil {
+		if _, err := a.d.deploymentClient.Patch(ctx, a.d.deployment.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return errors.Wrapf(err, "error while scaling %q", a.d.deployment.Name)
+		}
+	}
+	if a.d.statefulSet != nil {
+		if _, err := a.d.statefulSetClient.Patch(ctx, a.d.statefulSet.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return errors.Wrapf(err, "error while scaling %q", a.d.statefulSet.Name)
+		}
+	}
+	return nil
+}
+
+func (a *autoscaler) scaleUp(ctx context.Context, replicas int32, prev []*corev1.Pod) (*corev1.Pod, error) {
+	d := a.d
+	if err := a.scale(ctx, replicas); err != nil {
+		return nil, err
+	}
+	ready := func(readyReplicas int32) error {
+		if readyReplicas < replicas {
+			return errors.Errorf("expected %d replicas to be ready, got %d", replicas, readyReplicas)
+		}
+		return nil
+	}
+	if d.deployment != nil {
+		if err := wait(ctx, d, d.deploymentClient, d.deployment.Name, func(s *appsv1.Deployment) error {
+			return ready(s.Status.ReadyReplicas)
+		}); err != nil {
+			return nil, err
+		}
+	} else if d.statefulSet != nil {
+		if err := wait(ctx, d, d.statefulSetClient, d.statefulSet.Name, func(s *appsv1.StatefulSet) error {
+			return ready(s.Status.ReadyReplicas)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	pods, err := podchooser.ListRunningPods(ctx, d.podClient, d.deployment, d.statefulSet)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		if !slices.ContainsFunc(prev, func(p *corev1.Pod) bool { return p.Name == pod.Name }) {
+			return pod, nil
+		}
+	}
+	return nil, errors.New("no new buildkit pod found after scaling up")
+}
+
+// scaleDown removes the pods of the plan that are still idle. Another client
+// may have chosen a pod since the plan was made, so every pod is checked
+// again right before the replicas are reduced. It returns the removed pods.
+func (a *autoscaler) scaleDown(ctx context.Context, plan scalePlan, replicas int32, idle time.Duration) ([]*corev1.Pod, error) {
+	d := a.d
+	pods, err := podchooser.ListRunningPods(ctx, d.podClient, d.deployment, d.statefulSet)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var remove []*corev1.Pod
+	for _, p := range plan.Remove {
+		i := slices.IndexFunc(pods, func(pod *corev1.Pod) bool { return pod.Name == p.Name })
+		stillIdle := i >= 0
+		if stillIdle {
+			// read again, the cached load may predate the choice
+			active, err := d.activeBuilds(ctx, pods[i])
+			stillIdle = err == nil && active == 0 && now.Sub(podLastActivity(pods[i])) >= idle
+		}
+		if !stillIdle {
+			if d.statefulSet != nil {
+				// only trailing pods of a StatefulSet can be removed
+				break
+			}
+			continue
+		}
+		remove = append(remove, p)
+	}
+	if len(remove) == 0 {
+		return nil, nil
+	}
+
+	logrus.Infof("scaling down %d idle buildkit pods to %d replicas", len(remove), replicas-int32(len(remove)))
+	if d.deployment != nil {
+		patch := fmt.Appendf(nil, `{"metadata":{"annotations":{%q:"-1000"}}}`, annotationPodDeletionCost)
+		for _, pod := range remove {
+			if _, err := d.podClient.Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+				return nil, errors.Wrapf(err, "error while marking pod %q for removal", pod.Name)
+			}
+		}
+	}
+	if err := a.scale(ctx, replicas-int32(len(remove))); err != nil {
+		return nil, err
+	}
+	return remove, nil
+}
+
+// planScale computes the desired number of replicas. It scales up by one
+// replica when every pod is busy, and scales down pods that have been idle
+// for longer than the idle timeout while keeping keepIdle idle pods for the
+// current build. Pods of a StatefulSet are removed by descending ordinal
+// so only trailing idle pods can be scaled down.
+func planScale(pods []*corev1.Pod, loads []podchooser.PodLoad, replicas, minReplicas, maxReplicas int32, idle time.Duration, now time.Time, ordered bool, keepIdle int) scalePlan {
+	plan := scalePlan{Replicas: replicas}
+	if len(pods) == 0 || int32(len(pods)) < replicas {
+		// pods are still starting or terminating
+		return plan
+	}
+
+	busy := true
+	var known int
+	for _, l := range loads {
+		if l.Err != nil {
+			continue
+		}
+		known++
+		if l.Active == 0 {
+			busy = false
+		}
+	}
+	if known == 0 {
+		return plan
+	}
+	if busy {
+		if replicas < maxReplicas {
+			plan.Replicas = replicas + 1
+		}
+		return plan
+	}
+
+	type candidate struct {
+		pod  *corev1.Pod
+		idle bool
+		last time.Time
+	}
+	candidates := make([]candidate, len(pods))
+	var numIdle int
+	for i, pod := range pods {
+		last := pod.CreationTimestamp.Time
+		if loads[i].LastActivity.After(last) {
+			last = loads[i].LastActivity
+		}
+		isIdle := loads[i].Err == nil && loads[i].Active == 0 && now.Sub(last) >= idle
+		if isIdle {
+			numIdle++
+		}
+		candidates[i] = candidate{pod: pod, idle: isIdle, last: last}
+	}
+	removable := min(numIdle-keepIdle, int(replicas-minReplicas))
+	if removable <= 0 {
+		return plan
+	}
+
+	if ordered {
+		slices.SortFunc(candidates, func(a, b candidate) int {
+			return podOrdinal(b.pod) - podOrdinal(a.pod)
+		})
+		for _, c := range candidates {
+			if !c.idle || len(plan.Remove) == removable {
+				break
+			}
+			plan.Remove = append(plan.Remove, c.pod)
+		}
+	} else {
+		slices.SortStableFunc(candidates, func(a, b candidate) int {
+			return a.last.Compare(b.last)
+		})
+		for _, c := range candidates {
+			if len(plan.Remove) == removable {
+				break
+			}
+			if c.idle {
+				plan.Remove = append(plan.Remove, c.pod)
+			}
+		}
+	}
+	plan.Replicas = replicas - int32(len(plan.Remove))
+	return plan
+}
+
+func podOrdinal(pod *corev1.Pod) int {
+	n, err := strconv.Atoi(pod.Name[strings.LastIndex(pod.Name, "-")+1:])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func withoutPods(pods []*corev1.Pod, loads []podchooser.PodLoad, remove []*corev1.Pod) ([]*corev1.Pod, []podchooser.PodLoad) {
+	var outPods []*corev1.Pod
+	var outLoads []podchooser.PodLoad
+	for i, pod := range pods {
+		if slices.Contains(remove, pod) {
+			continue
+		}
+		outPods = append(outPods, pod)
+		outLoads = append(outLoads, loads[i])
+	}
+	return outPods, outLoads
+}
+
+// podLoad returns the number of active builds on a pod and the last time
+// the pod was chosen for a build. The active builds read within
+// podLoadCacheTTL are reused.
+func (d *Driver) podLoad(ctx context.Context, pod *corev1.Pod) (podchooser.PodLoad, error) {
+	active, ok := d.loads.get(pod, time.Now())
+	if !ok {
+		var err error
+		if active, err = d.activeBuilds(ctx, pod); err != nil {
+			return podchooser.PodLoad{}, err
+		}
+	}
+	return podchooser.PodLoad{
+		Active:       active,
+		LastActivity: podLastActivity(pod),
+	}, nil
+}
+
+// activeBuilds returns the number of active builds on a pod using the
+// history API.
+func (d *Driver) activeBuilds(ctx context.Context, pod *corev1.Pod) (int, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, podLoadTimeout, errors.WithStack(context.DeadlineExceeded))
+	defer cancel()
+
+	c, err := client.New(ctx, "", client.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return d.dialPod(ctx, pod)
+	}))
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	cl, err := c.ControlClient().ListenBuildHistory(ctx, &controlapi.BuildHistoryRequest{
+		ActiveOnly: true,
+		EarlyExit:  true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cl.CloseSend()
+
+	active := map[string]struct{}{}
+	for {
+		ev, err := cl.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return 0, err
+		}
+		if ev.Record == nil {
+			continue
+		}
+		if ev.Type == controlapi.BuildHistoryEventType_DELETED || ev.Record.CompletedAt != nil {
+			delete(active, ev.Record.Ref)
+			continue
+		}
+		active[ev.Record.Ref] = struct{}{}
+	}
+	d.loads.set(pod, len(active), time.Now())
+	return len(active), nil
+}
+
+// loadCache keeps the number of active builds last read from each pod.
+type loadCache struct {
+	mu    sync.Mutex
+	loads map[types.UID]cachedLoad
+}
+
+type cachedLoad struct {
+	active int
+	time   time.Time
+}
+
+func (c *loadCache) get(pod *corev1.Pod, now time.Time) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.loads[pod.UID]
+	if !ok || now.Sub(l.time) >= podLoadCacheTTL {
+		return 0, false
+	}
+	return l.active, true
+}
+
+func (c *loadCache) set(pod *corev1.Pod, active int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loads == nil {
+		c.loads = map[types.UID]cachedLoad{}
+	}
+	c.loads[pod.UID] = cachedLoad{active: active, time: now}
+}
+
+// chosen counts a build started on the pod since its load was read, so
+// the next connections don't all choose the same pod.
+func (c *loadCache) chosen(pod *corev1.Pod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.loads[pod.UID]; ok {
+		l.active++
+		c.loads[pod.UID] = l
+	}
+}
+
+func podLastActivity(pod *corev1.Pod) time.Time {
+	if v, ok := pod.Annotations[annotationLastActivity]; ok {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
+
+func (d *Driver) dialPod(ctx context.Context, pod *corev1.Pod) (net.Conn, error) {
+	restClientConfig, err := d.clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return nil, errors.Errorf("pod %s does not have any container", pod.Name)
+	}
+	containerName := pod.Spec.Containers[0].Name
+	cmd := []string{"buildctl", "dial-stdio"}
+	return execconn.ExecConn(ctx, d.podClient.RESTClient(), restClientConfig, pod.Namespace, pod.Name, containerName, cmd)
+}
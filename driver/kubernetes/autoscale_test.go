@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/buildx/driver/kubernetes/podchooser"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPlanScale(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-24 * time.Hour)
+	pods := func(names ...string) []*corev1.Pod {
+		out := make([]*corev1.Pod, 0, len(names))
+		for _, name := range names {
+			out = append(out, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+			}})
+		}
+		return out
+	}
+	idle := podchooser.PodLoad{LastActivity: now.Add(-time.Hour)}
+	recent := podchooser.PodLoad{LastActivity: now.Add(-time.Minute)}
+	busy := podchooser.PodLoad{Active: 1, LastActivity: now}
+	unknown := podchooser.PodLoad{Err: errors.New("unreachable")}
+
+	names := func(plan scalePlan) []string {
+		var out []string
+		for _, p := range plan.Remove {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+
+	t.Run("ScaleUpWhenBusy", func(t *testing.T) {
+		plan := planScale(pods("b-0", "b-1"), []podchooser.PodLoad{busy, busy}, 2, 1, 3, 10*time.Minute, now, false, 1)
+		require.Equal(t, int32(3), plan.Replicas)
+		require.Empty(t, plan.Remove)
+	})
+
+	t.Run("MaxReplicasReached", func(t *testing.T) {
+		plan := planScale(pods("b-0", "b-1", "b-2"), []podchooser.PodLoad{busy, busy, busy}, 3, 1, 3, 10*time.Minute, now, false, 1)
+		require.Equal(t, int32(3), plan.Replicas)
+	})
+
+	t.Run("UnknownLoadIgnored", func(t *testing.T) {
+		plan := planScale(pods("b-0", "b-1"), []podchooser.PodLoad{busy, unknown}, 2, 1, 3, 10*time.Minute, now, false, 1)
+		require.Equal(t, int32(3), plan.Replicas)
+
+		plan = planScale(pods("b-0", "b-1"), []podchooser.PodLoad{unknown, unknown}, 2, 1, 3, 10*time.Minute, now, false, 1)
+		require.Equal(t, int32(2), plan.Replicas)
+	})
+
+	t.Run("WaitForPendingPods", func(t *testing.T) {
+		plan := planScale(pods("b-0"), []podchooser.PodLoad{busy}, 2, 1, 3, 10*time.Minute, now, false, 1)
+		require.Equal(t, int32(2), plan.Replicas)
+	})
+
+	t.Run("ScaleDownIdle", func(t *testing.T) {
+		plan := planScale(pods("b-a", "b-b", "b-c", "b-d"), []podchooser.PodLoad{idle, busy, idle, recent}, 4, 1, 5, 10*time.Minute, now, false, 1)
+		require.Equal(t, int32(3), plan.Replicas)
+		require.Len(t, plan.Remove, 1)
+	})
+
+	t.Run("KeepMinReplicas", func(t *testing.T) {
+		plan := planScale(pods("b-a", "b-b", "b-c"), []podchooser.PodLoad{idle, idle, idle}, 3, 2, 5, 10*time.Minute, now, false, 1)
+		require.Equal(t, int32(2), plan.Replicas)
+		require.Len(t, plan.Remove, 1)
+	})
+
+	t.Run("StopRemovesAllIdle", func(t *testing.T) {
+		plan := planScale(pods("b-a", "b-b", "b-c"), []podchooser.PodLoad{idle, recent, busy}, 3, 1, 3, 0, now, false, 0)
+		require.Equal(t, int32(1), plan.Replicas)
+		require.Equal(t, []string{"b-a", "b-b"}, names(plan))
+	})
+
+	t.Run("StatefulSetTrailingOrdinals", func(t *testing.T) {
+		ps := pods("b-0", "b-1", "b-2", "b-10")
+		plan := planScale(ps, []podchooser.PodLoad{idle, idle, busy, idle}, 4, 1, 5, 10*time.Minute, now, true, 1)
+		require.Equal(t, int32(3), plan.Replicas)
+		require.Equal(t, []string{"b-10"}, names(plan))
+	})
+
+	t.Run("RecentlyCreatedPodNotIdle", func(t *testing.T) {
+		ps := pods("b-0", "b-1", "b-2")
+		ps[2].CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+		plan := planScale(ps, []podchooser.PodLoad{busy, idle, {}}, 3, 1, 5, 10*time.Minute, now, true, 1)
+		require.Equal(t, int32(3), plan.Replicas)
+		require.Empty(t, plan.Remove)
+	})
+}
+
+func TestPodLastActivity(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}}
+	require.Equal(t, created, podLastActivity(pod))
+
+	pod.Annotations = map[string]string{annotationLastActivity: "2024-01-01T13:00:00.5Z"}
+	require.Equal(t, created.Add(time.Hour+500*time.Millisecond), podLastActivity(pod))
+}
+
+func TestLoadCache(t *testing.T) {
+	now := time.Now()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "buildkit-0", UID: "uid-0"}}
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "buildkit-0", UID: "uid-1"}}
+
+	var c loadCache
+	_, ok := c.get(pod, now)
+	require.False(t, ok)
+	c.chosen(pod)
+	_, ok = c.get(pod, now)
+	require.False(t, ok)
+
+	c.set(pod, 1, now)
+	active, ok := c.get(pod, now.Add(time.Second))
+	require.True(t, ok)
+	require.Equal(t, 1, active)
+
+	// a build started on the pod is counted until the load is read again
+	c.chosen(pod)
+	active, ok = c.get(pod, now.Add(time.Second))
+	require.True(t, ok)
+	require.Equal(t, 2, active)
+
+	// a pod recreated with the same name has no load yet
+	_, ok = c.get(other, now)
+	require.False(t, ok)
+
+	_, ok = c.get(pod, now.Add(podLoadCacheTTL))
+	require.False(t, ok)
+}
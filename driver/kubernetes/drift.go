@@ -0,0 +1,210 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/buildx/driver"
+	"github.com/docker/buildx/driver/kubernetes/manifest"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ driver.DriftDriver = &Driver{}
+
+// Drift compares the replicas and pod template of the BuildKit deployment
+// or stateful set and its config maps with the ones generated from the node.
+func (d *Driver) Drift(ctx context.Context) ([]driver.Drift, error) {
+	var expected, actual *corev1.PodTemplateSpec
+	var replicas *int32
+	switch {
+	case d.deployment != nil:
+		depl, err := d.deploymentClient.Get(ctx, d.deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "error while calling deploymentClient.Get for %q", d.deployment.Name)
+		}
+		expected, actual = &d.deployment.Spec.Template, &depl.Spec.Template
+		replicas = depl.Spec.Replicas
+	case d.statefulSet != nil:
+		sts, err := d.statefulSetClient.Get(ctx, d.statefulSet.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "error while calling statefulSetClient.Get for %q", d.statefulSet.Name)
+		}
+		expected, actual = &d.statefulSet.Spec.Template, &sts.Spec.Template
+		replicas = sts.Spec.Replicas
+	default:
+		return nil, nil
+	}
+
+	maxReplicas := int32(d.minReplicas)
+	if a, ok := d.podChooser.(*autoscaler); ok {
+		maxReplicas = a.opt.MaxReplicas
+	}
+	drifts := replicasDrift(int32(d.minReplicas), maxReplicas, replicas)
+	drifts = append(drifts, podTemplateDrift(expected, actual)...)
+	for _, cfg := range d.configMaps {
+		cm, err := d.configMapClient.Get(ctx, cfg.Name, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "error while calling configMapClient.Get for %q", cfg.Name)
+			}
+			cm = &corev1.ConfigMap{}
+		}
+		drifts = append(drifts, configMapDrift(configMapDir(expected, cfg.Name), cfg.Data, cm.Data)...)
+	}
+	return drifts, nil
+}
+
+// replicasDrift compares the replicas of the BuildKit deployment or stateful
+// set with the configured ones. With autoscale, any number of replicas
+// between the configured replicas and maxReplicas is expected.
+func replicasDrift(minReplicas, maxReplicas int32, actual *int32) driver.DriftSet {
+	var drifts driver.DriftSet
+	n := int32(1)
+	if actual != nil {
+		n = *actual
+	}
+	if n < minReplicas || n > max(minReplicas, maxReplicas) {
+		expected := strconv.Itoa(int(minReplicas))
+		if maxReplicas > minReplicas {
+			expected += "-" + strconv.Itoa(int(maxReplicas))
+		}
+		drifts = append(drifts, driver.Drift{Field: "replicas", Expected: expected, Actual: strconv.Itoa(int(n))})
+	}
+	return drifts
+}
+
+func podTemplateDrift(expected, actual *corev1.PodTemplateSpec) driver.DriftSet {
+	var drifts driver.DriftSet
+	ec, ac := buildkitdContainer(expected), buildkitdContainer(actual)
+	if ac == nil {
+		drifts = append(drifts, driver.Drift{Field: "container", Expected: ec.Name, Actual: "missing"})
+		return drifts
+	}
+
+	drifts.Compare("image", ec.Image, ac.Image)
+	drifts.CompareSlices("buildkitd-flags", ec.Args, ac.Args)
+	drifts.CompareSlices("env", formatEnv(ec.Env), formatEnv(ac.Env))
+	er, ar := formatResources(ec.Resources.Requests, ac.Resources.Requests)
+	drifts.CompareSlices("requests", er, ar)
+	el, al := formatResources(ec.Resources.Limits, ac.Resources.Limits)
+	drifts.CompareSlices("limits", el, al)
+	drifts.CompareSlices("nodeselector", formatMap(expected.Spec.NodeSelector), formatMap(actual.Spec.NodeSelector))
+	drifts.CompareSlices("tolerations", formatTolerations(expected.Spec.Tolerations), formatTolerations(actual.Spec.Tolerations))
+	drifts.Compare("platforms", expected.Annotations[manifest.AnnotationPlatform], actual.Annotations[manifest.AnnotationPlatform])
+	drifts.Compare("qemu.image", initContainerImage(expected), initContainerImage(actual))
+	return drifts
+}
+
+func configMapDrift(dir string, expected, actual map[string]string) driver.DriftSet {
+	var drifts driver.DriftSet
+	for _, k := range slices.Sorted(maps.Keys(expected)) {
+		name := path.Join(dir, k)
+		if v, ok := actual[k]; !ok {
+			drifts = append(drifts, driver.Drift{Field: "files", Expected: name, Actual: "missing"})
+		} else if v != expected[k] {
+			drifts = append(drifts, driver.Drift{Field: "files", Expected: name, Actual: "modified"})
+		}
+	}
+	return drifts
+}
+
+// configMapDir returns the directory of the config files of a config map,
+// relative to the BuildKit config directory.
+func configMapDir(tmpl *corev1.PodTemplateSpec, configMap string) string {
+	for _, v := range tmpl.Spec.Volumes {
+		if v.ConfigMap == nil || v.ConfigMap.Name != configMap {
+			continue
+		}
+		if c := buildkitdContainer(tmpl); c != nil {
+			for _, m := range c.VolumeMounts {
+				if m.Name == v.Name {
+					return strings.TrimPrefix(strings.TrimPrefix(m.MountPath, "/etc/buildkit"), "/")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func buildkitdContainer(tmpl *corev1.PodTemplateSpec) *corev1.Container {
+	for i, c := range tmpl.Spec.Containers {
+		if c.Name == "buildkitd" {
+			return &tmpl.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+func initContainerImage(tmpl *corev1.PodTemplateSpec) string {
+	for _, c := range tmpl.Spec.InitContainers {
+		if c.Name == "qemu" {
+			return c.Image
+		}
+	}
+	return ""
+}
+
+func formatEnv(env []corev1.EnvVar) []string {
+	res := make([]string, 0, len(env))
+	for _, e := range env {
+		res = append(res, e.Name+"="+e.Value)
+	}
+	return res
+}
+
+// formatResources formats the resources of expected and actual. Quantities
+// are formatted as expected if they are equal, as the API server may return
+// them in canonical form.
+func formatResources(expected, actual corev1.ResourceList) ([]string, []string) {
+	var e, a []string
+	for _, k := range slices.Sorted(maps.Keys(expected)) {
+		q := expected[k]
+		e = append(e, fmt.Sprintf("%s=%s", k, q.String()))
+	}
+	for _, k := range slices.Sorted(maps.Keys(actual)) {
+		q := actual[k]
+		if eq, ok := expected[k]; ok && eq.Cmp(q) == 0 {
+			q = eq
+		}
+		a = append(a, fmt.Sprintf("%s=%s", k, q.String()))
+	}
+	return e, a
+}
+
+func formatMap(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		res = append(res, k+"="+m[k])
+	}
+	return res
+}
+
+func formatTolerations(tolerations []corev1.Toleration) []string {
+	res := make([]string, 0, len(tolerations))
+	for _, t := range tolerations {
+		op := t.Operator
+		if op == "" {
+			op = corev1.TolerationOpEqual
+		}
+		s := fmt.Sprintf("key=%s,operator=%s,value=%s,effect=%s", t.Key, op, t.Value, t.Effect)
+		if t.TolerationSeconds != nil {
+			s += fmt.Sprintf(",tolerationSeconds=%d", *t.TolerationSeconds)
+		}
+		res = append(res, s)
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/docker/buildx/driver"
+	"github.com/docker/buildx/driver/kubernetes/manifest"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPodTemplateDrift(t *testing.T) {
+	depl, _, cms, err := manifest.NewDeployment(&manifest.DeploymentOpt{
+		Name:          "buildkit",
+		Image:         "moby/buildkit:v0.20.0",
+		Replicas:      1,
+		BuildkitFlags: []string{"--debug"},
+		ConfigFiles: map[string][]byte{
+			"buildkitd.toml":           []byte("debug = true\n"),
+			"certs/example.com/ca.pem": []byte("certificate"),
+		},
+		RequestsCPU:  "500m",
+		NodeSelector: map[string]string{"kubernetes.io/arch": "arm64"},
+	})
+	require.NoError(t, err)
+	require.Len(t, cms, 2)
+
+	expected := &depl.Spec.Template
+	actual := expected.DeepCopy()
+	// the API server returns quantities in canonical form
+	actual.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("0.5")
+	require.Empty(t, podTemplateDrift(expected, actual))
+
+	actual.Spec.Containers[0].Image = "moby/buildkit:v0.19.0"
+	actual.Spec.Containers[0].Args = nil
+	actual.Spec.NodeSelector = nil
+	require.Equal(t, driver.DriftSet{
+		{Field: "image", Expected: "moby/buildkit:v0.20.0", Actual: "moby/buildkit:v0.19.0"},
+		{Field: "buildkitd-flags", Expected: "--debug"},
+		{Field: "nodeselector", Expected: "kubernetes.io/arch=arm64"},
+	}, podTemplateDrift(expected, actual))
+
+	for _, cm := range cms {
+		dir := configMapDir(expected, cm.Name)
+		require.Empty(t, configMapDrift(dir, cm.Data, cm.Data))
+		require.Len(t, configMapDrift(dir, cm.Data, nil), len(cm.Data))
+		if dir == "certs/example.com" {
+			require.Equal(t, driver.DriftSet{
+				{Field: "files", Expected: "certs/example.com/ca.pem", Actual: "modified"},
+			}, configMapDrift(dir, cm.Data, map[string]string{"ca.pem": "other"}))
+		}
+	}
+}
+
+func TestReplicasDrift(t *testing.T) {
+	require.Empty(t, replicasDrift(1, 1, nil))
+	require.Empty(t, replicasDrift(2, 2, new(int32(2))))
+	require.Equal(t, driver.DriftSet{
+		{Field: "replicas", Expected: "2", Actual: "3"},
+	}, replicasDrift(2, 2, new(int32(3))))
+
+	// scaled by autoscale
+	require.Empty(t, replicasDrift(1, 4, new(int32(1))))
+	require.Empty(t, replicasDrift(1, 4, new(int32(4))))
+	require.Equal(t, driver.DriftSet{
+		{Field: "replicas", Expected: "1-4", Actual: "5"},
+	}, replicasDrift(1, 4, new(int32(5))))
+	require.Equal(t, driver.DriftSet{
+		{Field: "replicas", Expected: "2-4", Actual: "1"},
+	}, replicasDrift(2, 4, new(int32(1))))
+}
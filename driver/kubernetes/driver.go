@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/docker/buildx/driver"
-	"github.com/docker/buildx/driver/kubernetes/execconn"
 	"github.com/docker/buildx/driver/kubernetes/kubeclient"
 	"github.com/docker/buildx/driver/kubernetes/manifest"
 	"github.com/docker/buildx/driver/kubernetes/podchooser"
@@ -33,8 +32,9 @@ const (
 
 const (
 	// valid values for driver-opt loadbalance
-	LoadbalanceRandom = "random"
-	LoadbalanceSticky = "sticky"
+	LoadbalanceRandom    = "random"
+	LoadbalanceSticky    = "sticky"
+	LoadbalanceLeastBusy = "least-busy"
 )
 
 type Driver struct {
@@ -54,6 +54,7 @@ type Driver struct {
 	podClient         kubeclient.PodClient
 	configMapClient   kubeclient.ConfigMapClient
 	podChooser        podchooser.PodChooser
+	loads             loadCache
 	defaultLoad       bool
 	timeout           time.Duration
 }
@@ -63,7 +64,7 @@ func (d *Driver) IsMobyDriver() bool {
 }
 
 func (d *Driver) RequiresUncachedClient() bool {
-	return d.loadbalance == LoadbalanceRandom
+	return d.loadbalance == LoadbalanceRandom || d.loadbalance == LoadbalanceLeastBusy
 }
 
 func (d *Driver) Config() driver.InitConfig {
@@ -251,7 +252,11 @@ func (d *Driver) Version(ctx context.Context) (string, error) {
 }
 
 func (d *Driver) Stop(ctx context.Context, force bool) error {
-	// future version may scale the replicas to zero here
+	// pods added by the autoscaler for past builds are removed once idle,
+	// the configured replicas are kept running
+	if a, ok := d.podChooser.(*autoscaler); ok {
+		return a.scaleDownIdle(ctx)
+	}
 	return nil
 }
 
@@ -287,26 +292,18 @@ func (d *Driver) Rm(ctx context.Context, force, rmVolume, rmDaemon bool) error {
 }
 
 func (d *Driver) Dial(ctx context.Context) (net.Conn, error) {
-	restClientConfig, err := d.clientConfig.ClientConfig()
-	if err != nil {
-		return nil, err
-	}
 	pod, err := d.podChooser.ChoosePod(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if len(pod.Spec.Containers) == 0 {
-		return nil, errors.Errorf("pod %s does not have any container", pod.Name)
-	}
-	containerName := pod.Spec.Containers[0].Name
-	cmd := []string{"buildctl", "dial-stdio"}
+	d.loads.chosen(pod)
 
 	// Retry connection with exponential backoff for transient errors
 	// See https://github.com/docker/buildx/issues/2668
 	var conn net.Conn
 	err = tryWithBackoff(ctx, pod.Name, func() error {
 		var err error
-		conn, err = execconn.ExecConn(ctx, d.podClient.RESTClient(), restClientConfig, pod.Namespace, pod.Name, containerName, cmd)
+		conn, err = d.dialPod(ctx, pod)
 		return err
 	})
 	return conn, err
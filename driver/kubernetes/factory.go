@@ -123,7 +123,7 @@ func (f *factory) New(ctx context.Context, cfg driver.InitConfig) (driver.Driver
 		InitConfig:   cfg,
 	}
 
-	deploymentOpt, loadbalance, namespace, defaultLoad, timeout, err := f.processDriverOpts(deploymentName, namespace, cfg)
+	deploymentOpt, loadbalance, namespace, defaultLoad, timeout, autoscale, err := f.processDriverOpts(deploymentName, namespace, cfg)
 	if nil != err {
 		return nil, err
 	}
@@ -148,6 +148,13 @@ func (f *factory) New(ctx context.Context, cfg driver.InitConfig) (driver.Driver
 	d.configMapClient = clients.ConfigMaps
 
 	switch loadbalance {
+	case LoadbalanceLeastBusy:
+		d.podChooser = &podchooser.LeastBusyPodChooser{
+			Load:        d.podLoad,
+			PodClient:   d.podClient,
+			Deployment:  d.deployment,
+			StatefulSet: d.statefulSet,
+		}
 	case LoadbalanceSticky:
 		d.podChooser = &podchooser.StickyPodChooser{
 			Key:         cfg.ContextPathHash,
@@ -162,11 +169,17 @@ func (f *factory) New(ctx context.Context, cfg driver.InitConfig) (driver.Driver
 			StatefulSet: d.statefulSet,
 		}
 	}
+	if autoscale != nil {
+		d.podChooser = &autoscaler{
+			d:   d,
+			opt: *autoscale,
+		}
+	}
 	d.loadbalance = loadbalance
 	return d, nil
 }
 
-func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg driver.InitConfig) (*manifest.DeploymentOpt, string, string, bool, time.Duration, error) {
+func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg driver.InitConfig) (*manifest.DeploymentOpt, string, string, bool, time.Duration, *autoscaleOpt, error) {
 	deploymentOpt := &manifest.DeploymentOpt{
 		Name:          deploymentName,
 		Image:         bkimage.DefaultImage,
@@ -181,6 +194,10 @@ func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg
 	timeout := defaultTimeout
 	deploymentOpt.Qemu.Image = bkimage.QemuImage
 	loadbalance := LoadbalanceSticky
+	var autoscale bool
+	autoscaleOpts := autoscaleOpt{
+		ScaleDownIdle: defaultScaleDownIdle,
+	}
 	var err error
 
 	for k, v := range cfg.DriverOpts {
@@ -194,7 +211,7 @@ func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg
 		case k == "replicas":
 			r, err := strconv.ParseInt(v, 10, 32)
 			if err != nil {
-				return nil, "", "", false, 0, err
+				return nil, "", "", false, 0, nil, err
 			}
 			deploymentOpt.Replicas = int32(r)
 		case k == "requests.cpu":
@@ -214,7 +231,7 @@ func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg
 		case k == "rootless":
 			deploymentOpt.Rootless, err = strconv.ParseBool(v)
 			if err != nil {
-				return nil, "", "", false, 0, err
+				return nil, "", "", false, 0, nil, err
 			}
 			if _, isImage := cfg.DriverOpts["image"]; !isImage {
 				deploymentOpt.Image = bkimage.DefaultRootlessImage
@@ -226,17 +243,17 @@ func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg
 		case k == "nodeselector":
 			deploymentOpt.NodeSelector, err = splitMultiValues(v, ",", "=")
 			if err != nil {
-				return nil, "", "", false, 0, errors.Wrap(err, "cannot parse node selector")
+				return nil, "", "", false, 0, nil, errors.Wrap(err, "cannot parse node selector")
 			}
 		case k == "annotations":
 			deploymentOpt.CustomAnnotations, err = splitMultiValues(v, ",", "=")
 			if err != nil {
-				return nil, "", "", false, 0, errors.Wrap(err, "cannot parse annotations")
+				return nil, "", "", false, 0, nil, errors.Wrap(err, "cannot parse annotations")
 			}
 		case k == "labels":
 			deploymentOpt.CustomLabels, err = splitMultiValues(v, ",", "=")
 			if err != nil {
-				return nil, "", "", false, 0, errors.Wrap(err, "cannot parse labels")
+				return nil, "", "", false, 0, nil, errors.Wrap(err, "cannot parse labels")
 			}
 		case k == "tolerations":
 			ts := strings.Split(v, ";")
@@ -261,12 +278,12 @@ func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg
 						case "tolerationSeconds":
 							c, err := strconv.Atoi(kv[1])
 							if nil != err {
-								return nil, "", "", false, 0, err
+								return nil, "", "", false, 0, nil, err
 							}
 							c64 := int64(c)
 							t.TolerationSeconds = &c64
 						default:
-							return nil, "", "", false, 0, errors.Errorf("invalid tolaration %q", v)
+							return nil, "", "", false, 0, nil, errors.Errorf("invalid tolaration %q", v)
 						}
 					}
 				}
@@ -275,15 +292,31 @@ func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg
 			}
 		case k == "loadbalance":
 			switch v {
-			case LoadbalanceSticky, LoadbalanceRandom:
+			case LoadbalanceSticky, LoadbalanceRandom, LoadbalanceLeastBusy:
 				loadbalance = v
 			default:
-				return nil, "", "", false, 0, errors.Errorf("invalid loadbalance %q", v)
+				return nil, "", "", false, 0, nil, errors.Errorf("invalid loadbalance %q", v)
+			}
+		case k == "autoscale":
+			autoscale, err = strconv.ParseBool(v)
+			if err != nil {
+				return nil, "", "", false, 0, nil, err
+			}
+		case k == "max-replicas":
+			r, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return nil, "", "", false, 0, nil, err
+			}
+			autoscaleOpts.MaxReplicas = int32(r)
+		case k == "scale-down-idle":
+			autoscaleOpts.ScaleDownIdle, err = time.ParseDuration(v)
+			if err != nil {
+				return nil, "", "", false, 0, nil, errors.Wrap(err, "cannot parse scale-down-idle")
 			}
 		case k == "qemu.install":
 			deploymentOpt.Qemu.Install, err = strconv.ParseBool(v)
 			if err != nil {
-				return nil, "", "", false, 0, err
+				return nil, "", "", false, 0, nil, err
 			}
 		case k == "qemu.image":
 			if v != "" {
@@ -296,24 +329,40 @@ func (f *factory) processDriverOpts(deploymentName string, namespace string, cfg
 		case k == "default-load":
 			defaultLoad, err = strconv.ParseBool(v)
 			if err != nil {
-				return nil, "", "", false, 0, err
+				return nil, "", "", false, 0, nil, err
 			}
 		case k == "timeout":
 			timeout, err = time.ParseDuration(v)
 			if err != nil {
-				return nil, "", "", false, 0, errors.Wrap(err, "cannot parse timeout")
+				return nil, "", "", false, 0, nil, errors.Wrap(err, "cannot parse timeout")
 			}
 		case strings.HasPrefix(k, "env."):
 			envName := strings.TrimPrefix(k, "env.")
 			if envName == "" {
-				return nil, "", "", false, 0, errors.Errorf("invalid env option %q, expecting env.FOO=bar", k)
+				return nil, "", "", false, 0, nil, errors.Errorf("invalid env option %q, expecting env.FOO=bar", k)
 			}
 			deploymentOpt.Env = append(deploymentOpt.Env, corev1.EnvVar{Name: envName, Value: v})
 		default:
-			return nil, "", "", false, 0, errors.Errorf("invalid driver option %s for driver %s", k, DriverName)
+			return nil, "", "", false, 0, nil, errors.Errorf("invalid driver option %s for driver %s", k, DriverName)
 		}
 	}
-	return deploymentOpt, loadbalance, namespace, defaultLoad, timeout, nil
+	if !autoscale {
+		if _, ok := cfg.DriverOpts["max-replicas"]; ok {
+			return nil, "", "", false, 0, nil, errors.New("max-replicas requires autoscale=true")
+		}
+		if _, ok := cfg.DriverOpts["scale-down-idle"]; ok {
+			return nil, "", "", false, 0, nil, errors.New("scale-down-idle requires autoscale=true")
+		}
+		return deploymentOpt, loadbalance, namespace, defaultLoad, timeout, nil, nil
+	}
+	if autoscaleOpts.MaxReplicas <= deploymentOpt.Replicas {
+		return nil, "", "", false, 0, nil, errors.Errorf("max-replicas must be greater than replicas (%d) when autoscale is enabled", deploymentOpt.Replicas)
+	}
+	if v, ok := cfg.DriverOpts["loadbalance"]; ok && v != LoadbalanceLeastBusy {
+		return nil, "", "", false, 0, nil, errors.Errorf("loadbalance %q cannot be used with autoscale, only %q is supported", v, LoadbalanceLeastBusy)
+	}
+	loadbalance = LoadbalanceLeastBusy
+	return deploymentOpt, loadbalance, namespace, defaultLoad, timeout, &autoscaleOpts, nil
 }
 
 func splitMultiValues(in string, itemsep string, kvsep string) (map[string]string, error) {
@@ -55,7 +55,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 				"qemu.image":      "qemu:latest",
 				"default-load":    "true",
 			}
-			r, loadbalance, ns, defaultLoad, timeout, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			r, loadbalance, ns, defaultLoad, timeout, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 
 			nodeSelectors := map[string]string{
 				"selector1": "value1",
@@ -113,7 +113,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 		"NoOptions", func(t *testing.T) {
 			cfg.DriverOpts = map[string]string{}
 
-			r, loadbalance, ns, defaultLoad, timeout, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			r, loadbalance, ns, defaultLoad, timeout, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 
 			require.NoError(t, err)
 
@@ -144,7 +144,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 				"loadbalance": "sticky",
 			}
 
-			r, loadbalance, ns, defaultLoad, timeout, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			r, loadbalance, ns, defaultLoad, timeout, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 
 			require.NoError(t, err)
 
@@ -173,7 +173,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"replicas": "invalid",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -183,7 +183,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"rootless": "invalid",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -193,7 +193,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"tolerations": "key=foo,value=bar,invalid=foo2",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -203,7 +203,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"tolerations": "key=foo,value=bar,tolerationSeconds=invalid",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -213,7 +213,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"annotations": "key,value",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -223,7 +223,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"labels": "key=value=foo",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -233,7 +233,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"loadbalance": "invalid",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -243,7 +243,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"qemu.install": "invalid",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -253,7 +253,7 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"invalid": "foo",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
@@ -263,10 +263,86 @@ func TestFactory_processDriverOpts(t *testing.T) {
 			cfg.DriverOpts = map[string]string{
 				"timeout": "invalid",
 			}
-			_, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
 			require.Error(t, err)
 		},
 	)
+
+	t.Run(
+		"Autoscale", func(t *testing.T) {
+			cfg.DriverOpts = map[string]string{
+				"replicas":        "2",
+				"autoscale":       "true",
+				"max-replicas":    "5",
+				"scale-down-idle": "30m",
+			}
+			r, loadbalance, _, _, _, autoscale, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			require.NoError(t, err)
+			require.Equal(t, int32(2), r.Replicas)
+			require.Equal(t, LoadbalanceLeastBusy, loadbalance)
+			require.NotNil(t, autoscale)
+			require.Equal(t, int32(5), autoscale.MaxReplicas)
+			require.Equal(t, 30*time.Minute, autoscale.ScaleDownIdle)
+		},
+	)
+
+	t.Run(
+		"AutoscaleDefaults", func(t *testing.T) {
+			cfg.DriverOpts = map[string]string{
+				"autoscale":    "true",
+				"max-replicas": "3",
+			}
+			_, _, _, _, _, autoscale, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			require.NoError(t, err)
+			require.NotNil(t, autoscale)
+			require.Equal(t, defaultScaleDownIdle, autoscale.ScaleDownIdle)
+		},
+	)
+
+	t.Run(
+		"AutoscaleMissingMaxReplicas", func(t *testing.T) {
+			cfg.DriverOpts = map[string]string{
+				"replicas":  "2",
+				"autoscale": "true",
+			}
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			require.Error(t, err)
+		},
+	)
+
+	t.Run(
+		"AutoscaleInvalidLoadBalance", func(t *testing.T) {
+			cfg.DriverOpts = map[string]string{
+				"autoscale":    "true",
+				"max-replicas": "3",
+				"loadbalance":  "sticky",
+			}
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			require.Error(t, err)
+		},
+	)
+
+	t.Run(
+		"MaxReplicasWithoutAutoscale", func(t *testing.T) {
+			cfg.DriverOpts = map[string]string{
+				"max-replicas": "3",
+			}
+			_, _, _, _, _, _, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			require.Error(t, err)
+		},
+	)
+
+	t.Run(
+		"LeastBusyLoadbalance", func(t *testing.T) {
+			cfg.DriverOpts = map[string]string{
+				"loadbalance": "least-busy",
+			}
+			_, loadbalance, _, _, _, autoscale, err := f.processDriverOpts(cfg.Name, "test", cfg)
+			require.NoError(t, err)
+			require.Equal(t, LoadbalanceLeastBusy, loadbalance)
+			require.Nil(t, autoscale)
+		},
+	)
 }
 
 func TestRequiresUncachedClient(t *testing.T) {
@@ -297,6 +373,25 @@ func TestRequiresUncachedClient(t *testing.T) {
 			"expected RequiresUncachedClient=false for loadbalance=sticky")
 	})
 
+	t.Run("LeastBusyLoadbalance", func(t *testing.T) {
+		cfg := baseCfg
+		cfg.DriverOpts = map[string]string{"loadbalance": "least-busy"}
+		d, err := f.New(t.Context(), cfg)
+		require.NoError(t, err)
+		require.True(t, d.(*Driver).RequiresUncachedClient(),
+			"expected RequiresUncachedClient=true for loadbalance=least-busy")
+	})
+
+	t.Run("Autoscale", func(t *testing.T) {
+		cfg := baseCfg
+		cfg.DriverOpts = map[string]string{"autoscale": "true", "max-replicas": "3"}
+		d, err := f.New(t.Context(), cfg)
+		require.NoError(t, err)
+		require.IsType(t, &autoscaler{}, d.(*Driver).podChooser)
+		require.True(t, d.(*Driver).RequiresUncachedClient(),
+			"expected RequiresUncachedClient=true for autoscale")
+	})
+
 	t.Run("DefaultLoadbalance", func(t *testing.T) {
 		cfg := baseCfg
 		cfg.DriverOpts = map[string]string{}
@@ -8,22 +8,26 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 )
 
 type DeploymentClient interface {
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*appsv1.Deployment, error)
 	Create(ctx context.Context, deployment *appsv1.Deployment, opts metav1.CreateOptions) (*appsv1.Deployment, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*appsv1.Deployment, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
 }
 
 type StatefulSetClient interface {
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*appsv1.StatefulSet, error)
 	Create(ctx context.Context, deployment *appsv1.StatefulSet, opts metav1.CreateOptions) (*appsv1.StatefulSet, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*appsv1.StatefulSet, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
 }
 
 type ConfigMapClient interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error)
 	Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error)
 	Update(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
@@ -31,6 +35,7 @@ type ConfigMapClient interface {
 
 type PodClient interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*corev1.PodList, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*corev1.Pod, error)
 	RESTClient() rest.Interface
 }
 
@@ -107,6 +112,20 @@ func (c *deploymentClient) Create(ctx context.Context, deployment *appsv1.Deploy
 	return result, err
 }
 
+func (c *deploymentClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*appsv1.Deployment, error) {
+	result := &appsv1.Deployment{}
+	err := c.client.Patch(pt).
+		UseProtobufAsDefault().
+		Namespace(c.namespace).
+		Resource("deployments").
+		Name(name).
+		VersionedParams(&opts, ParameterCodec()).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
 func (c *deploymentClient) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.client.Delete().
 		UseProtobufAsDefault().
@@ -149,6 +168,20 @@ func (c *statefulSetClient) Create(ctx context.Context, statefulSet *appsv1.Stat
 	return result, err
 }
 
+func (c *statefulSetClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*appsv1.StatefulSet, error) {
+	result := &appsv1.StatefulSet{}
+	err := c.client.Patch(pt).
+		UseProtobufAsDefault().
+		Namespace(c.namespace).
+		Resource("statefulsets").
+		Name(name).
+		VersionedParams(&opts, ParameterCodec()).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
 func (c *statefulSetClient) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.client.Delete().
 		UseProtobufAsDefault().
@@ -165,6 +198,19 @@ type configMapClient struct {
 	namespace string
 }
 
+func (c *configMapClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error) {
+	result := &corev1.ConfigMap{}
+	err := c.client.Get().
+		UseProtobufAsDefault().
+		Namespace(c.namespace).
+		Resource("configmaps").
+		Name(name).
+		VersionedParams(&opts, ParameterCodec()).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
 func (c *configMapClient) Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error) {
 	result := &corev1.ConfigMap{}
 	err := c.client.Post().
@@ -220,6 +266,20 @@ func (c *podClient) List(ctx context.Context, opts metav1.ListOptions) (*corev1.
 	return result, err
 }
 
+func (c *podClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*corev1.Pod, error) {
+	result := &corev1.Pod{}
+	err := c.client.Patch(pt).
+		UseProtobufAsDefault().
+		Namespace(c.namespace).
+		Resource("pods").
+		Name(name).
+		VersionedParams(&opts, ParameterCodec()).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
 func (c *podClient) RESTClient() rest.Interface {
 	return c.client
 }
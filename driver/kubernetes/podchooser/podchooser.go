@@ -4,6 +4,8 @@ import (
 	"context"
 	"math/rand"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/docker/buildx/driver/kubernetes/kubeclient"
 	"github.com/pkg/errors"
@@ -70,6 +72,76 @@ func (pc *StickyPodChooser) ChoosePod(ctx context.Context) (*corev1.Pod, error)
 	return podMap[chosen], nil
 }
 
+// PodLoad describes the build activity of a pod.
+type PodLoad struct {
+	// Active is the number of builds currently running on the pod.
+	Active int
+	// LastActivity is the last time a build started or completed on the pod.
+	LastActivity time.Time
+	// Err is set if the load of the pod could not be determined.
+	Err error
+}
+
+// LoadFunc returns the current load of a pod.
+type LoadFunc func(ctx context.Context, pod *corev1.Pod) (PodLoad, error)
+
+type LeastBusyPodChooser struct {
+	Load        LoadFunc
+	PodClient   kubeclient.PodClient
+	Deployment  *appsv1.Deployment
+	StatefulSet *appsv1.StatefulSet
+}
+
+func (pc *LeastBusyPodChooser) ChoosePod(ctx context.Context) (*corev1.Pod, error) {
+	pods, err := ListRunningPods(ctx, pc.PodClient, pc.Deployment, pc.StatefulSet)
+	if err != nil {
+		return nil, err
+	}
+	return LeastBusy(pods, LoadPods(ctx, pods, pc.Load))
+}
+
+// LoadPods returns the load of each pod, in the same order as pods.
+func LoadPods(ctx context.Context, pods []*corev1.Pod, fn LoadFunc) []PodLoad {
+	loads := make([]PodLoad, len(pods))
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Go(func() {
+			load, err := fn(ctx, pod)
+			if err != nil {
+				logrus.Debugf("failed to load activity of pod %q: %v", pod.Name, err)
+				load = PodLoad{Err: err}
+			}
+			loads[i] = load
+		})
+	}
+	wg.Wait()
+	return loads
+}
+
+// LeastBusy returns the pod with the fewest active builds. Pods whose load is
+// unknown are only chosen if no other pod is available.
+func LeastBusy(pods []*corev1.Pod, loads []PodLoad) (*corev1.Pod, error) {
+	if len(pods) == 0 {
+		return nil, errors.New("no running buildkit pods found")
+	}
+	best := -1
+	for i := range pods {
+		if loads[i].Err != nil {
+			continue
+		}
+		if best == -1 || loads[i].Active < loads[best].Active {
+			best = i
+		}
+	}
+	if best == -1 {
+		n := rand.Intn(len(pods)) // #nosec G404 -- no strong seeding required
+		logrus.Debugf("LeastBusy(): no pod load known, len(pods)=%d, n=%d", len(pods), n)
+		return pods[n], nil
+	}
+	logrus.Debugf("LeastBusy(): len(pods)=%d, chosen=%q, active=%d", len(pods), pods[best].Name, loads[best].Active)
+	return pods[best], nil
+}
+
 func ListRunningPods(ctx context.Context, client kubeclient.PodClient, depl *appsv1.Deployment, stat *appsv1.StatefulSet) ([]*corev1.Pod, error) {
 	var labelSelector *metav1.LabelSelector
 	if depl != nil {
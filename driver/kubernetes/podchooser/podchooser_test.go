@@ -0,0 +1,63 @@
+package podchooser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPods(names ...string) []*corev1.Pod {
+	pods := make([]*corev1.Pod, 0, len(names))
+	for _, name := range names {
+		pods = append(pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return pods
+}
+
+func TestLeastBusy(t *testing.T) {
+	pods := testPods("pod-0", "pod-1", "pod-2")
+
+	pod, err := LeastBusy(pods, []PodLoad{{Active: 2}, {Active: 1}, {Active: 3}})
+	require.NoError(t, err)
+	require.Equal(t, "pod-1", pod.Name)
+
+	// ties are resolved by pod order
+	pod, err = LeastBusy(pods, []PodLoad{{Active: 1}, {Active: 0}, {Active: 0}})
+	require.NoError(t, err)
+	require.Equal(t, "pod-1", pod.Name)
+
+	// pods with unknown load are skipped
+	pod, err = LeastBusy(pods, []PodLoad{{Err: errors.New("boom")}, {Active: 4}, {Err: errors.New("boom")}})
+	require.NoError(t, err)
+	require.Equal(t, "pod-1", pod.Name)
+
+	// falls back to any pod if no load is known
+	pod, err = LeastBusy(pods, []PodLoad{{Err: errors.New("boom")}, {Err: errors.New("boom")}, {Err: errors.New("boom")}})
+	require.NoError(t, err)
+	require.Contains(t, []string{"pod-0", "pod-1", "pod-2"}, pod.Name)
+
+	_, err = LeastBusy(nil, nil)
+	require.Error(t, err)
+}
+
+func TestLoadPods(t *testing.T) {
+	pods := testPods("pod-0", "pod-1", "pod-2")
+	loads := LoadPods(context.TODO(), pods, func(_ context.Context, pod *corev1.Pod) (PodLoad, error) {
+		switch pod.Name {
+		case "pod-0":
+			return PodLoad{Active: 3}, nil
+		case "pod-1":
+			return PodLoad{}, errors.New("unreachable")
+		default:
+			return PodLoad{Active: 1}, nil
+		}
+	})
+	require.Len(t, loads, 3)
+	require.Equal(t, 3, loads[0].Active)
+	require.Error(t, loads[1].Err)
+	require.Equal(t, 1, loads[2].Active)
+}
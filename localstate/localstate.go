@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/docker/buildx/util/confutil"
 	"github.com/pkg/errors"
@@ -30,6 +31,24 @@ type State struct {
 	DockerfilePath string
 	// GroupRef is the ref of the state group that this ref belongs to
 	GroupRef string `json:",omitempty"`
+	// PolicyDecisions are the decisions made by source policies during the
+	// build
+	PolicyDecisions []PolicyDecision `json:",omitempty"`
+}
+
+type PolicyDecision struct {
+	// Source is the identifier of the evaluated source
+	Source string
+	// Kind is the type of the evaluated source (image, git, http, local)
+	Kind string `json:",omitempty"`
+	// Platform is the platform the source was evaluated for
+	Platform string `json:",omitempty"`
+	// Action is the decision made by the policy
+	Action string
+	// Update is the identifier the source was converted to, if any
+	Update string `json:",omitempty"`
+	// DenyMessages are the messages returned by the policy on deny
+	DenyMessages []string `json:",omitempty"`
 }
 
 type StateGroup struct {
@@ -83,6 +102,68 @@ func (ls *LocalState) SaveRef(builderName, nodeName, id string, st State) error
 	return ls.cfg.AtomicWriteFile(filepath.Join(refDir, id), dt, 0644)
 }
 
+// RemoveRef removes the state of a build ref of a builder node.
+func (ls *LocalState) RemoveRef(builderName, nodeName, id string) error {
+	if err := ls.validate(builderName, nodeName, id); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(ls.cfg.Dir(), refsDir, builderName, nodeName, id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// TargetKey identifies a target built from a local path.
+type TargetKey struct {
+	Target    string
+	LocalPath string
+}
+
+// LastNodes returns the name of the builder node that most recently built
+// each target recorded for the builder.
+func (ls *LocalState) LastNodes(builderName string) (map[TargetKey]string, error) {
+	if builderName == "" {
+		return nil, errors.Errorf("builder name empty")
+	}
+	dir := filepath.Join(ls.cfg.Dir(), refsDir, builderName)
+	nodes, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[TargetKey]string{}, nil
+		}
+		return nil, err
+	}
+
+	last := map[TargetKey]string{}
+	lastTime := map[TargetKey]time.Time{}
+	for _, node := range nodes {
+		if !node.IsDir() {
+			continue
+		}
+		refs, err := os.ReadDir(filepath.Join(dir, node.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			fi, err := ref.Info()
+			if err != nil {
+				continue
+			}
+			st, err := ls.ReadRef(builderName, node.Name(), ref.Name())
+			if err != nil {
+				continue
+			}
+			k := TargetKey{Target: st.Target, LocalPath: st.LocalPath}
+			if t, ok := lastTime[k]; ok && !fi.ModTime().After(t) {
+				continue
+			}
+			last[k] = node.Name()
+			lastTime[k] = fi.ModTime()
+		}
+	}
+	return last, nil
+}
+
 func (ls *LocalState) GroupDir() string {
 	return filepath.Join(ls.cfg.Dir(), refsDir, groupDir)
 }
@@ -1,6 +1,7 @@
 package localstate
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -19,6 +20,16 @@ func TestReadRef(t *testing.T) {
 	require.Equal(t, testStateRef, *r)
 }
 
+func TestRemoveRef(t *testing.T) {
+	l := newls(t)
+	require.NoError(t, l.RemoveRef(testBuilderName, testNodeName, testStateRefID))
+	_, err := l.ReadRef(testBuilderName, testNodeName, testStateRefID)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// removing a missing ref is a no-op
+	require.NoError(t, l.RemoveRef(testBuilderName, testNodeName, testStateRefID))
+}
+
 func TestReadGroup(t *testing.T) {
 	l := newls(t)
 	g, err := l.ReadGroup(testStateGroupID)
@@ -26,6 +37,18 @@ func TestReadGroup(t *testing.T) {
 	require.Equal(t, testStateGroup, *g)
 }
 
+func TestLastNodes(t *testing.T) {
+	l := newls(t)
+	last, err := l.LastNodes(testBuilderName)
+	require.NoError(t, err)
+	require.Equal(t, testNodeName, last[TargetKey{Target: "format", LocalPath: testStateGroupRef1.LocalPath}])
+	require.Empty(t, last[TargetKey{Target: "unknown", LocalPath: testStateGroupRef1.LocalPath}])
+
+	last, err = l.LastNodes("unknown")
+	require.NoError(t, err)
+	require.Empty(t, last)
+}
+
 func TestRemoveBuilder(t *testing.T) {
 	l := newls(t)
 	require.NoError(t, l.RemoveBuilder(testBuilderName))
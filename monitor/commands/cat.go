@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"io"
+	iofs "io/fs"
+
+	"github.com/docker/buildx/build"
+	"github.com/docker/buildx/monitor/types"
+	"github.com/pkg/errors"
+)
+
+type CatCmd struct {
+	m types.Monitor
+
+	invokeConfig *build.InvokeConfig
+	stdout       io.WriteCloser
+}
+
+func NewCatCmd(m types.Monitor, invokeConfig *build.InvokeConfig, stdout io.WriteCloser) types.Command {
+	return &CatCmd{m, invokeConfig, stdout}
+}
+
+func (cm *CatCmd) Info() types.CommandInfo {
+	return types.CommandInfo{
+		Name:        "cat",
+		HelpMessage: "print the content of files in the interactive container",
+		HelpMessageLong: `
+Usage:
+  cat FILE [FILE...]
+
+FILE is a file in the container, including its mounts.
+Relative paths are resolved from the working directory of the container.
+`,
+	}
+}
+
+func (cm *CatCmd) Exec(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errors.Errorf("file must be passed")
+	}
+	ctr, err := cm.m.Container(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range args[1:] {
+		fpath := containerPath(cm.invokeConfig.Cwd, p)
+		st, err := statFile(ctx, ctr, fpath)
+		if err != nil {
+			return err
+		}
+		if iofs.FileMode(st.Mode).IsDir() {
+			return errors.Errorf("%s is a directory", fpath)
+		}
+		if _, err := copyFile(ctx, ctr, fpath, cm.stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
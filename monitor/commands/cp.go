@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/docker/buildx/build"
+	"github.com/docker/buildx/monitor/types"
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+type CpCmd struct {
+	m types.Monitor
+
+	invokeConfig *build.InvokeConfig
+	stdout       io.WriteCloser
+}
+
+func NewCpCmd(m types.Monitor, invokeConfig *build.InvokeConfig, stdout io.WriteCloser) types.Command {
+	return &CpCmd{m, invokeConfig, stdout}
+}
+
+func (cm *CpCmd) Info() types.CommandInfo {
+	return types.CommandInfo{
+		Name:        "cp",
+		HelpMessage: "copy files from the interactive container to the host",
+		HelpMessageLong: `
+Usage:
+  cp SRC_PATH DEST_PATH
+
+SRC_PATH is a file or directory in the container, including its mounts.
+Relative paths are resolved from the working directory of the container.
+Directories are copied recursively. If DEST_PATH is an existing directory,
+SRC_PATH is copied into it.
+`,
+	}
+}
+
+func (cm *CpCmd) Exec(ctx context.Context, args []string) error {
+	if len(args) != 3 {
+		return errors.Errorf("source and destination paths must be passed")
+	}
+	ctr, err := cm.m.Container(ctx)
+	if err != nil {
+		return err
+	}
+	src := containerPath(cm.invokeConfig.Cwd, args[1])
+	stats, dest, err := copyPath(ctx, ctr, src, args[2])
+	if err != nil {
+		return err
+	}
+	for _, p := range stats.Skipped {
+		fmt.Fprintf(cm.stdout, "skipped special file %s\n", p)
+	}
+	fmt.Fprintf(cm.stdout, "Copied %d files (%s) to %s\n", stats.Files, units.HumanSize(float64(stats.Size)), dest)
+	return nil
+}
+
+// copyPath copies src from the container to dest on the host and returns
+// the path it was copied to.
+func copyPath(ctx context.Context, ctr containerFS, src, dest string) (*copyStats, string, error) {
+	st, err := statFile(ctx, ctr, src)
+	if err != nil {
+		return nil, "", err
+	}
+	if fi, err := os.Stat(dest); err == nil && fi.IsDir() && src != "/" {
+		dest = filepath.Join(dest, path.Base(src))
+	}
+	var stats copyStats
+	if err := copyToHost(ctx, ctr, src, st, dest, &stats); err != nil {
+		return nil, "", err
+	}
+	return &stats, dest, nil
+}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"context"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	gateway "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/pkg/errors"
+	"github.com/tonistiigi/fsutil/types"
+)
+
+// readChunkSize is the size of the chunks files are read from the container
+// with, so large files like core dumps don't exceed the message size limit.
+const readChunkSize = 1 << 20
+
+// containerFS is the filesystem of the interactive container.
+type containerFS interface {
+	ResolvePath(fpath string) (string, int, error)
+	ReadFile(ctx context.Context, req gateway.ReadContainerRequest) ([]byte, error)
+	ReadDir(ctx context.Context, req gateway.ReadDirContainerRequest) ([]*types.Stat, error)
+	StatFile(ctx context.Context, req gateway.StatContainerRequest) (*types.Stat, error)
+}
+
+// containerPath returns the absolute path of p in the container. Relative
+// paths are resolved from the working directory of the container.
+func containerPath(cwd, p string) string {
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	if cwd == "" {
+		cwd = "/"
+	}
+	return path.Join(cwd, p)
+}
+
+func statFile(ctx context.Context, ctr containerFS, fpath string) (*types.Stat, error) {
+	p, idx, err := ctr.ResolvePath(fpath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s", fpath)
+	}
+	st, err := ctr.StatFile(ctx, gateway.StatContainerRequest{
+		StatRequest: gateway.StatRequest{Path: p},
+		MountIndex:  idx,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s", fpath)
+	}
+	return st, nil
+}
+
+func readDir(ctx context.Context, ctr containerFS, fpath string) ([]*types.Stat, error) {
+	p, idx, err := ctr.ResolvePath(fpath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read dir %s", fpath)
+	}
+	entries, err := ctr.ReadDir(ctx, gateway.ReadDirContainerRequest{
+		ReadDirRequest: gateway.ReadDirRequest{Path: p},
+		MountIndex:     idx,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "read dir %s", fpath)
+	}
+	return entries, nil
+}
+
+// copyFile writes the content of a file of the container to w.
+func copyFile(ctx context.Context, ctr containerFS, fpath string, w io.Writer) (int64, error) {
+	p, idx, err := ctr.ResolvePath(fpath)
+	if err != nil {
+		return 0, errors.Wrapf(err, "read %s", fpath)
+	}
+	var n int64
+	for {
+		dt, err := ctr.ReadFile(ctx, gateway.ReadContainerRequest{
+			ReadRequest: gateway.ReadRequest{
+				Filename: p,
+				Range:    &gateway.FileRange{Offset: int(n), Length: readChunkSize},
+			},
+			MountIndex: idx,
+		})
+		if err != nil {
+			return n, errors.Wrapf(err, "read %s", fpath)
+		}
+		if _, err := w.Write(dt); err != nil {
+			return n, err
+		}
+		n += int64(len(dt))
+		if len(dt) < readChunkSize {
+			return n, nil
+		}
+	}
+}
+
+type copyStats struct {
+	Files   int
+	Size    int64
+	Skipped []string
+}
+
+// copyToHost copies a file or directory of the container to dest on the
+// host. Symlinks are copied as is, special files are skipped.
+func copyToHost(ctx context.Context, ctr containerFS, src string, st *types.Stat, dest string, stats *copyStats) error {
+	mode := iofs.FileMode(st.Mode)
+	switch {
+	case mode.IsDir():
+		if err := os.MkdirAll(dest, mode.Perm()|0o700); err != nil {
+			return err
+		}
+		entries, err := readDir(ctx, ctr, src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			name := path.Base(e.Path)
+			if name == "." || name == ".." || name == "/" {
+				continue
+			}
+			if err := copyToHost(ctx, ctr, path.Join(src, name), e, filepath.Join(dest, name), stats); err != nil {
+				return err
+			}
+		}
+		return nil
+	case mode&iofs.ModeSymlink != 0:
+		if err := os.Symlink(st.Linkname, dest); err != nil {
+			return err
+		}
+		stats.Files++
+		return nil
+	case mode.IsRegular():
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+		if err != nil {
+			return err
+		}
+		n, err := copyFile(ctx, ctr, src, f)
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+		if err != nil {
+			return err
+		}
+		if st.ModTime != 0 {
+			t := time.Unix(0, st.ModTime)
+			_ = os.Chtimes(dest, t, t)
+		}
+		stats.Files++
+		stats.Size += n
+		return nil
+	default:
+		stats.Skipped = append(stats.Skipped, src)
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gateway "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/stretchr/testify/require"
+	"github.com/tonistiigi/fsutil/types"
+)
+
+// testContainerFS serves the mounts of a container from host directories.
+type testContainerFS struct {
+	mounts []string // container mount destinations
+	dirs   []string // host directories of the mounts
+}
+
+func (c *testContainerFS) ResolvePath(fpath string) (string, int, error) {
+	idx := -1
+	for i, m := range c.mounts {
+		if strings.HasPrefix(fpath, m) && (idx == -1 || len(m) > len(c.mounts[idx])) {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		return "", 0, os.ErrNotExist
+	}
+	return "/" + strings.TrimPrefix(strings.TrimPrefix(fpath, c.mounts[idx]), "/"), idx, nil
+}
+
+func (c *testContainerFS) hostPath(p string, idx int) string {
+	return filepath.Join(c.dirs[idx], filepath.FromSlash(p))
+}
+
+func (c *testContainerFS) ReadFile(_ context.Context, req gateway.ReadContainerRequest) ([]byte, error) {
+	dt, err := os.ReadFile(c.hostPath(req.Filename, req.MountIndex))
+	if err != nil {
+		return nil, err
+	}
+	if r := req.Range; r != nil {
+		dt = dt[min(r.Offset, len(dt)):]
+		dt = dt[:min(r.Length, len(dt))]
+	}
+	return dt, nil
+}
+
+func (c *testContainerFS) ReadDir(_ context.Context, req gateway.ReadDirContainerRequest) ([]*types.Stat, error) {
+	dir := c.hostPath(req.Path, req.MountIndex)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []*types.Stat
+	for _, e := range entries {
+		st, err := lstat(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (c *testContainerFS) StatFile(_ context.Context, req gateway.StatContainerRequest) (*types.Stat, error) {
+	return lstat(c.hostPath(req.Path, req.MountIndex))
+}
+
+func lstat(p string) (*types.Stat, error) {
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return nil, err
+	}
+	st := &types.Stat{
+		Path:    fi.Name(),
+		Mode:    uint32(fi.Mode()),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime().UnixNano(),
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		st.Linkname, _ = os.Readlink(p)
+	}
+	return st, nil
+}
+
+func newTestContainerFS(t *testing.T) *testContainerFS {
+	rootfs, cache := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "app", "reports", "unit"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "app", "reports", "junit.xml"), []byte("<testsuites/>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "app", "reports", "unit", "core"), bytes.Repeat([]byte("x"), readChunkSize+10), 0o600))
+	require.NoError(t, os.Symlink("junit.xml", filepath.Join(rootfs, "app", "reports", "latest.xml")))
+	require.NoError(t, os.WriteFile(filepath.Join(cache, "build.log"), []byte("failed\n"), 0o644))
+	return &testContainerFS{
+		mounts: []string{"/", "/cache"},
+		dirs:   []string{rootfs, cache},
+	}
+}
+
+func TestCopyPath(t *testing.T) {
+	ctr := newTestContainerFS(t)
+	dest := t.TempDir()
+
+	stats, out, err := copyPath(context.TODO(), ctr, "/app/reports", dest)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dest, "reports"), out)
+	require.Equal(t, 3, stats.Files)
+	require.Equal(t, int64(len("<testsuites/>")+readChunkSize+10), stats.Size)
+
+	dt, err := os.ReadFile(filepath.Join(out, "junit.xml"))
+	require.NoError(t, err)
+	require.Equal(t, "<testsuites/>", string(dt))
+	fi, err := os.Stat(filepath.Join(out, "unit", "core"))
+	require.NoError(t, err)
+	require.Equal(t, int64(readChunkSize+10), fi.Size())
+	require.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	link, err := os.Readlink(filepath.Join(out, "latest.xml"))
+	require.NoError(t, err)
+	require.Equal(t, "junit.xml", link)
+
+	// files are read from the mount containing them
+	_, out, err = copyPath(context.TODO(), ctr, "/cache/build.log", filepath.Join(dest, "build.log"))
+	require.NoError(t, err)
+	dt, err = os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "failed\n", string(dt))
+
+	_, _, err = copyPath(context.TODO(), ctr, "/app/missing", dest)
+	require.Error(t, err)
+}
+
+func TestListFiles(t *testing.T) {
+	ctr := newTestContainerFS(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, listFiles(context.TODO(), ctr, "/app/reports", &buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasSuffix(lines[0], "junit.xml"), lines[0])
+	require.True(t, strings.HasSuffix(lines[1], "latest.xml -> junit.xml"), lines[1])
+	require.True(t, strings.HasSuffix(lines[2], "unit/"), lines[2])
+
+	buf.Reset()
+	require.NoError(t, listFiles(context.TODO(), ctr, "/cache/build.log", &buf))
+	require.Contains(t, buf.String(), "build.log")
+}
+
+func TestContainerPath(t *testing.T) {
+	require.Equal(t, "/app/out", containerPath("/app", "out"))
+	require.Equal(t, "/out", containerPath("", "out"))
+	require.Equal(t, "/etc/passwd", containerPath("/app", "/etc/../etc/passwd"))
+}
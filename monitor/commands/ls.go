@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/buildx/build"
+	"github.com/docker/buildx/monitor/types"
+	"github.com/docker/go-units"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+type LsCmd struct {
+	m types.Monitor
+
+	invokeConfig *build.InvokeConfig
+	stdout       io.WriteCloser
+}
+
+func NewLsCmd(m types.Monitor, invokeConfig *build.InvokeConfig, stdout io.WriteCloser) types.Command {
+	return &LsCmd{m, invokeConfig, stdout}
+}
+
+func (cm *LsCmd) Info() types.CommandInfo {
+	return types.CommandInfo{
+		Name:        "ls",
+		HelpMessage: "list files in the interactive container",
+		HelpMessageLong: `
+Usage:
+  ls [PATH]
+
+PATH is a file or directory in the container, including its mounts.
+Relative paths are resolved from the working directory of the container.
+`,
+	}
+}
+
+func (cm *LsCmd) Exec(ctx context.Context, args []string) error {
+	ctr, err := cm.m.Container(ctx)
+	if err != nil {
+		return err
+	}
+	p := "."
+	if len(args) >= 2 {
+		p = args[1]
+	}
+	return listFiles(ctx, ctr, containerPath(cm.invokeConfig.Cwd, p), cm.stdout)
+}
+
+func listFiles(ctx context.Context, ctr containerFS, fpath string, w io.Writer) error {
+	st, err := statFile(ctx, ctr, fpath)
+	if err != nil {
+		return err
+	}
+	entries := []*fstypes.Stat{st}
+	if iofs.FileMode(st.Mode).IsDir() {
+		if entries, err = readDir(ctx, ctr, fpath); err != nil {
+			return err
+		}
+	}
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	for _, e := range entries {
+		mode := iofs.FileMode(e.Mode)
+		name := path.Base(e.Path)
+		switch {
+		case mode.IsDir():
+			name += "/"
+		case mode&iofs.ModeSymlink != 0:
+			name += " -> " + e.Linkname
+		}
+		modTime := time.Unix(0, e.ModTime).UTC().Format("Jan 2 15:04 2006")
+		fmt.Fprintf(tw, "%s\t%d:%d\t%s\t%s\t%s\n", mode, e.Uid, e.Gid, units.HumanSize(float64(e.Size)), modTime, name)
+	}
+	return tw.Flush()
+}
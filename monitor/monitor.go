@@ -189,6 +189,9 @@ func RunMonitor(ctx context.Context, invokeConfig *build.InvokeConfig, rCtx *bui
 		commands.NewAttachCmd(m, stdout),
 		commands.NewExecCmd(m, invokeConfig, stdout),
 		commands.NewPsCmd(m, stdout),
+		commands.NewLsCmd(m, invokeConfig, stdout),
+		commands.NewCatCmd(m, invokeConfig, stdout),
+		commands.NewCpCmd(m, invokeConfig, stdout),
 	}
 	registeredCommands := make(map[string]types.Command)
 	for _, c := range availableCommands {
@@ -388,6 +391,14 @@ func (m *monitor) Detach() {
 	}
 }
 
+func (m *monitor) Container(ctx context.Context) (*build.Container, error) {
+	ctr, ok := m.processes.Container()
+	if !ok {
+		return nil, errors.New("no container is running, use \"rollback\" to start one")
+	}
+	return ctr, nil
+}
+
 func (m *monitor) Reload() {
 	m.cancel(build.ErrRestart)
 }
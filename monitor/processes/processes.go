@@ -58,6 +58,12 @@ func (m *Manager) Get(id string) (*Process, bool) {
 	return v.(*Process), true
 }
 
+// Container returns the container processes are started in, if any.
+func (m *Manager) Container() (*build.Container, bool) {
+	ctr, ok := m.container.Load().(*build.Container)
+	return ctr, ok && ctr != nil
+}
+
 // CancelRunningProcesses cancels execution of all running processes.
 func (m *Manager) CancelRunningProcesses() {
 	var funcs []func()
@@ -35,6 +35,9 @@ type Monitor interface {
 	// Detach detaches IO from the container.
 	Detach()
 
+	// Container returns the interactive container.
+	Container(ctx context.Context) (*build.Container, error)
+
 	// Reload will signal the monitor to be reloaded.
 	Reload()
 
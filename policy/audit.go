@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditRecord describes a single policy decision for a source.
+type AuditRecord struct {
+	Time         time.Time `json:"time"`
+	Policy       []string  `json:"policy,omitempty"`
+	Kind         string    `json:"kind"`
+	Source       string    `json:"source"`
+	Platform     string    `json:"platform,omitempty"`
+	Input        Input     `json:"input"`
+	Action       string    `json:"action"`
+	Update       string    `json:"update,omitempty"`
+	DenyMessages []string  `json:"denyMessages,omitempty"`
+}
+
+// AuditLog collects policy decisions and appends them as JSON lines to a
+// file.
+type AuditLog struct {
+	mu      sync.Mutex
+	f       *os.File
+	records []AuditRecord
+}
+
+// NewAuditLog opens the audit file at path for appending. An empty path
+// keeps the records in memory only.
+func NewAuditLog(path string) (*AuditLog, error) {
+	l := &AuditLog{}
+	if path == "" {
+		return l, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create policy audit log directory")
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open policy audit log")
+	}
+	l.f = f
+	return l, nil
+}
+
+// Record adds a decision to the log.
+func (l *AuditLog) Record(rec AuditRecord) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, rec)
+	if l.f == nil {
+		return nil
+	}
+	dt, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal policy audit record")
+	}
+	// a single write per record keeps lines intact when multiple builds
+	// append to the same file
+	if _, err := l.f.Write(append(dt, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write policy audit record")
+	}
+	return nil
+}
+
+// Records returns the decisions recorded so far.
+func (l *AuditLog) Records() []AuditRecord {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.records)
+}
+
+func (l *AuditLog) Close() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+func (inp *Input) kind() string {
+	switch {
+	case inp.Image != nil:
+		return "image"
+	case inp.Git != nil:
+		return "git"
+	case inp.HTTP != nil:
+		return "http"
+	case inp.Local != nil:
+		return "local"
+	case inp.Build != nil:
+		return "build"
+	default:
+		return "unknown"
+	}
+}
+
+func (p *Policy) audit(inp Input, source, platform, action, update string, denyMessages []string) {
+	if p.opt.Audit == nil {
+		return
+	}
+	files := make([]string, 0, len(p.opt.Files))
+	for _, f := range p.opt.Files {
+		files = append(files, f.Filename)
+	}
+	if err := p.opt.Audit.Record(AuditRecord{
+		Time:         time.Now().UTC(),
+		Policy:       files,
+		Kind:         inp.kind(),
+		Source:       strings.TrimSpace(source),
+		Platform:     platform,
+		Input:        inp,
+		Action:       action,
+		Update:       update,
+		DenyMessages: denyMessages,
+	}); err != nil {
+		p.log(logrus.ErrorLevel, "%v", err)
+	}
+}
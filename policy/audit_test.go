@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gwpb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/solver/pb"
+	moby_buildkit_v1_sourcepolicy "github.com/moby/buildkit/sourcepolicy/pb"
+	"github.com/moby/buildkit/sourcepolicy/policysession"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogRecordsDecisions(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "audit", "policy.jsonl")
+	audit, err := NewAuditLog(fp)
+	require.NoError(t, err)
+
+	p := DefaultPolicy(Opt{
+		Log: func(level logrus.Level, msg string) {
+			t.Logf("[%s] %s", level, msg)
+		},
+		Audit: audit,
+	})
+	for _, id := range []string{"https://example.com/foo.tar.gz", "https://example.com/bar.tar.gz"} {
+		resp, _, err := p.CheckPolicy(context.Background(), &policysession.CheckPolicyRequest{
+			Platform: &pb.Platform{OS: "linux", Architecture: "amd64"},
+			Source: &gwpb.ResolveSourceMetaResponse{
+				Source: &pb.SourceOp{Identifier: id},
+				HTTP: &gwpb.ResolveSourceHTTPResponse{
+					Checksum: "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, moby_buildkit_v1_sourcepolicy.PolicyAction_ALLOW, resp.Action)
+	}
+	require.NoError(t, audit.Close())
+
+	records := audit.Records()
+	require.Len(t, records, 2)
+	require.Equal(t, "https://example.com/foo.tar.gz", records[0].Source)
+	require.Equal(t, "http", records[0].Kind)
+	require.Equal(t, "linux/amd64", records[0].Platform)
+	require.Equal(t, "ALLOW", records[0].Action)
+
+	f, err := os.Open(fp)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []AuditRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec AuditRecord
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &rec))
+		lines = append(lines, rec)
+	}
+	require.NoError(t, sc.Err())
+	require.Len(t, lines, 2)
+	require.Equal(t, "https://example.com/bar.tar.gz", lines[1].Source)
+	require.Equal(t, "ALLOW", lines[1].Action)
+}
+
+func TestAuditLogInMemory(t *testing.T) {
+	audit, err := NewAuditLog("")
+	require.NoError(t, err)
+	require.NoError(t, audit.Record(AuditRecord{Source: "docker-image://alpine", Action: "DENY"}))
+	require.NoError(t, audit.Close())
+	require.Len(t, audit.Records(), 1)
+
+	var nilLog *AuditLog
+	require.NoError(t, nilLog.Record(AuditRecord{}))
+	require.Nil(t, nilLog.Records())
+}
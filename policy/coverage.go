@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/cover"
+)
+
+// CoverageReport is the line coverage of the policy modules exercised by
+// policy tests.
+type CoverageReport struct {
+	Files           []FileCoverage `json:"files"`
+	CoveredLines    int            `json:"covered_lines"`
+	NotCoveredLines int            `json:"not_covered_lines"`
+	Coverage        float64        `json:"coverage"`
+}
+
+// FileCoverage is the line coverage of a single policy module.
+type FileCoverage struct {
+	Filename        string         `json:"filename"`
+	CoveredLines    int            `json:"covered_lines"`
+	NotCoveredLines int            `json:"not_covered_lines"`
+	Coverage        float64        `json:"coverage"`
+	NotCovered      []LineRange    `json:"not_covered,omitempty"`
+	Rules           []RuleCoverage `json:"rules,omitempty"`
+}
+
+// RuleCoverage is the line coverage of all the definitions of a rule. Line is
+// the first line of the first definition.
+type RuleCoverage struct {
+	Name            string  `json:"name"`
+	Line            int     `json:"line"`
+	CoveredLines    int     `json:"covered_lines"`
+	NotCoveredLines int     `json:"not_covered_lines"`
+	Coverage        float64 `json:"coverage"`
+}
+
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// coverageModules returns the compiled modules that should be included in
+// the coverage report. Test modules and the builtin module are excluded.
+func coverageModules(compiler *ast.Compiler, testModules map[string]*ast.Module) map[string]*ast.Module {
+	out := make(map[string]*ast.Module, len(compiler.Modules))
+	for name, mod := range compiler.Modules {
+		if _, ok := testModules[name]; ok || name == builtinPolicyModuleFilename {
+			continue
+		}
+		out[name] = mod
+	}
+	return out
+}
+
+func newCoverageReport(c *cover.Cover, modules map[string]*ast.Module) *CoverageReport {
+	report := c.Report(modules)
+	out := &CoverageReport{
+		Files: []FileCoverage{},
+	}
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		mod := modules[name]
+		fr := report.Files[name]
+		fc := FileCoverage{
+			Filename: name,
+		}
+		if fr != nil {
+			for _, r := range fr.NotCovered {
+				fc.NotCovered = append(fc.NotCovered, LineRange{Start: r.Start.Row, End: r.End.Row})
+			}
+		}
+		rules := map[string]int{}
+		for _, rule := range mod.Rules {
+			if rule.Location == nil {
+				continue
+			}
+			name := rule.Head.Ref().String()
+			i, ok := rules[name]
+			if !ok {
+				i = len(fc.Rules)
+				rules[name] = i
+				fc.Rules = append(fc.Rules, RuleCoverage{Name: name, Line: rule.Location.Row})
+			}
+			start := rule.Location.Row
+			end := start + strings.Count(string(rule.Location.Text), "\n")
+			for row := start; row <= end; row++ {
+				switch {
+				case fr.IsCovered(row):
+					fc.Rules[i].CoveredLines++
+				case fr.IsNotCovered(row):
+					fc.Rules[i].NotCoveredLines++
+				}
+			}
+		}
+		for i, r := range fc.Rules {
+			fc.Rules[i].Coverage = coveragePercent(r.CoveredLines, r.NotCoveredLines)
+			fc.CoveredLines += r.CoveredLines
+			fc.NotCoveredLines += r.NotCoveredLines
+		}
+		fc.Coverage = coveragePercent(fc.CoveredLines, fc.NotCoveredLines)
+		out.CoveredLines += fc.CoveredLines
+		out.NotCoveredLines += fc.NotCoveredLines
+		out.Files = append(out.Files, fc)
+	}
+	out.Coverage = coveragePercent(out.CoveredLines, out.NotCoveredLines)
+	return out
+}
+
+// coveragePercent returns the percentage of covered lines. A file or rule
+// without any line to cover is reported as fully covered.
+func coveragePercent(covered, notCovered int) float64 {
+	if covered+notCovered == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(covered+notCovered)
+}
@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPolicyTestsCoverage(t *testing.T) {
+	root := fstest.MapFS{
+		"Dockerfile.rego": &fstest.MapFile{Data: []byte(`package docker
+
+default allow := false
+
+allow if {
+	input.image.repo == "alpine"
+}
+
+deny_msg contains msg if {
+	input.image.repo == "busybox"
+	msg := "busybox is not allowed"
+}
+
+decision := {"allow": allow, "deny_msg": deny_msg}
+`)},
+		"Dockerfile_test.rego": &fstest.MapFile{Data: []byte(`package docker
+
+test_alpine if {
+	allow with input as {"image": {"repo": "alpine"}}
+}
+`)},
+	}
+
+	summary, err := RunPolicyTests(context.TODO(), ".", TestOptions{
+		Filename: "Dockerfile",
+		Root:     root,
+		Coverage: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, summary.Failed)
+	require.NotNil(t, summary.Coverage)
+	require.Len(t, summary.Coverage.Files, 1)
+
+	fc := summary.Coverage.Files[0]
+	require.Equal(t, "Dockerfile.rego", fc.Filename)
+	require.Equal(t, []RuleCoverage{
+		{Name: "allow", Line: 3, CoveredLines: 2, NotCoveredLines: 1, Coverage: 200.0 / 3},
+		{Name: "deny_msg", Line: 9, CoveredLines: 0, NotCoveredLines: 3, Coverage: 0},
+		{Name: "decision", Line: 14, CoveredLines: 0, NotCoveredLines: 1, Coverage: 0},
+	}, fc.Rules)
+	require.Equal(t, []LineRange{{Start: 3, End: 3}, {Start: 9, End: 11}, {Start: 14, End: 14}}, fc.NotCovered)
+	require.Equal(t, 2, summary.Coverage.CoveredLines)
+	require.Equal(t, 5, summary.Coverage.NotCoveredLines)
+	require.InDelta(t, 28.57, summary.Coverage.Coverage, 0.01)
+}
+
+func TestRunPolicyTestsNoCoverage(t *testing.T) {
+	root := fstest.MapFS{
+		"Dockerfile.rego":      &fstest.MapFile{Data: []byte("package docker\n\nallow := true\n")},
+		"Dockerfile_test.rego": &fstest.MapFile{Data: []byte("package docker\n\ntest_allow if {\n\tallow\n}\n")},
+	}
+	summary, err := RunPolicyTests(context.TODO(), ".", TestOptions{
+		Filename: "Dockerfile",
+		Root:     root,
+	})
+	require.NoError(t, err)
+	require.Nil(t, summary.Coverage)
+}
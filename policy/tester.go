@@ -15,6 +15,7 @@ import (
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/sourcepolicy/policysession"
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/cover"
 	"github.com/open-policy-agent/opa/v1/rego"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
@@ -25,11 +26,14 @@ type TestOptions struct {
 	Filename string
 	Root     fs.StatFS
 	Provider *TestOptionsProvider
+	// Coverage enables the line coverage report of the policy modules
+	Coverage bool
 }
 
 type TestSummary struct {
-	Results []TestResult
-	Failed  int
+	Results  []TestResult
+	Failed   int
+	Coverage *CoverageReport
 }
 
 type TestResult struct {
@@ -101,8 +105,13 @@ func RunPolicyTests(ctx context.Context, path string, opts TestOptions) (TestSum
 		return summary, errors.New("no tests found")
 	}
 
+	var cov *cover.Cover
+	if opts.Coverage {
+		cov = cover.New()
+	}
+
 	for _, t := range tests {
-		result, err := runPolicyTest(ctx, policyModules, testModules, policyFiles, comp, p, t, opts, fsProvider)
+		result, err := runPolicyTest(ctx, policyModules, testModules, policyFiles, comp, p, t, opts, fsProvider, cov)
 		if err != nil {
 			return summary, err
 		}
@@ -111,6 +120,9 @@ func RunPolicyTests(ctx context.Context, path string, opts TestOptions) (TestSum
 		}
 		summary.Results = append(summary.Results, result)
 	}
+	if cov != nil {
+		summary.Coverage = newCoverageReport(cov, coverageModules(comp, testModules))
+	}
 	return summary, nil
 }
 
@@ -261,7 +273,7 @@ func filterPolicyTests(tests []testDef, substr string) []testDef {
 	return out
 }
 
-func runPolicyTest(ctx context.Context, policyModules map[string]*ast.Module, testModules map[string]*ast.Module, policyFiles []File, compiler *ast.Compiler, p *Policy, t testDef, opts TestOptions, fsProvider func() (fs.StatFS, func() error, error)) (TestResult, error) {
+func runPolicyTest(ctx context.Context, policyModules map[string]*ast.Module, testModules map[string]*ast.Module, policyFiles []File, compiler *ast.Compiler, p *Policy, t testDef, opts TestOptions, fsProvider func() (fs.StatFS, func() error, error), cov *cover.Cover) (TestResult, error) {
 	result := TestResult{
 		Name:    t.Name,
 		Package: t.PkgPath,
@@ -285,7 +297,13 @@ func runPolicyTest(ctx context.Context, policyModules map[string]*ast.Module, te
 
 	testState := stateFromInput(effectiveInput)
 	query := fmt.Sprintf("%s.%s", t.PkgPath, t.Name)
-	ok, err := evalBool(ctx, compiler, p, testState, query, effectiveInput)
+	var evalOpts []func(*rego.Rego)
+	if cov != nil {
+		// only the test query is traced so the decision evaluated for the
+		// report doesn't count as covered
+		evalOpts = append(evalOpts, rego.QueryTracer(cov))
+	}
+	ok, err := evalBool(ctx, compiler, p, testState, query, effectiveInput, evalOpts...)
 	if err != nil {
 		return result, err
 	}
@@ -527,8 +545,8 @@ func filterResolvableMissing(missing []string) []string {
 	return out
 }
 
-func evalBool(ctx context.Context, compiler *ast.Compiler, p *Policy, st *state, query string, input *Input) (bool, error) {
-	r := newPolicyRego(compiler, p, st, query, input)
+func evalBool(ctx context.Context, compiler *ast.Compiler, p *Policy, st *state, query string, input *Input, opts ...func(*rego.Rego)) (bool, error) {
+	r := newPolicyRego(compiler, p, st, query, input, opts...)
 	rs, err := r.Eval(ctx)
 	if err != nil {
 		return false, err
@@ -555,7 +573,7 @@ func evalValue(ctx context.Context, compiler *ast.Compiler, p *Policy, st *state
 	return rs[0].Expressions[0].Value, nil
 }
 
-func newPolicyRego(compiler *ast.Compiler, p *Policy, st *state, query string, input *Input) *rego.Rego {
+func newPolicyRego(compiler *ast.Compiler, p *Policy, st *state, query string, input *Input, extra ...func(*rego.Rego)) *rego.Rego {
 	opts := []func(*rego.Rego){
 		rego.SetRegoVersion(ast.RegoV1),
 		rego.Query(query),
@@ -568,6 +586,7 @@ func newPolicyRego(compiler *ast.Compiler, p *Policy, st *state, query string, i
 	for _, f := range p.funcs {
 		opts = append(opts, f.impl(st))
 	}
+	opts = append(opts, extra...)
 	return rego.New(opts...)
 }
 
@@ -14,6 +14,7 @@ type Input struct {
 	Image *Image `json:"image,omitempty"`
 	HTTP  *HTTP  `json:"http,omitempty"`
 	Git   *Git   `json:"git,omitempty"`
+	Build *Build `json:"build,omitempty"`
 
 	unknowns []string `json:"-"`
 }
@@ -266,3 +267,28 @@ func toSignatureKind(k policytypes.Kind) SignatureKind {
 type Local struct {
 	Name string `json:"name,omitempty"`
 }
+
+// Build describes the resolved options of a build. It is evaluated once
+// before the build starts by the policies that reference input.build.
+type Build struct {
+	Context      string             `json:"context,omitempty"`
+	Contexts     map[string]string  `json:"contexts,omitempty"`
+	Platforms    []string           `json:"platforms,omitempty"`
+	Tags         []string           `json:"tags,omitempty"`
+	Outputs      []BuildOutput      `json:"outputs,omitempty"`
+	Entitlements []string           `json:"entitlements,omitempty"`
+	Network      string             `json:"network,omitempty"`
+	Secrets      []string           `json:"secrets,omitempty"` // IDs only
+	SSH          []string           `json:"ssh,omitempty"`     // IDs only
+	Attestations []BuildAttestation `json:"attestations,omitempty"`
+}
+
+type BuildOutput struct {
+	Type  string            `json:"type"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+type BuildAttestation struct {
+	Type  string            `json:"type"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
@@ -69,6 +69,8 @@ type Opt struct {
 	VerifierProvider PolicyVerifierProvider
 	DefaultPlatform  *ocispecs.Platform
 	SourceResolver   *sourcemeta.Resolver
+	// Audit receives every decision made by the policy if set.
+	Audit *AuditLog
 }
 
 var _ policysession.PolicyCallback = (&Policy{}).CheckPolicy
@@ -344,6 +346,7 @@ func (p *Policy) CheckPolicy(ctx context.Context, req *policysession.CheckPolicy
 					return nil, nil, errors.Wrapf(err, "failed to add image pin to source")
 				}
 				p.log(logrus.InfoLevel, "policy decision for source %s: convert to %s", sourceName(req), newSrc.Identifier)
+				p.audit(runInput, req.Source.Source.Identifier, auditPlatform(platform), moby_buildkit_v1_sourcepolicy.PolicyAction_CONVERT.String(), newSrc.Identifier, nil)
 
 				return &policysession.DecisionResponse{
 					Action: moby_buildkit_v1_sourcepolicy.PolicyAction_CONVERT,
@@ -359,6 +362,7 @@ func (p *Policy) CheckPolicy(ctx context.Context, req *policysession.CheckPolicy
 		if resp.Action == moby_buildkit_v1_sourcepolicy.PolicyAction_DENY {
 			p.recordDenyIdentifier(req)
 		}
+		p.audit(runInput, req.Source.Source.Identifier, auditPlatform(platform), resp.Action.String(), "", decision.DenyMessages)
 		return resp, nil, nil
 	}
 
@@ -409,6 +413,102 @@ func (p *Policy) CheckCaps(ctx context.Context) (Caps, error) {
 	return decision.Caps, nil
 }
 
+// BuildDeniedError is returned by CheckBuild when a policy denies a build.
+type BuildDeniedError struct {
+	Messages []string
+}
+
+func (e *BuildDeniedError) Error() string {
+	if len(e.Messages) == 0 {
+		return "build not allowed by policy"
+	}
+	return "build not allowed by policy: " + strings.Join(e.Messages, ", ")
+}
+
+var buildInputRef = ast.MustParseRef("input.build")
+
+// CheckBuild evaluates the policy for the resolved options of a build before
+// it starts. Policies that don't reference input.build in their files allow
+// any build, so policies written for sources keep their behavior.
+func (p *Policy) CheckBuild(ctx context.Context, b *Build) error {
+	if !p.referencesBuild() {
+		return nil
+	}
+
+	baseOpts, closeRoot, err := p.regoBaseOpts()
+	if err != nil {
+		return err
+	}
+	defer closeRoot()
+
+	runInput := Input{Build: b}
+	applyEnvWithDepth(&runInput, p.opt.Env, 0)
+
+	runOpts := append([]func(*rego.Rego){}, baseOpts...)
+	runOpts = append(runOpts, rego.Input(runInput))
+
+	st := &state{Input: runInput}
+	for _, f := range p.funcs {
+		runOpts = append(runOpts, f.impl(st))
+	}
+
+	dt, err := json.MarshalIndent(runInput, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal policy input")
+	}
+	p.log(logrus.DebugLevel, "policy input: %s", dt)
+
+	rs, err := rego.New(runOpts...).Eval(ctx)
+	if err != nil {
+		return err
+	}
+	if refs := runtimeUnknownInputRefs(st); len(refs) > 0 || st.checksumNeededForSignature != nil {
+		return errors.Errorf("policy build request cannot resolve source metadata: %+v", summarizeUnknownsForLog(refs))
+	}
+
+	decision, err := policyDecisionFromResult(rs)
+	if err != nil {
+		return err
+	}
+	action := moby_buildkit_v1_sourcepolicy.PolicyAction_DENY
+	if decision.Allow != nil && *decision.Allow {
+		action = moby_buildkit_v1_sourcepolicy.PolicyAction_ALLOW
+	}
+
+	p.log(logrus.InfoLevel, "policy decision for build: %s", action)
+	for _, m := range decision.DenyMessages {
+		p.log(logrus.InfoLevel, " - %s", m)
+	}
+	p.audit(runInput, b.Context, strings.Join(b.Platforms, ","), action.String(), "", decision.DenyMessages)
+	if action == moby_buildkit_v1_sourcepolicy.PolicyAction_DENY {
+		return &BuildDeniedError{Messages: decision.DenyMessages}
+	}
+	return nil
+}
+
+// referencesBuild returns true if one of the policy files references
+// input.build.
+func (p *Policy) referencesBuild() bool {
+	for _, f := range p.opt.Files {
+		mod, err := ast.ParseModuleWithOpts(f.Filename, string(f.Data), ast.ParserOptions{
+			RegoVersion: ast.RegoV1,
+		})
+		if err != nil {
+			// reported when the policy is evaluated
+			return true
+		}
+		var found bool
+		ast.WalkRefs(mod, func(ref ast.Ref) bool {
+			found = found || ref.HasPrefix(buildInputRef)
+			return found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
 func policyDecisionFromResult(rs rego.ResultSet) (*Decision, error) {
 	if len(rs) == 0 {
 		return nil, errors.Errorf("policy returned zero result")
@@ -517,6 +617,13 @@ func platformFromReq(req *policysession.CheckPolicyRequest) (*ocispecs.Platform,
 	return nil, nil
 }
 
+func auditPlatform(p *ocispecs.Platform) string {
+	if p == nil {
+		return ""
+	}
+	return platforms.Format(*p)
+}
+
 func sourceName(req *policysession.CheckPolicyRequest) string {
 	name := req.Source.Source.Identifier
 	if p, _ := platformFromReq(req); p != nil {
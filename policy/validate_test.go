@@ -988,6 +988,119 @@ decision := {
 	require.ErrorContains(t, err, `unknown policy cap "exec.unknown"`)
 }
 
+func TestCheckBuild(t *testing.T) {
+	audit, err := NewAuditLog("")
+	require.NoError(t, err)
+	p := NewPolicy(Opt{
+		Files: []File{{
+			Filename: "policy.rego",
+			Data: []byte(`
+package docker
+
+default allow := false
+
+allow if input.image
+
+allow if {
+	input.build
+	count(build_deny_msg) == 0
+}
+
+build_deny_msg contains msg if {
+	input.build.network == "host"
+	msg := "host network is not allowed"
+}
+
+build_deny_msg contains msg if {
+	some out in input.build.outputs
+	out.attrs.push == "true"
+	some name in split(out.attrs.name, ",")
+	not startswith(name, "registry.example.com/")
+	msg := sprintf("push to %s is not allowed", [name])
+}
+
+build_deny_msg contains msg if {
+	some name, ctx in input.build.contexts
+	startswith(ctx, "docker-image://")
+	not contains(ctx, "@sha256:")
+	msg := sprintf("context %s must be pinned", [name])
+}
+
+build_deny_msg contains msg if {
+	input.env.target == "release"
+	not "sbom" in {a.type | some a in input.build.attestations}
+	msg := "release requires an sbom attestation"
+}
+
+decision := {"allow": allow, "deny_msg": build_deny_msg}
+`),
+		}},
+		Env:   Env{Target: "release"},
+		Audit: audit,
+	})
+
+	err = p.CheckBuild(t.Context(), &Build{
+		Context:   ".",
+		Platforms: []string{"linux/amd64"},
+		Contexts: map[string]string{
+			"alpine": "docker-image://alpine@sha256:1234567890123456789012345678901234567890123456789012345678901234",
+		},
+		Outputs: []BuildOutput{{
+			Type:  "image",
+			Attrs: map[string]string{"name": "registry.example.com/app:latest", "push": "true"},
+		}},
+		Attestations: []BuildAttestation{{Type: "sbom"}},
+	})
+	require.NoError(t, err)
+
+	err = p.CheckBuild(t.Context(), &Build{
+		Context: ".",
+		Network: "host",
+		Contexts: map[string]string{
+			"alpine": "docker-image://alpine:latest",
+		},
+		Outputs: []BuildOutput{{
+			Type:  "image",
+			Attrs: map[string]string{"name": "docker.io/user/app:latest", "push": "true"},
+		}},
+	})
+	var denied *BuildDeniedError
+	require.ErrorAs(t, err, &denied)
+	require.ElementsMatch(t, []string{
+		"host network is not allowed",
+		"push to docker.io/user/app:latest is not allowed",
+		"context alpine must be pinned",
+		"release requires an sbom attestation",
+	}, denied.Messages)
+
+	records := audit.Records()
+	require.Len(t, records, 2)
+	require.Equal(t, "build", records[0].Kind)
+	require.Equal(t, "ALLOW", records[0].Action)
+	require.Equal(t, "linux/amd64", records[0].Platform)
+	require.Equal(t, "DENY", records[1].Action)
+}
+
+func TestCheckBuildNotReferenced(t *testing.T) {
+	p := NewPolicy(Opt{
+		Files: []File{{
+			Filename: "policy.rego",
+			Data: []byte(`
+package docker
+
+default allow := false
+
+allow if input.image.repo == "alpine"
+
+decision := {"allow": allow}
+`),
+		}},
+	})
+
+	// policies for sources only don't deny builds
+	require.NoError(t, p.CheckBuild(t.Context(), &Build{Network: "host"}))
+}
+
 func mustMarshalImageConfig(t *testing.T, img ocispecs.Image) []byte {
 	t.Helper()
 	dt, err := json.Marshal(img)
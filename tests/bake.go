@@ -84,6 +84,7 @@ var bakeTests = []func(t *testing.T, sb integration.Sandbox){
 	testBakeListTargets,
 	testBakeListVariables,
 	testBakeListTypedVariables,
+	testBakeListGraph,
 	testBakeCallCheck,
 	testBakeCallCheckFlag,
 	testBakeCallMetadata,
@@ -2493,6 +2494,40 @@ target "default" {
 	require.Equal(t, "VARIABLE\tTYPE\tVALUE\tDESCRIPTION\nabc\t\t\t<null>\t\ndef\t\t\t\t\nfoo\t\t\tbar\tThis is foo", strings.TrimSpace(out))
 }
 
+func testBakeListGraph(t *testing.T, sb integration.Sandbox) {
+	bakefile := []byte(`
+group "default" {
+	targets = ["app"]
+}
+target "base" {
+}
+target "app" {
+	contexts = {
+		base = "target:base"
+	}
+}
+`)
+	dir := tmpdir(
+		t,
+		fstest.CreateFile("docker-bake.hcl", bakefile, 0600),
+	)
+
+	out, err := bakeCmd(
+		sb,
+		withDir(dir),
+		withArgs("--list=type=graph,format=json"),
+	)
+	require.NoError(t, err, out)
+
+	var g bake.Graph
+	require.NoError(t, json.Unmarshal([]byte(out), &g), out)
+	require.Len(t, g.Nodes, 3)
+	require.Equal(t, []bake.GraphEdge{
+		{From: "app", To: "base", Type: bake.GraphEdgeContext, Context: "base"},
+		{From: "default", To: "app", Type: bake.GraphEdgeGroup},
+	}, g.Edges)
+}
+
 func testBakeListTypedVariables(t *testing.T, sb integration.Sandbox) {
 	bakefile := []byte(`
 variable "abc" {
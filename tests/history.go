@@ -23,6 +23,7 @@ var historyTests = []func(t *testing.T, sb integration.Sandbox){
 	testHistoryExportFinalizeMultiNodeRef,
 	testHistoryExportFinalizeMultiNodeAll,
 	testHistoryInspect,
+	testHistoryDiff,
 	testHistoryLs,
 	testHistoryRm,
 	testHistoryLsStoppedBuilder,
@@ -113,6 +114,35 @@ func testHistoryInspect(t *testing.T, sb integration.Sandbox) {
 	require.NotEmpty(t, rec.Name)
 }
 
+func testHistoryDiff(t *testing.T, sb integration.Sandbox) {
+	ref1 := buildTestProject(t, sb)
+	require.NotEmpty(t, ref1.Ref)
+	ref2 := buildTestProject(t, sb, withArgs("--build-arg=FOO=bar"))
+	require.NotEmpty(t, ref2.Ref)
+
+	cmd := buildxCmd(sb, withArgs("history", "diff", ref1.Ref, ref2.Ref, "--format=json"))
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	type valueDiffT struct {
+		Name   string
+		Change string
+		Ref1   string
+		Ref2   string
+	}
+	type diffT struct {
+		Ref1      struct{ Ref string }
+		Ref2      struct{ Ref string }
+		BuildArgs []valueDiffT
+	}
+	var diff diffT
+	err = json.Unmarshal(out, &diff)
+	require.NoError(t, err)
+	require.Equal(t, ref1.Ref, diff.Ref1.Ref)
+	require.Equal(t, ref2.Ref, diff.Ref2.Ref)
+	require.Contains(t, diff.BuildArgs, valueDiffT{Name: "FOO", Change: "added", Ref2: "bar"})
+}
+
 func testHistoryLs(t *testing.T, sb integration.Sandbox) {
 	ref := buildTestProject(t, sb)
 	require.NotEmpty(t, ref.Ref)
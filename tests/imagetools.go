@@ -45,6 +45,7 @@ var imagetoolsTests = []func(t *testing.T, sb integration.Sandbox){
 	testImagetoolsMergeSourcesWithAttestations,
 	testImagetoolsMergeSourcesWithFallbackAttestations,
 	testImagetoolsCopyAttestationWithSignature,
+	testImagetoolsSync,
 }
 
 // testImagetoolsCopyManifest verifies create/inspect behavior for a single-platform image.
@@ -133,6 +134,61 @@ func testImagetoolsCopyManifest(t *testing.T, sb integration.Sandbox) {
 	require.Equal(t, defaultManifestMediaType(t, sb), mfst3.MediaType)
 }
 
+// testImagetoolsSync verifies that sync mirrors the selected tags and skips
+// tags that are already up to date.
+func testImagetoolsSync(t *testing.T, sb integration.Sandbox) {
+	if !isDockerContainerWorker(sb) {
+		t.Skip("only testing with docker-container worker, imagetools only runs on docker-container")
+	}
+
+	dir := createDockerfile(t)
+	registry, err := sb.NewRegistry()
+	if errors.Is(err, integration.ErrRequirements) {
+		t.Skip(err.Error())
+	}
+	require.NoError(t, err)
+	repo := registry + "/buildx/imtools-sync"
+
+	for _, tag := range []string{"1.0.0", "1.1.0", "2.0.0", "dev"} {
+		out, err := buildCmd(sb, withArgs("-t", repo+":"+tag, "--push", "--platform=linux/amd64", "--provenance=false", dir))
+		require.NoError(t, err, string(out))
+	}
+
+	mirror := registry + "/buildx/imtools-sync-mirror"
+	cfg := `repositories:
+  - source: ` + repo + `
+    destinations:
+      - ` + mirror + `
+    tags:
+      semver: "^1"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mirror.yaml"), []byte(cfg), 0o644))
+
+	cmd := buildxCmd(sb, withDir(dir), withArgs("imagetools", "sync", "-f", "mirror.yaml", "--dry-run"))
+	dt, err := cmd.Output()
+	require.NoError(t, err, string(dt))
+	require.Contains(t, string(dt), "2 to copy, 0 up-to-date, 0 failed")
+
+	cmd = buildxCmd(sb, withDir(dir), withArgs("imagetools", "sync", "-f", "mirror.yaml"))
+	dt, err = cmd.Output()
+	require.NoError(t, err, string(dt))
+	require.Contains(t, string(dt), "2 copied, 0 up-to-date, 0 failed")
+
+	for _, tag := range []string{"1.0.0", "1.1.0"} {
+		cmd = buildxCmd(sb, withArgs("imagetools", "inspect", mirror+":"+tag, "--raw"))
+		dt, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(dt))
+	}
+	cmd = buildxCmd(sb, withArgs("imagetools", "inspect", mirror+":2.0.0", "--raw"))
+	dt, err = cmd.CombinedOutput()
+	require.Error(t, err, string(dt))
+
+	cmd = buildxCmd(sb, withDir(dir), withArgs("imagetools", "sync", "-f", "mirror.yaml"))
+	dt, err = cmd.Output()
+	require.NoError(t, err, string(dt))
+	require.Contains(t, string(dt), "0 copied, 2 up-to-date, 0 failed")
+}
+
 // testImagetoolsCopyIndex verifies create/inspect behavior for a multi-platform index.
 func testImagetoolsCopyIndex(t *testing.T, sb integration.Sandbox) {
 	if !isDockerContainerWorker(sb) {
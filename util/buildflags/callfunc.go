@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/docker/buildx/util/lintreport"
 	"github.com/pkg/errors"
 	"github.com/tonistiigi/go-csvvalue"
 )
@@ -15,6 +16,9 @@ type CallFunc struct {
 	Name         string
 	Format       string
 	IgnoreStatus bool
+	// Output is the file the report of the sarif and junit formats is
+	// written to. The report is written to stdout if empty.
+	Output string
 }
 
 func (x *CallFunc) String() string {
@@ -28,6 +32,9 @@ func (x *CallFunc) String() string {
 	if x.IgnoreStatus {
 		elems = append(elems, fmt.Sprintf("IgnoreStatus:%v", x.IgnoreStatus))
 	}
+	if x.Output != "" {
+		elems = append(elems, fmt.Sprintf("Output:%q", x.Output))
+	}
 	return strings.Join(elems, " ")
 }
 
@@ -53,6 +60,8 @@ func ParseCallFunc(str string) (*CallFunc, error) {
 					return nil, errors.Wrapf(err, "invalid ignorestatus print value: %s", parts[1])
 				}
 				f.IgnoreStatus = v
+			case "output":
+				f.Output = parts[1]
 			default:
 				return nil, errors.Errorf("invalid print field: %s", field)
 			}
@@ -75,5 +84,12 @@ func ParseCallFunc(str string) (*CallFunc, error) {
 		return nil, nil
 	}
 
+	if lintreport.IsFormat(f.Format) && f.Name != "lint" {
+		return nil, errors.Errorf("%s format is only supported by check", f.Format)
+	}
+	if f.Output != "" && !lintreport.IsFormat(f.Format) {
+		return nil, errors.Errorf("output is only supported with %s and %s formats", lintreport.FormatSARIF, lintreport.FormatJUnit)
+	}
+
 	return f, nil
 }
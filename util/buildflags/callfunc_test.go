@@ -0,0 +1,31 @@
+package buildflags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCallFunc(t *testing.T) {
+	f, err := ParseCallFunc("build")
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	f, err = ParseCallFunc("check,format=json")
+	require.NoError(t, err)
+	require.Equal(t, &CallFunc{Name: "lint", Format: "json"}, f)
+
+	f, err = ParseCallFunc("check,format=sarif,output=reports/check.sarif")
+	require.NoError(t, err)
+	require.Equal(t, &CallFunc{Name: "lint", Format: "sarif", Output: "reports/check.sarif"}, f)
+
+	f, err = ParseCallFunc("check,format=junit")
+	require.NoError(t, err)
+	require.Equal(t, &CallFunc{Name: "lint", Format: "junit"}, f)
+
+	_, err = ParseCallFunc("outline,format=sarif")
+	require.ErrorContains(t, err, "sarif format is only supported by check")
+
+	_, err = ParseCallFunc("check,output=check.json")
+	require.ErrorContains(t, err, "output is only supported")
+}
@@ -16,6 +16,7 @@ type PolicyConfig struct {
 	Disabled bool
 	Strict   *bool
 	LogLevel *logrus.Level
+	AuditLog string
 }
 
 func ParsePolicyConfigs(in []string) ([]PolicyConfig, error) {
@@ -80,6 +81,11 @@ func parsePolicyFields(fields []string) (PolicyConfig, error) {
 				return PolicyConfig{}, errors.Wrapf(err, "invalid value %s", field)
 			}
 			cfg.LogLevel = &lvl
+		case "audit-log":
+			if value == "" {
+				return PolicyConfig{}, errors.Errorf("invalid value %s", field)
+			}
+			cfg.AuditLog = value
 		default:
 			return PolicyConfig{}, errors.Errorf("invalid value %s", field)
 		}
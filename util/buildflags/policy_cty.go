@@ -109,6 +109,9 @@ func (p PolicyConfig) ToCtyValue() cty.Value {
 	if p.LogLevel != nil {
 		vals["log-level"] = cty.StringVal(p.LogLevel.String())
 	}
+	if p.AuditLog != "" {
+		vals["audit-log"] = cty.StringVal(p.AuditLog)
+	}
 	if len(vals) == 0 {
 		return cty.MapValEmpty(cty.String)
 	}
@@ -22,6 +22,7 @@ func TestPolicyConfigs_FromCtyValue(t *testing.T) {
 			"reset":     cty.BoolVal(true),
 			"strict":    cty.BoolVal(true),
 			"log-level": cty.StringVal("warn"),
+			"audit-log": cty.StringVal("policy-audit.jsonl"),
 		}),
 		cty.StringVal("filename=" + policyPath + ",disabled=true"),
 	})
@@ -38,6 +39,7 @@ func TestPolicyConfigs_FromCtyValue(t *testing.T) {
 	require.True(t, *actual[0].Strict)
 	require.NotNil(t, actual[0].LogLevel)
 	require.Equal(t, logrus.WarnLevel, *actual[0].LogLevel)
+	require.Equal(t, "policy-audit.jsonl", actual[0].AuditLog)
 
 	require.Equal(t, policyPath, actual[1].Files[0].Filename)
 	require.Nil(t, actual[1].Files[0].Data)
@@ -57,6 +59,7 @@ func TestPolicyConfigs_ToCtyValue(t *testing.T) {
 			Disabled: true,
 			Strict:   &strict,
 			LogLevel: &lvl,
+			AuditLog: "audit.jsonl",
 		},
 	}
 
@@ -71,6 +74,7 @@ func TestPolicyConfigs_ToCtyValue(t *testing.T) {
 			"disabled":  cty.StringVal("true"),
 			"strict":    cty.StringVal("true"),
 			"log-level": cty.StringVal("info"),
+			"audit-log": cty.StringVal("audit.jsonl"),
 		}),
 	})
 
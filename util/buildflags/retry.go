@@ -0,0 +1,96 @@
+package buildflags
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tonistiigi/go-csvvalue"
+)
+
+const (
+	// RetryOnPush retries when pushing the result to a registry fails with a
+	// server error.
+	RetryOnPush = "push"
+	// RetryOnNetwork retries when the connection to the node is interrupted.
+	RetryOnNetwork = "network"
+	// RetryOnNode retries on another node supporting the same platforms when
+	// the node becomes unavailable.
+	RetryOnNode = "node"
+
+	defaultRetryAttempts = 3
+)
+
+var retryConditions = []string{RetryOnPush, RetryOnNetwork, RetryOnNode}
+
+// RetryPolicy configures how a build is retried after a transient failure.
+// Attempts is the total number of attempts, including the first one.
+type RetryPolicy struct {
+	Attempts int
+	On       []string
+}
+
+func (r *RetryPolicy) String() string {
+	if r == nil {
+		return ""
+	}
+	s := "attempts=" + strconv.Itoa(r.Attempts)
+	if len(r.On) > 0 {
+		s += ",on=" + strings.Join(r.On, "|")
+	}
+	return s
+}
+
+// Enabled reports whether the policy retries on the given condition.
+func (r *RetryPolicy) Enabled(on string) bool {
+	return r != nil && r.Attempts > 1 && slices.Contains(r.On, on)
+}
+
+// ParseRetryPolicy parses a retry policy in the form
+// "attempts=3,on=push|network|node". Both keys are optional. Attempts
+// defaults to 3 and all conditions are enabled if "on" is not set.
+func ParseRetryPolicy(str string) (*RetryPolicy, error) {
+	if str == "" {
+		return nil, nil
+	}
+	fields, err := csvvalue.Fields(str, nil)
+	if err != nil {
+		return nil, err
+	}
+	r := &RetryPolicy{Attempts: defaultRetryAttempts}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid retry field: %s", field)
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "attempts":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid retry attempts value: %s", value)
+			}
+			if n < 1 {
+				return nil, errors.Errorf("invalid retry attempts value %d, must be at least 1", n)
+			}
+			r.Attempts = n
+		case "on":
+			r.On = nil
+			for v := range strings.SplitSeq(value, "|") {
+				v = strings.TrimSpace(v)
+				if !slices.Contains(retryConditions, v) {
+					return nil, errors.Errorf("invalid retry condition %q, expected one of %s", v, strings.Join(retryConditions, ", "))
+				}
+				if !slices.Contains(r.On, v) {
+					r.On = append(r.On, v)
+				}
+			}
+		default:
+			return nil, errors.Errorf("invalid retry field: %s", field)
+		}
+	}
+	if r.On == nil {
+		r.On = slices.Clone(retryConditions)
+	}
+	return r, nil
+}
@@ -0,0 +1,75 @@
+package buildflags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryPolicy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected *RetryPolicy
+		err      string
+	}{
+		{
+			input: "",
+		},
+		{
+			input:    "attempts=5",
+			expected: &RetryPolicy{Attempts: 5, On: []string{RetryOnPush, RetryOnNetwork, RetryOnNode}},
+		},
+		{
+			input:    "on=push",
+			expected: &RetryPolicy{Attempts: 3, On: []string{RetryOnPush}},
+		},
+		{
+			input:    "attempts=2,on=network|node|network",
+			expected: &RetryPolicy{Attempts: 2, On: []string{RetryOnNetwork, RetryOnNode}},
+		},
+		{
+			input: "attempts=0",
+			err:   "must be at least 1",
+		},
+		{
+			input: "attempts=abc",
+			err:   "invalid retry attempts value",
+		},
+		{
+			input: "on=push|disk",
+			err:   `invalid retry condition "disk"`,
+		},
+		{
+			input: "delay=1s",
+			err:   "invalid retry field",
+		},
+		{
+			input: "3",
+			err:   "invalid retry field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := ParseRetryPolicy(tt.input)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, r)
+		})
+	}
+}
+
+func TestRetryPolicyEnabled(t *testing.T) {
+	var r *RetryPolicy
+	require.False(t, r.Enabled(RetryOnPush))
+
+	r = &RetryPolicy{Attempts: 1, On: []string{RetryOnPush}}
+	require.False(t, r.Enabled(RetryOnPush))
+
+	r.Attempts = 2
+	require.True(t, r.Enabled(RetryOnPush))
+	require.False(t, r.Enabled(RetryOnNode))
+	require.Equal(t, "attempts=2,on=push", r.String())
+}
@@ -29,14 +29,38 @@ func (s Secrets) Normalize() Secrets {
 	return removeSecretDupes(s)
 }
 
+const (
+	SecretTypeFile = "file"
+	SecretTypeEnv  = "env"
+	// SecretTypeCmd runs a local command and uses its stdout as the secret.
+	SecretTypeCmd = "cmd"
+	// SecretTypeDockerCredential reads the secret from the docker credentials
+	// of a registry host.
+	SecretTypeDockerCredential = "docker-credential"
+	// SecretTypeFileStore reads the secret from an encrypted local store.
+	SecretTypeFileStore = "file-store"
+)
+
+// Secret is a secret exposed to the build. File and env secrets leave Type
+// empty, other types are resolved by a provider when the build requests the
+// secret.
 type Secret struct {
 	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
 	FilePath string `json:"src,omitempty"`
 	Env      string `json:"env,omitempty"`
+	Cmd      string `json:"cmd,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Key      string `json:"key,omitempty"`
+
+	// Value is the value of an env secret set by the caller instead of
+	// being read from the environment. It's never serialized.
+	Value string `json:"-"`
 }
 
 func (s *Secret) Equal(other *Secret) bool {
-	return s.ID == other.ID && s.FilePath == other.FilePath && s.Env == other.Env
+	return *s == *other
 }
 
 func (s *Secret) String() string {
@@ -44,29 +68,77 @@ func (s *Secret) String() string {
 	if s.ID != "" {
 		b.Write("id", s.ID)
 	}
+	if s.Type != "" {
+		b.Write("type", s.Type)
+	}
 	if s.FilePath != "" {
 		b.Write("src", s.FilePath)
 	}
 	if s.Env != "" {
 		b.Write("env", s.Env)
 	}
+	if s.Cmd != "" {
+		b.Write("cmd", s.Cmd)
+	}
+	if s.Host != "" {
+		b.Write("host", s.Host)
+	}
+	if s.Field != "" {
+		b.Write("field", s.Field)
+	}
+	if s.Key != "" {
+		b.Write("key", s.Key)
+	}
 	return b.String()
 }
 
-func (s *Secret) UnmarshalJSON(data []byte) error {
-	var v struct {
-		ID       string `json:"id,omitempty"`
-		FilePath string `json:"src,omitempty"`
-		Env      string `json:"env,omitempty"`
+// Validate checks that the attributes of the secret match its type.
+func (s *Secret) Validate() error {
+	switch s.Type {
+	case "":
+		if s.Cmd != "" || s.Host != "" || s.Field != "" || s.Key != "" {
+			return errors.Errorf("secret %q: cmd, host, field and key require a secret type", s.ID)
+		}
+	case SecretTypeCmd:
+		if s.Cmd == "" {
+			return errors.Errorf("secret %q: type=cmd requires cmd", s.ID)
+		}
+		if s.FilePath != "" || s.Env != "" || s.Host != "" || s.Field != "" || s.Key != "" {
+			return errors.Errorf("secret %q: type=cmd only accepts cmd", s.ID)
+		}
+	case SecretTypeDockerCredential:
+		if s.Host == "" {
+			return errors.Errorf("secret %q: type=docker-credential requires host", s.ID)
+		}
+		switch s.Field {
+		case "", "username", "password":
+		default:
+			return errors.Errorf("secret %q: invalid docker-credential field %q, expected username or password", s.ID, s.Field)
+		}
+		if s.FilePath != "" || s.Env != "" || s.Cmd != "" || s.Key != "" {
+			return errors.Errorf("secret %q: type=docker-credential only accepts host and field", s.ID)
+		}
+	case SecretTypeFileStore:
+		if s.FilePath == "" {
+			return errors.Errorf("secret %q: type=file-store requires src", s.ID)
+		}
+		if s.Env != "" || s.Cmd != "" || s.Host != "" || s.Field != "" {
+			return errors.Errorf("secret %q: type=file-store only accepts src and key", s.ID)
+		}
+	default:
+		return errors.Errorf("unsupported secret type %q", s.Type)
 	}
+	return nil
+}
+
+func (s *Secret) UnmarshalJSON(data []byte) error {
+	type alias Secret
+	var v alias
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
-
-	s.ID = v.ID
-	s.FilePath = v.FilePath
-	s.Env = v.Env
-	return nil
+	*s = Secret(v)
+	return s.Validate()
 }
 
 func (s *Secret) UnmarshalText(text []byte) error {
@@ -90,7 +162,11 @@ func (s *Secret) UnmarshalText(text []byte) error {
 		value := parts[1]
 		switch key {
 		case "type":
-			if value != "file" && value != "env" {
+			switch value {
+			case SecretTypeFile, SecretTypeEnv:
+			case SecretTypeCmd, SecretTypeDockerCredential, SecretTypeFileStore:
+				s.Type = value
+			default:
 				return errors.Errorf("unsupported secret type %q", value)
 			}
 			typ = value
@@ -100,15 +176,23 @@ func (s *Secret) UnmarshalText(text []byte) error {
 			s.FilePath = value
 		case "env":
 			s.Env = value
+		case "cmd":
+			s.Cmd = value
+		case "host":
+			s.Host = value
+		case "field":
+			s.Field = value
+		case "key":
+			s.Key = value
 		default:
 			return errors.Errorf("unexpected key '%s' in '%s'", key, field)
 		}
 	}
-	if typ == "env" && s.Env == "" {
+	if typ == SecretTypeEnv && s.Env == "" {
 		s.Env = s.FilePath
 		s.FilePath = ""
 	}
-	return nil
+	return s.Validate()
 }
 
 func ParseSecretSpecs(sl []string) (Secrets, error) {
@@ -10,11 +10,16 @@ import (
 var secretType = sync.OnceValue(func() cty.Type {
 	return cty.ObjectWithOptionalAttrs(
 		map[string]cty.Type{
-			"id":  cty.String,
-			"src": cty.String,
-			"env": cty.String,
+			"id":    cty.String,
+			"type":  cty.String,
+			"src":   cty.String,
+			"env":   cty.String,
+			"cmd":   cty.String,
+			"host":  cty.String,
+			"field": cty.String,
+			"key":   cty.String,
 		},
-		[]string{"id", "src", "env"},
+		[]string{"id", "type", "src", "env", "cmd", "host", "field", "key"},
 	)
 })
 
@@ -71,12 +76,34 @@ func (e *Secret) FromCtyValue(in cty.Value, p cty.Path) error {
 	if id := conv.GetAttr("id"); !id.IsNull() && id.IsKnown() {
 		e.ID = id.AsString()
 	}
+	if typ := conv.GetAttr("type"); !typ.IsNull() && typ.IsKnown() {
+		switch v := typ.AsString(); v {
+		case SecretTypeFile, SecretTypeEnv:
+		default:
+			e.Type = v
+		}
+	}
 	if src := conv.GetAttr("src"); !src.IsNull() && src.IsKnown() {
 		e.FilePath = src.AsString()
 	}
 	if env := conv.GetAttr("env"); !env.IsNull() && env.IsKnown() {
 		e.Env = env.AsString()
 	}
+	if cmd := conv.GetAttr("cmd"); !cmd.IsNull() && cmd.IsKnown() {
+		e.Cmd = cmd.AsString()
+	}
+	if host := conv.GetAttr("host"); !host.IsNull() && host.IsKnown() {
+		e.Host = host.AsString()
+	}
+	if field := conv.GetAttr("field"); !field.IsNull() && field.IsKnown() {
+		e.Field = field.AsString()
+	}
+	if key := conv.GetAttr("key"); !key.IsNull() && key.IsKnown() {
+		e.Key = key.AsString()
+	}
+	if err := e.Validate(); err != nil {
+		return p.NewError(err)
+	}
 	return nil
 }
 
@@ -86,8 +113,13 @@ func (e *Secret) ToCtyValue() cty.Value {
 	}
 
 	return cty.ObjectVal(map[string]cty.Value{
-		"id":  cty.StringVal(e.ID),
-		"src": cty.StringVal(e.FilePath),
-		"env": cty.StringVal(e.Env),
+		"id":    cty.StringVal(e.ID),
+		"type":  cty.StringVal(e.Type),
+		"src":   cty.StringVal(e.FilePath),
+		"env":   cty.StringVal(e.Env),
+		"cmd":   cty.StringVal(e.Cmd),
+		"host":  cty.StringVal(e.Host),
+		"field": cty.StringVal(e.Field),
+		"key":   cty.StringVal(e.Key),
 	})
 }
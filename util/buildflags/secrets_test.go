@@ -67,14 +67,24 @@ func TestSecrets(t *testing.T) {
 		actual := secrets.ToCtyValue()
 		expected := cty.ListVal([]cty.Value{
 			cty.ObjectVal(map[string]cty.Value{
-				"id":  cty.StringVal("mysecret"),
-				"src": cty.StringVal("/local/secret"),
-				"env": cty.StringVal(""),
+				"id":    cty.StringVal("mysecret"),
+				"type":  cty.StringVal(""),
+				"src":   cty.StringVal("/local/secret"),
+				"env":   cty.StringVal(""),
+				"cmd":   cty.StringVal(""),
+				"host":  cty.StringVal(""),
+				"field": cty.StringVal(""),
+				"key":   cty.StringVal(""),
 			}),
 			cty.ObjectVal(map[string]cty.Value{
-				"id":  cty.StringVal("mysecret2"),
-				"src": cty.StringVal(""),
-				"env": cty.StringVal("TOKEN"),
+				"id":    cty.StringVal("mysecret2"),
+				"type":  cty.StringVal(""),
+				"src":   cty.StringVal(""),
+				"env":   cty.StringVal("TOKEN"),
+				"cmd":   cty.StringVal(""),
+				"host":  cty.StringVal(""),
+				"field": cty.StringVal(""),
+				"key":   cty.StringVal(""),
 			}),
 		})
 
@@ -94,4 +104,34 @@ func TestSecrets(t *testing.T) {
 		require.NoError(t, err)
 		require.JSONEq(t, expected, string(actual))
 	})
+
+	t.Run("Providers", func(t *testing.T) {
+		secrets, err := ParseSecretSpecs([]string{
+			"id=npm,type=cmd,cmd=pass show npm/token",
+			"id=ghcr,type=docker-credential,host=ghcr.io",
+			"id=ghcr_user,type=docker-credential,host=ghcr.io,field=username",
+			"id=aws,type=file-store,src=secrets.enc,key=aws_key",
+		})
+		require.NoError(t, err)
+		require.Equal(t, Secrets{
+			{ID: "npm", Type: SecretTypeCmd, Cmd: "pass show npm/token"},
+			{ID: "ghcr", Type: SecretTypeDockerCredential, Host: "ghcr.io"},
+			{ID: "ghcr_user", Type: SecretTypeDockerCredential, Host: "ghcr.io", Field: "username"},
+			{ID: "aws", Type: SecretTypeFileStore, FilePath: "secrets.enc", Key: "aws_key"},
+		}, secrets)
+		require.Equal(t, "id=npm,type=cmd,cmd=pass show npm/token", secrets[0].String())
+
+		for _, in := range []string{
+			"id=npm,type=cmd",
+			"id=npm,type=cmd,cmd=true,src=file",
+			"id=ghcr,type=docker-credential",
+			"id=ghcr,type=docker-credential,host=ghcr.io,field=token",
+			"id=aws,type=file-store,key=aws_key",
+			"id=foo,cmd=true",
+			"id=foo,type=vault",
+		} {
+			_, err := ParseSecretSpecs([]string{in})
+			require.Error(t, err, in)
+		}
+	})
 }
@@ -103,6 +103,38 @@ func (cli *GitCLI) Tag(ctx context.Context) (string, error) {
 	return tag, err
 }
 
+// ChangedFiles returns the absolute paths of the files of the work tree that
+// changed since ref, including uncommitted and untracked files. Paths are
+// relative to the work tree root with symlinks resolved.
+func (cli *GitCLI) ChangedFiles(ctx context.Context, ref string) ([]string, error) {
+	root, err := cli.WorkTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, err
+	}
+	diff, err := cli.Run(ctx, "diff", "--name-only", "--no-renames", "-z", ref, "--")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list files changed since %s", ref)
+	}
+	// ls-files only lists the files of the current directory, run it from
+	// the root to list all untracked files of the work tree
+	untracked, err := cli.GitCLI.New(bkgitutil.WithDir(root)).Run(ctx, "ls-files", "--others", "--exclude-standard", "--full-name", "-z")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list untracked files")
+	}
+	var files []string
+	for _, dt := range [][]byte{diff, untracked} {
+		for name := range strings.SplitSeq(string(dt), "\x00") {
+			if name != "" {
+				files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+			}
+		}
+	}
+	return files, nil
+}
+
 func (cli *GitCLI) clean(dt []byte, err error) (string, error) {
 	out := string(dt)
 	out = strings.ReplaceAll(strings.Split(out, "\n")[0], "'", "")
@@ -2,6 +2,8 @@ package gitutil_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -238,3 +240,52 @@ func TestGitRemoteURL(t *testing.T) {
 		})
 	}
 }
+
+func TestGitChangedFiles(t *testing.T) {
+	ctx := context.TODO()
+	dir := gittestutil.Mktmp(t)
+	c, err := gitutil.New()
+	require.NoError(t, err)
+
+	gittestutil.GitInit(c, t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "app"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "main.go"), []byte("package main"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o644))
+	gittestutil.GitAdd(c, t, ".")
+	gittestutil.GitCommit(c, t, "initial")
+	gittestutil.GitTag(c, t, "v1")
+
+	files, err := c.ChangedFiles(ctx, "v1")
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	root, err := c.WorkTree(ctx)
+	require.NoError(t, err)
+	root, err = filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "main.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "new.go"), []byte("package main"), 0o644))
+	files, err = c.ChangedFiles(ctx, "v1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		filepath.Join(root, "app", "main.go"),
+		filepath.Join(root, "app", "new.go"),
+	}, files)
+
+	// untracked files outside of the current directory are listed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "NEW.md"), []byte("new"), 0o644))
+	t.Chdir(filepath.Join(dir, "app"))
+	c, err = gitutil.New()
+	require.NoError(t, err)
+	files, err = c.ChangedFiles(ctx, "v1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		filepath.Join(root, "NEW.md"),
+		filepath.Join(root, "app", "main.go"),
+		filepath.Join(root, "app", "new.go"),
+	}, files)
+
+	_, err = c.ChangedFiles(ctx, "v2")
+	require.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package history
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// CacheBuild is a build history record used to attribute cache records to
+// the build that produced them.
+type CacheBuild struct {
+	// Target is the name of the bake target or build
+	Target string
+	// Repository is the source repository of the build, if known
+	Repository string
+	// Vertices are the names of the vertices of the build
+	Vertices []string
+	// Digests are the digests of the materials resolved by the build
+	Digests []digest.Digest
+}
+
+const (
+	// NoOwner is the owner of cache records not attributed to any build.
+	NoOwner = -1
+	// SharedOwner is the owner of cache records used by builds of different
+	// targets.
+	SharedOwner = -2
+)
+
+// AttributeCache returns the index in builds of the build owning each cache
+// record, NoOwner if no build matches it or SharedOwner if builds of
+// different targets match it. Builds should be ordered from the most recent
+// one so records used by several builds of the same target are attributed to
+// the last of them. Pulled records are matched by digest and exec records by
+// the command of a RUN step. Records that can't be matched inherit the owner
+// of their parent, which also picks the owner of shared records a parent was
+// attributed to.
+func AttributeCache(records []*client.UsageInfo, builds []CacheBuild) []int {
+	owners := make([]int, len(records))
+	matches := make([][]int, len(records))
+	byID := make(map[string]int, len(records))
+	for i, r := range records {
+		matches[i] = matchBuilds(r.Description, builds)
+		owners[i] = resolveOwner(matches[i], builds)
+		byID[r.ID] = i
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, r := range records {
+			if owners[i] >= 0 {
+				continue
+			}
+			for _, p := range r.Parents {
+				j, ok := byID[p]
+				if !ok || owners[j] < 0 {
+					continue
+				}
+				if owners[i] == NoOwner {
+					owners[i] = owners[j]
+					changed = true
+					break
+				}
+				// shared record, keep the match for the target of the parent
+				if k := slices.IndexFunc(matches[i], func(k int) bool {
+					return builds[k].Target == builds[owners[j]].Target
+				}); k != -1 {
+					owners[i] = matches[i][k]
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return owners
+}
+
+// CacheRepository returns the familiar name of the image repository a cache
+// record was pulled from, or an empty string if the record was not pulled.
+func CacheRepository(description string) string {
+	ref, ok := strings.CutPrefix(description, "pulled from ")
+	if !ok {
+		return ""
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	return reference.FamiliarName(named)
+}
+
+func matchBuilds(description string, builds []CacheBuild) []int {
+	if description == "" {
+		return nil
+	}
+	var out []int
+	for i, b := range builds {
+		if matchBuild(description, b) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// resolveOwner returns the most recent of the matching builds if they all
+// build the same target.
+func resolveOwner(matches []int, builds []CacheBuild) int {
+	if len(matches) == 0 {
+		return NoOwner
+	}
+	for _, i := range matches[1:] {
+		if builds[i].Target != builds[matches[0]].Target {
+			return SharedOwner
+		}
+	}
+	return matches[0]
+}
+
+// matchBuild reports whether a cache record with the given description was
+// produced by a build. Descriptions are set by BuildKit when the record is
+// created, e.g. "mount / from exec /bin/sh -c make" for the vertex
+// "[build 2/3] RUN make" or "pulled from docker.io/library/alpine@sha256:..."
+// for an image pinned in the materials of the build. Generic descriptions
+// such as "local source for context" can't be told apart between builds and
+// are never matched.
+func matchBuild(description string, b CacheBuild) bool {
+	switch {
+	case strings.Contains(description, " from exec "):
+		_, args, _ := strings.Cut(description, " from exec ")
+		for _, v := range b.Vertices {
+			_, cmd, ok := strings.Cut(v, "] RUN ")
+			if !ok {
+				continue
+			}
+			// the command must match whole words, so "RUN make" doesn't
+			// match a record of "/bin/sh -c cmake"
+			if cmd = trimFlags(cmd); cmd != "" && (args == cmd || strings.HasSuffix(args, " "+cmd)) {
+				return true
+			}
+		}
+	case strings.HasPrefix(description, "pulled from "):
+		ref := strings.TrimPrefix(description, "pulled from ")
+		named, err := reference.ParseNormalizedNamed(ref)
+		if err != nil {
+			return false
+		}
+		canonical, ok := named.(reference.Canonical)
+		if !ok {
+			return false
+		}
+		dgst := canonical.Digest()
+		if slices.Contains(b.Digests, dgst) {
+			return true
+		}
+		for _, v := range b.Vertices {
+			if strings.Contains(v, "@"+dgst.String()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trimFlags removes the leading instruction flags from a RUN command, e.g.
+// "--mount=type=cache,target=/root/.cache go build" returns "go build".
+func trimFlags(cmd string) string {
+	for strings.HasPrefix(cmd, "--") {
+		_, rest, ok := strings.Cut(cmd, " ")
+		if !ok {
+			return ""
+		}
+		cmd = strings.TrimLeft(rest, " ")
+	}
+	return cmd
+}
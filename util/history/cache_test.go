@@ -0,0 +1,93 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeCache(t *testing.T) {
+	builds := []CacheBuild{
+		{
+			Target: "frontend-tests",
+			Vertices: []string{
+				"[internal] load build definition from Dockerfile",
+				"[internal] load metadata for docker.io/library/node:22",
+				"[test 1/3] FROM docker.io/library/node:22@sha256:6ba9f1a9a8e4d8dbe5e1a5f3b6f2d3a3b1a4c5b3e5a7c9b1d3f5a7c9b1d3f5a7",
+				"[test 2/3] RUN --mount=type=cache,target=/root/.npm npm ci",
+				"[test 3/3] RUN npm test",
+			},
+		},
+		{
+			Target: "backend",
+			Vertices: []string{
+				"[internal] load build context",
+				"[build 1/2] FROM docker.io/library/golang:1.23",
+				"[build 2/2] RUN go build -o /out/app ./cmd/app",
+			},
+		},
+		{
+			Target: "frontend-lint",
+			Vertices: []string{
+				"[internal] load build context",
+				"[lint 1/3] FROM docker.io/library/node:22",
+				"[lint 2/3] RUN npm ci",
+				"[lint 3/3] RUN npm run lint",
+			},
+			Digests: []digest.Digest{"sha256:6ba9f1a9a8e4d8dbe5e1a5f3b6f2d3a3b1a4c5b3e5a7c9b1d3f5a7c9b1d3f5a7"},
+		},
+	}
+	records := []*client.UsageInfo{
+		{ID: "node-layer", Description: "pulled from docker.io/library/node:22@sha256:6ba9f1a9a8e4d8dbe5e1a5f3b6f2d3a3b1a4c5b3e5a7c9b1d3f5a7c9b1d3f5a7"},
+		{ID: "npm-ci", Description: "mount / from exec /bin/sh -c npm ci", Parents: []string{"node-layer"}},
+		{ID: "npm-cache", Description: "cached mount /root/.npm from exec /bin/sh -c npm ci"},
+		{ID: "npm-test", Description: "mount / from exec /bin/sh -c npm test", Parents: []string{"npm-ci"}},
+		{ID: "go-build", Description: "mount / from exec /bin/sh -c go build -o /out/app ./cmd/app"},
+		{ID: "go-build-child", Parents: []string{"go-build"}},
+		{ID: "context", Description: "local source for context"},
+		{ID: "unknown", Description: "pulled from docker.io/library/alpine:latest"},
+		{ID: "npm-ci-after-test", Description: "mount / from exec /bin/sh -c npm ci", Parents: []string{"npm-test"}},
+	}
+
+	owners := AttributeCache(records, builds)
+	require.Equal(t, []int{
+		SharedOwner, // pinned in the materials of both frontend targets
+		SharedOwner,
+		SharedOwner,
+		0,
+		1,
+		1,
+		NoOwner, // generic description
+		NoOwner, // no digest
+		0,       // parent attributed to frontend-tests
+	}, owners)
+}
+
+func TestMatchBuild(t *testing.T) {
+	b := CacheBuild{
+		Target: "app",
+		Vertices: []string{
+			"[build 2/3] RUN make",
+		},
+	}
+	require.True(t, matchBuild("mount / from exec /bin/sh -c make", b))
+	require.True(t, matchBuild("mount / from exec make", b))
+	// commands only match whole words
+	require.False(t, matchBuild("mount / from exec /bin/sh -c cmake", b))
+	require.False(t, matchBuild("mount / from exec /bin/sh -c make install", b))
+}
+
+func TestCacheRepository(t *testing.T) {
+	require.Equal(t, "alpine", CacheRepository("pulled from docker.io/library/alpine:latest@sha256:1e42bbe2508154c9126d48c2b8a75420c3544343bf86fd041fb7527e017a4b4a"))
+	require.Equal(t, "ghcr.io/org/app", CacheRepository("pulled from ghcr.io/org/app:v1"))
+	require.Empty(t, CacheRepository("mount / from exec /bin/sh -c make"))
+	require.Empty(t, CacheRepository("pulled from :invalid"))
+}
+
+func TestTrimFlags(t *testing.T) {
+	require.Equal(t, "go build", trimFlags("go build"))
+	require.Equal(t, "go build", trimFlags("--mount=type=cache,target=/root/.cache --network=none go build"))
+	require.Empty(t, trimFlags("--mount=type=cache,target=/root/.cache"))
+}
@@ -0,0 +1,68 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	provenancetypes "github.com/moby/buildkit/solver/llbsolver/provenance/types"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// MaterialDigests returns the digests of the materials resolved by a build,
+// read from the provenance attestations of its build history record.
+func MaterialDigests(ctx context.Context, store content.Provider, rec *controlapi.BuildHistoryRecord) ([]digest.Digest, error) {
+	var descs []*controlapi.Descriptor
+	if rec.Result != nil {
+		descs = append(descs, rec.Result.Attestations...)
+	}
+	for _, ri := range rec.Results {
+		descs = append(descs, ri.Attestations...)
+	}
+
+	var out []digest.Digest
+	for _, d := range descs {
+		predType := d.Annotations["in-toto.io/predicate-type"]
+		if !strings.HasPrefix(predType, "https://slsa.dev/provenance/") {
+			continue
+		}
+		dt, err := content.ReadBlob(ctx, store, ocispecs.Descriptor{
+			MediaType: d.MediaType,
+			Digest:    digest.Digest(d.Digest),
+			Size:      d.Size,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read provenance %s", d.Digest)
+		}
+		var pred *provenancetypes.ProvenancePredicateSLSA1
+		if predType == slsa02.PredicateSLSAProvenance {
+			var pred02 *provenancetypes.ProvenancePredicateSLSA02
+			if err := json.Unmarshal(dt, &pred02); err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal provenance %s", d.Digest)
+			}
+			if pred02 != nil {
+				pred = pred02.ConvertToSLSA1()
+			}
+		} else if err := json.Unmarshal(dt, &pred); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal provenance %s", d.Digest)
+		}
+		if pred == nil {
+			continue
+		}
+		for _, m := range pred.BuildDefinition.ResolvedDependencies {
+			for alg, hash := range m.Digest {
+				dgst := digest.NewDigestFromEncoded(digest.Algorithm(alg), hash)
+				if dgst.Validate() == nil && !slices.Contains(out, dgst) {
+					out = append(out, dgst)
+				}
+			}
+		}
+	}
+	return out, nil
+}
@@ -283,17 +283,7 @@ func (r *Resolver) CopyWithIngester(ctx context.Context, src *Source, dest *Loca
 	}
 
 	referrers := &referrersProvider{base: referrersFunc(func(ctx context.Context, subject ocispecs.Descriptor) ([]ocispecs.Descriptor, error) {
-		descs, err := r.FetchReferrers(ctx, src.Ref, subject.Digest)
-		if err != nil {
-			return nil, err
-		}
-		var filtered []ocispecs.Descriptor
-		for _, d := range descs {
-			if _, ok := supportedArtifactTypes[d.ArtifactType]; ok {
-				filtered = append(filtered, d)
-			}
-		}
-		return filtered, nil
+		return r.supportedReferrers(ctx, src.Ref, subject.Digest)
 	})}
 
 	err = contentutil.CopyChain(ctx, ingester, provider, desc, contentutil.WithReferrers(referrers))
@@ -308,6 +298,22 @@ func (r *Resolver) CopyWithIngester(ctx context.Context, src *Source, dest *Loca
 	return nil
 }
 
+// supportedReferrers returns the referrers of a subject with an artifact
+// type that is copied along with it.
+func (r *Resolver) supportedReferrers(ctx context.Context, loc *Location, subject digest.Digest) ([]ocispecs.Descriptor, error) {
+	descs, err := r.FetchReferrers(ctx, loc, subject)
+	if err != nil {
+		return nil, err
+	}
+	var filtered []ocispecs.Descriptor
+	for _, d := range descs {
+		if _, ok := supportedArtifactTypes[d.ArtifactType]; ok {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered, nil
+}
+
 func (r *Resolver) loadPlatform(ctx context.Context, loc *Location, p2 *ocispecs.Platform, dt []byte) error {
 	var manifest ocispecs.Manifest
 	if err := json.Unmarshal(dt, &manifest); err != nil {
@@ -0,0 +1,468 @@
+package imagetools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+// Diff holds the differences between two images.
+type Diff struct {
+	From        string         `json:"from"`
+	To          string         `json:"to"`
+	Digest      *Change        `json:"digest,omitempty"`
+	Annotations []Change       `json:"annotations,omitempty"`
+	Platforms   []PlatformDiff `json:"platforms"`
+}
+
+// PlatformDiff holds the differences between the images of a platform.
+type PlatformDiff struct {
+	Platform string `json:"platform"`
+	// Status is one of "added", "removed", "changed" or "unchanged".
+	Status      string       `json:"status"`
+	Manifest    *Change      `json:"manifest,omitempty"`
+	Config      []Change     `json:"config,omitempty"`
+	Layers      []LayerDiff  `json:"layers,omitempty"`
+	Annotations []Change     `json:"annotations,omitempty"`
+	SBOM        *ListChanges `json:"sbom,omitempty"`
+	Provenance  *ListChanges `json:"provenance,omitempty"`
+}
+
+// Change is a value that differs between two images. Old is empty if the
+// value was added and New is empty if it was removed.
+type Change struct {
+	Field string `json:"field,omitempty"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// LayerDiff is a layer that differs between two images.
+type LayerDiff struct {
+	Index int    `json:"index"`
+	Old   *Layer `json:"old,omitempty"`
+	New   *Layer `json:"new,omitempty"`
+}
+
+// Layer describes a layer of an image.
+type Layer struct {
+	Digest    digest.Digest `json:"digest"`
+	Size      int64         `json:"size"`
+	MediaType string        `json:"mediaType,omitempty"`
+}
+
+// ListChanges holds the entries added to and removed from a list, like the
+// packages of an SBOM or the materials of a provenance attestation.
+type ListChanges struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+const (
+	diffAdded     = "added"
+	diffRemoved   = "removed"
+	diffChanged   = "changed"
+	diffUnchanged = "unchanged"
+)
+
+// Diff compares the images from and to.
+func (r *Resolver) Diff(ctx context.Context, from, to string) (*Diff, error) {
+	var fromRes, toRes *result
+	var fromDesc, toDesc ocispecs.Descriptor
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		fromDesc, fromRes, err = r.load(ctx, from)
+		return err
+	})
+	eg.Go(func() (err error) {
+		toDesc, toRes, err = r.load(ctx, to)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	d, err := diffResults(fromRes, toRes)
+	if err != nil {
+		return nil, err
+	}
+	d.From, d.To = from, to
+	if fromDesc.Digest != toDesc.Digest {
+		d.Digest = &Change{Old: fromDesc.Digest.String(), New: toDesc.Digest.String()}
+	}
+	return d, nil
+}
+
+func (r *Resolver) load(ctx context.Context, name string) (ocispecs.Descriptor, *result, error) {
+	loc, err := ParseLocation(name)
+	if err != nil {
+		return ocispecs.Descriptor{}, nil, err
+	}
+	_, desc, err := r.Resolve(ctx, loc.String())
+	if err != nil {
+		return ocispecs.Descriptor{}, nil, err
+	}
+	res, err := newLoader(r).Load(ctx, name)
+	if err != nil {
+		return ocispecs.Descriptor{}, nil, err
+	}
+	return desc, res, nil
+}
+
+// Changed reports whether the images differ.
+func (d *Diff) Changed() bool {
+	if d.Digest != nil || len(d.Annotations) > 0 {
+		return true
+	}
+	return slices.ContainsFunc(d.Platforms, func(p PlatformDiff) bool {
+		return p.Status != diffUnchanged
+	})
+}
+
+func diffResults(from, to *result) (*Diff, error) {
+	d := &Diff{
+		Annotations: diffMaps("", indexAnnotations(from), indexAnnotations(to)),
+	}
+
+	fromSBOM, err := from.SBOM()
+	if err != nil {
+		return nil, err
+	}
+	toSBOM, err := to.SBOM()
+	if err != nil {
+		return nil, err
+	}
+	fromProvenance, err := from.Provenance()
+	if err != nil {
+		return nil, err
+	}
+	toProvenance, err := to.Provenance()
+	if err != nil {
+		return nil, err
+	}
+
+	plats := map[string]struct{}{}
+	for _, p := range slices.Concat(from.platforms, to.platforms) {
+		plats[p] = struct{}{}
+	}
+	for _, p := range slices.Sorted(maps.Keys(plats)) {
+		fromDgst, inFrom := from.images[p]
+		toDgst, inTo := to.images[p]
+		pd := PlatformDiff{Platform: p}
+		switch {
+		case !inFrom:
+			pd.Status = diffAdded
+			pd.Manifest = &Change{New: toDgst.String()}
+		case !inTo:
+			pd.Status = diffRemoved
+			pd.Manifest = &Change{Old: fromDgst.String()}
+		default:
+			fm, tm := from.manifests[fromDgst], to.manifests[toDgst]
+			if fromDgst != toDgst {
+				pd.Manifest = &Change{Old: fromDgst.String(), New: toDgst.String()}
+			}
+			pd.Config = diffConfigs(from.assets[p].config, to.assets[p].config)
+			pd.Layers = diffLayers(fm.manifest.Layers, tm.manifest.Layers)
+			pd.Annotations = diffMaps("", manifestAnnotations(fm), manifestAnnotations(tm))
+			if f, t := fromSBOM[p], toSBOM[p]; f.SPDX != nil || t.SPDX != nil {
+				pd.SBOM = diffLists(sbomPackages(f), sbomPackages(t))
+			}
+			if f, t := fromProvenance[p], toProvenance[p]; f.SLSA != nil || t.SLSA != nil {
+				pd.Provenance = diffLists(provenanceMaterials(f), provenanceMaterials(t))
+			}
+			pd.Status = diffUnchanged
+			if pd.Manifest != nil || len(pd.Config) > 0 || len(pd.Layers) > 0 || len(pd.Annotations) > 0 || pd.SBOM != nil || pd.Provenance != nil {
+				pd.Status = diffChanged
+			}
+		}
+		d.Platforms = append(d.Platforms, pd)
+	}
+	return d, nil
+}
+
+func indexAnnotations(r *result) map[string]string {
+	annotations := map[string]string{}
+	for _, idx := range r.indexes {
+		maps.Copy(annotations, idx.index.Annotations)
+	}
+	return annotations
+}
+
+func manifestAnnotations(m manifest) map[string]string {
+	annotations := map[string]string{}
+	maps.Copy(annotations, m.desc.Annotations)
+	maps.Copy(annotations, m.manifest.Annotations)
+	return annotations
+}
+
+func diffConfigs(from, to *ocispecs.Image) []Change {
+	if from == nil {
+		from = &ocispecs.Image{}
+	}
+	if to == nil {
+		to = &ocispecs.Image{}
+	}
+	var changes []Change
+	compare := func(field, a, b string) {
+		if a != b {
+			changes = append(changes, Change{Field: field, Old: a, New: b})
+		}
+	}
+	compare("User", from.Config.User, to.Config.User)
+	compare("WorkingDir", from.Config.WorkingDir, to.Config.WorkingDir)
+	compare("Entrypoint", formatArgs(from.Config.Entrypoint), formatArgs(to.Config.Entrypoint))
+	compare("Cmd", formatArgs(from.Config.Cmd), formatArgs(to.Config.Cmd))
+	compare("StopSignal", from.Config.StopSignal, to.Config.StopSignal)
+	compare("ExposedPorts", strings.Join(slices.Sorted(maps.Keys(from.Config.ExposedPorts)), " "), strings.Join(slices.Sorted(maps.Keys(to.Config.ExposedPorts)), " "))
+	compare("Volumes", strings.Join(slices.Sorted(maps.Keys(from.Config.Volumes)), " "), strings.Join(slices.Sorted(maps.Keys(to.Config.Volumes)), " "))
+	changes = append(changes, diffMaps("Env", envMap(from.Config.Env), envMap(to.Config.Env))...)
+	changes = append(changes, diffMaps("Labels", from.Config.Labels, to.Config.Labels)...)
+	return changes
+}
+
+func formatArgs(args []string) string {
+	if args == nil {
+		return ""
+	}
+	dt, _ := json.Marshal(args)
+	return string(dt)
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// diffMaps returns the changes between two maps. Fields are prefixed with
+// prefix if set.
+func diffMaps(prefix string, from, to map[string]string) []Change {
+	keys := map[string]struct{}{}
+	for k := range from {
+		keys[k] = struct{}{}
+	}
+	for k := range to {
+		keys[k] = struct{}{}
+	}
+	var changes []Change
+	for _, k := range slices.Sorted(maps.Keys(keys)) {
+		a, inFrom := from[k]
+		b, inTo := to[k]
+		if inFrom && inTo && a == b {
+			continue
+		}
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		changes = append(changes, Change{Field: field, Old: a, New: b})
+	}
+	return changes
+}
+
+func diffLayers(from, to []ocispecs.Descriptor) []LayerDiff {
+	var changes []LayerDiff
+	for i := range max(len(from), len(to)) {
+		var a, b *Layer
+		if i < len(from) {
+			a = &Layer{Digest: from[i].Digest, Size: from[i].Size, MediaType: from[i].MediaType}
+		}
+		if i < len(to) {
+			b = &Layer{Digest: to[i].Digest, Size: to[i].Size, MediaType: to[i].MediaType}
+		}
+		if a != nil && b != nil && a.Digest == b.Digest {
+			continue
+		}
+		changes = append(changes, LayerDiff{Index: i, Old: a, New: b})
+	}
+	return changes
+}
+
+// diffLists returns the entries added and removed between two lists, or nil
+// if they have the same entries.
+func diffLists(from, to []string) *ListChanges {
+	var lc ListChanges
+	for _, v := range to {
+		if !slices.Contains(from, v) {
+			lc.Added = append(lc.Added, v)
+		}
+	}
+	for _, v := range from {
+		if !slices.Contains(to, v) {
+			lc.Removed = append(lc.Removed, v)
+		}
+	}
+	if len(lc.Added) == 0 && len(lc.Removed) == 0 {
+		return nil
+	}
+	slices.Sort(lc.Added)
+	slices.Sort(lc.Removed)
+	return &lc
+}
+
+// sbomPackages returns the packages of the SPDX documents of an SBOM as
+// name@version.
+func sbomPackages(sbom sbomStub) []string {
+	var pkgs []string
+	for _, doc := range append([]any{sbom.SPDX}, sbom.AdditionalSPDXs...) {
+		var spdx struct {
+			Packages []struct {
+				Name        string `json:"name"`
+				VersionInfo string `json:"versionInfo"`
+			} `json:"packages"`
+		}
+		if !convertJSON(doc, &spdx) {
+			continue
+		}
+		for _, p := range spdx.Packages {
+			pkg := p.Name
+			if p.VersionInfo != "" {
+				pkg += "@" + p.VersionInfo
+			}
+			if !slices.Contains(pkgs, pkg) {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	}
+	return pkgs
+}
+
+// provenanceMaterials returns the materials of a SLSA v0.2 provenance, or
+// the resolved dependencies of a SLSA v1 provenance, as uri@digest.
+func provenanceMaterials(prv provenanceStub) []string {
+	type material struct {
+		URI    string            `json:"uri"`
+		Digest map[string]string `json:"digest"`
+	}
+	var slsa struct {
+		Materials       []material `json:"materials"`
+		BuildDefinition struct {
+			ResolvedDependencies []material `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+	}
+	if !convertJSON(prv.SLSA, &slsa) {
+		return nil
+	}
+	var materials []string
+	for _, m := range slices.Concat(slsa.Materials, slsa.BuildDefinition.ResolvedDependencies) {
+		s := m.URI
+		for _, alg := range slices.Sorted(maps.Keys(m.Digest)) {
+			s += "@" + alg + ":" + m.Digest[alg]
+			break
+		}
+		materials = append(materials, s)
+	}
+	return materials
+}
+
+func convertJSON(in, out any) bool {
+	if in == nil {
+		return false
+	}
+	dt, err := json.Marshal(in)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(dt, out) == nil
+}
+
+// Print prints the differences in a human-readable form.
+func (d *Diff) Print(w io.Writer) error {
+	fmt.Fprintf(w, "From: %s\n", d.From)
+	fmt.Fprintf(w, "To:   %s\n", d.To)
+	if !d.Changed() {
+		fmt.Fprintln(w, "\nNo differences")
+		return nil
+	}
+	if d.Digest != nil {
+		fmt.Fprintf(w, "\nDigest: %s -> %s\n", d.Digest.Old, d.Digest.New)
+	}
+	if len(d.Annotations) > 0 {
+		fmt.Fprintln(w, "\nAnnotations:")
+		printChanges(w, defaultPfx, d.Annotations)
+	}
+	for _, p := range d.Platforms {
+		switch p.Status {
+		case diffUnchanged:
+			continue
+		case diffAdded:
+			fmt.Fprintf(w, "\nPlatform: %s (added)\n", p.Platform)
+			fmt.Fprintf(w, "%sManifest: %s\n", defaultPfx, p.Manifest.New)
+			continue
+		case diffRemoved:
+			fmt.Fprintf(w, "\nPlatform: %s (removed)\n", p.Platform)
+			fmt.Fprintf(w, "%sManifest: %s\n", defaultPfx, p.Manifest.Old)
+			continue
+		}
+		fmt.Fprintf(w, "\nPlatform: %s\n", p.Platform)
+		if p.Manifest != nil {
+			fmt.Fprintf(w, "%sManifest: %s -> %s\n", defaultPfx, p.Manifest.Old, p.Manifest.New)
+		}
+		if len(p.Config) > 0 {
+			fmt.Fprintf(w, "%sConfig:\n", defaultPfx)
+			printChanges(w, defaultPfx+defaultPfx, p.Config)
+		}
+		if len(p.Layers) > 0 {
+			fmt.Fprintf(w, "%sLayers:\n", defaultPfx)
+			for _, l := range p.Layers {
+				switch {
+				case l.Old == nil:
+					fmt.Fprintf(w, "%s+ #%d %s\n", defaultPfx+defaultPfx, l.Index, formatLayer(l.New))
+				case l.New == nil:
+					fmt.Fprintf(w, "%s- #%d %s\n", defaultPfx+defaultPfx, l.Index, formatLayer(l.Old))
+				default:
+					fmt.Fprintf(w, "%s~ #%d %s -> %s\n", defaultPfx+defaultPfx, l.Index, formatLayer(l.Old), formatLayer(l.New))
+				}
+			}
+		}
+		if len(p.Annotations) > 0 {
+			fmt.Fprintf(w, "%sAnnotations:\n", defaultPfx)
+			printChanges(w, defaultPfx+defaultPfx, p.Annotations)
+		}
+		if p.SBOM != nil {
+			fmt.Fprintf(w, "%sSBOM packages:\n", defaultPfx)
+			printListChanges(w, defaultPfx+defaultPfx, p.SBOM)
+		}
+		if p.Provenance != nil {
+			fmt.Fprintf(w, "%sProvenance materials:\n", defaultPfx)
+			printListChanges(w, defaultPfx+defaultPfx, p.Provenance)
+		}
+	}
+	return nil
+}
+
+func printChanges(w io.Writer, pfx string, changes []Change) {
+	for _, c := range changes {
+		switch {
+		case c.Old == "":
+			fmt.Fprintf(w, "%s+ %s: %s\n", pfx, c.Field, c.New)
+		case c.New == "":
+			fmt.Fprintf(w, "%s- %s: %s\n", pfx, c.Field, c.Old)
+		default:
+			fmt.Fprintf(w, "%s~ %s: %s -> %s\n", pfx, c.Field, c.Old, c.New)
+		}
+	}
+}
+
+func printListChanges(w io.Writer, pfx string, lc *ListChanges) {
+	for _, v := range lc.Added {
+		fmt.Fprintf(w, "%s+ %s\n", pfx, v)
+	}
+	for _, v := range lc.Removed {
+		fmt.Fprintf(w, "%s- %s\n", pfx, v)
+	}
+}
+
+func formatLayer(l *Layer) string {
+	return fmt.Sprintf("%s (%s)", l.Digest, units.HumanSizeWithPrecision(float64(l.Size), 3))
+}
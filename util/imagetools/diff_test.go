@@ -0,0 +1,136 @@
+package imagetools
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffResults(t *testing.T) {
+	layer := func(s string, size int64) ocispecs.Descriptor {
+		return ocispecs.Descriptor{MediaType: ocispecs.MediaTypeImageLayerGzip, Digest: digest.FromString(s), Size: size}
+	}
+	newResult := func(images map[string]manifest, configs map[string]*ocispecs.Image, sboms map[string]*sbomStub, provenances map[string]*provenanceStub) *result {
+		r := &result{
+			manifests: map[digest.Digest]manifest{},
+			images:    map[string]digest.Digest{},
+			assets:    map[string]asset{},
+		}
+		for p, m := range images {
+			r.platforms = append(r.platforms, p)
+			r.images[p] = m.desc.Digest
+			r.manifests[m.desc.Digest] = m
+			a := asset{config: configs[p]}
+			if sbom := sboms[p]; sbom != nil {
+				a.deferredSbom = func() (*sbomStub, error) { return sbom, nil }
+			}
+			if prv := provenances[p]; prv != nil {
+				a.deferredProvenance = func() (*provenanceStub, error) { return prv, nil }
+			}
+			r.assets[p] = a
+		}
+		return r
+	}
+	sbom := func(pkgs ...string) *sbomStub {
+		var packages []any
+		for _, p := range pkgs {
+			packages = append(packages, map[string]any{"name": p, "versionInfo": "1.0"})
+		}
+		return &sbomStub{SPDX: map[string]any{"packages": packages}}
+	}
+
+	from := newResult(
+		map[string]manifest{
+			"linux/amd64": {
+				desc:     ocispecs.Descriptor{Digest: digest.FromString("amd64-1")},
+				manifest: ocispecs.Manifest{Layers: []ocispecs.Descriptor{layer("base", 100), layer("app-1", 10)}},
+			},
+			"linux/arm64": {
+				desc: ocispecs.Descriptor{Digest: digest.FromString("arm64")},
+			},
+		},
+		map[string]*ocispecs.Image{
+			"linux/amd64": {Config: ocispecs.ImageConfig{
+				Env:        []string{"PATH=/usr/bin", "VERSION=1"},
+				Entrypoint: []string{"/app"},
+				Labels:     map[string]string{"org.opencontainers.image.version": "1"},
+			}},
+		},
+		map[string]*sbomStub{"linux/amd64": sbom("alpine-baselayout", "busybox")},
+		map[string]*provenanceStub{"linux/amd64": {SLSA: map[string]any{
+			"materials": []any{map[string]any{"uri": "pkg:docker/alpine@3.20", "digest": map[string]any{"sha256": "aaa"}}},
+		}}},
+	)
+	same, err := diffResults(from, from)
+	require.NoError(t, err)
+	require.False(t, same.Changed())
+
+	to := newResult(
+		map[string]manifest{
+			"linux/amd64": {
+				desc: ocispecs.Descriptor{Digest: digest.FromString("amd64-2")},
+				manifest: ocispecs.Manifest{
+					Layers:      []ocispecs.Descriptor{layer("base", 100), layer("app-2", 12), layer("extra", 1)},
+					Annotations: map[string]string{"org.opencontainers.image.revision": "abc"},
+				},
+			},
+			"linux/riscv64": {
+				desc: ocispecs.Descriptor{Digest: digest.FromString("riscv64")},
+			},
+		},
+		map[string]*ocispecs.Image{
+			"linux/amd64": {Config: ocispecs.ImageConfig{
+				User:       "app",
+				Env:        []string{"PATH=/usr/bin", "VERSION=2"},
+				Entrypoint: []string{"/app", "serve"},
+				Labels:     map[string]string{"org.opencontainers.image.version": "2"},
+			}},
+		},
+		map[string]*sbomStub{"linux/amd64": sbom("alpine-baselayout", "ca-certificates")},
+		map[string]*provenanceStub{"linux/amd64": {SLSA: map[string]any{
+			"buildDefinition": map[string]any{
+				"resolvedDependencies": []any{map[string]any{"uri": "pkg:docker/alpine@3.21", "digest": map[string]any{"sha256": "bbb"}}},
+			},
+		}}},
+	)
+
+	d, err := diffResults(from, to)
+	require.NoError(t, err)
+	require.True(t, d.Changed())
+	require.Len(t, d.Platforms, 3)
+
+	amd64 := d.Platforms[0]
+	require.Equal(t, "linux/amd64", amd64.Platform)
+	require.Equal(t, diffChanged, amd64.Status)
+	require.Equal(t, &Change{Old: digest.FromString("amd64-1").String(), New: digest.FromString("amd64-2").String()}, amd64.Manifest)
+	require.Equal(t, []Change{
+		{Field: "User", New: "app"},
+		{Field: "Entrypoint", Old: `["/app"]`, New: `["/app","serve"]`},
+		{Field: "Env.VERSION", Old: "1", New: "2"},
+		{Field: "Labels.org.opencontainers.image.version", Old: "1", New: "2"},
+	}, amd64.Config)
+	require.Equal(t, []LayerDiff{
+		{Index: 1, Old: &Layer{Digest: digest.FromString("app-1"), Size: 10, MediaType: ocispecs.MediaTypeImageLayerGzip}, New: &Layer{Digest: digest.FromString("app-2"), Size: 12, MediaType: ocispecs.MediaTypeImageLayerGzip}},
+		{Index: 2, New: &Layer{Digest: digest.FromString("extra"), Size: 1, MediaType: ocispecs.MediaTypeImageLayerGzip}},
+	}, amd64.Layers)
+	require.Equal(t, []Change{{Field: "org.opencontainers.image.revision", New: "abc"}}, amd64.Annotations)
+	require.Equal(t, &ListChanges{Added: []string{"ca-certificates@1.0"}, Removed: []string{"busybox@1.0"}}, amd64.SBOM)
+	require.Equal(t, &ListChanges{Added: []string{"pkg:docker/alpine@3.21@sha256:bbb"}, Removed: []string{"pkg:docker/alpine@3.20@sha256:aaa"}}, amd64.Provenance)
+
+	require.Equal(t, PlatformDiff{Platform: "linux/arm64", Status: diffRemoved, Manifest: &Change{Old: digest.FromString("arm64").String()}}, d.Platforms[1])
+	require.Equal(t, PlatformDiff{Platform: "linux/riscv64", Status: diffAdded, Manifest: &Change{New: digest.FromString("riscv64").String()}}, d.Platforms[2])
+
+	d.From, d.To = "alpine:3.20", "alpine:3.21"
+	var buf bytes.Buffer
+	require.NoError(t, d.Print(&buf))
+	out := buf.String()
+	require.Contains(t, out, "Platform: linux/amd64\n")
+	require.Contains(t, out, "    ~ Env.VERSION: 1 -> 2\n")
+	require.Contains(t, out, "    + #2 "+digest.FromString("extra").String()+" (1B)\n")
+	require.Contains(t, out, "    - busybox@1.0\n")
+	require.Contains(t, out, "Platform: linux/arm64 (removed)\n")
+	require.Contains(t, out, "Platform: linux/riscv64 (added)\n")
+}
@@ -0,0 +1,414 @@
+package imagetools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+// SyncConfig describes the repositories mirrored by imagetools sync.
+type SyncConfig struct {
+	Repositories []SyncRepository `yaml:"repositories"`
+}
+
+// SyncRepository describes a source repository, the tags and platforms to
+// mirror from it, and the destinations to copy them to.
+type SyncRepository struct {
+	Source       string    `yaml:"source"`
+	Destinations []string  `yaml:"destinations"`
+	Tags         TagFilter `yaml:"tags"`
+	Platforms    []string  `yaml:"platforms"`
+}
+
+// TagFilter selects the tags of a repository. Tags listed in Names are
+// always selected. Other tags are selected if they match one of the Regex
+// patterns (or any tag if no pattern is set) and satisfy the Semver
+// constraint. Latest keeps only the N highest versions of the selection.
+type TagFilter struct {
+	Names  []string `yaml:"names"`
+	Regex  []string `yaml:"regex"`
+	Semver string   `yaml:"semver"`
+	Latest int      `yaml:"latest"`
+}
+
+type SyncStatus string
+
+const (
+	SyncStatusCopied   SyncStatus = "copied"
+	SyncStatusUpToDate SyncStatus = "up-to-date"
+	SyncStatusPending  SyncStatus = "pending"
+	SyncStatusFailed   SyncStatus = "failed"
+)
+
+// SyncJob copies a single tag from a source to a destination.
+type SyncJob struct {
+	Source      *Location
+	Destination *Location
+	Platforms   []ocispecs.Platform
+}
+
+type SyncResult struct {
+	Source      string
+	Destination string
+	Digest      digest.Digest `json:",omitempty"`
+	Status      SyncStatus
+	Error       string `json:",omitempty"`
+}
+
+func ParseSyncConfig(dt []byte) (*SyncConfig, error) {
+	var cfg SyncConfig
+	dec := yaml.NewDecoder(bytes.NewReader(dt))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, errors.Wrap(err, "failed to parse sync config")
+	}
+	if len(cfg.Repositories) == 0 {
+		return nil, errors.New("no repositories defined in sync config")
+	}
+	for i, repo := range cfg.Repositories {
+		if repo.Source == "" {
+			return nil, errors.Errorf("repository %d: source is required", i)
+		}
+		if len(repo.Destinations) == 0 {
+			return nil, errors.Errorf("repository %s: at least one destination is required", repo.Source)
+		}
+		if err := repo.Tags.validate(); err != nil {
+			return nil, errors.Wrapf(err, "repository %s", repo.Source)
+		}
+	}
+	return &cfg, nil
+}
+
+func (f TagFilter) validate() error {
+	for _, re := range f.Regex {
+		if _, err := regexp.Compile(re); err != nil {
+			return errors.Wrapf(err, "invalid tag regex %q", re)
+		}
+	}
+	if f.Semver != "" {
+		if _, err := semver.NewConstraint(f.Semver); err != nil {
+			return errors.Wrapf(err, "invalid semver constraint %q", f.Semver)
+		}
+	}
+	if f.Latest < 0 {
+		return errors.Errorf("invalid latest value %d", f.Latest)
+	}
+	return nil
+}
+
+// listAll reports whether the filter needs the tags of the repository to
+// be listed, as opposed to only using explicit names.
+func (f TagFilter) listAll() bool {
+	return len(f.Names) == 0 || len(f.Regex) > 0 || f.Semver != "" || f.Latest > 0
+}
+
+// Apply returns the tags selected by the filter.
+func (f TagFilter) Apply(tags []string) ([]string, error) {
+	res := make([]*regexp.Regexp, 0, len(f.Regex))
+	for _, s := range f.Regex {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid tag regex %q", s)
+		}
+		res = append(res, re)
+	}
+	var constraint *semver.Constraints
+	if f.Semver != "" {
+		c, err := semver.NewConstraint(f.Semver)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid semver constraint %q", f.Semver)
+		}
+		constraint = c
+	}
+
+	var selected []string
+	if f.listAll() {
+		for _, tag := range tags {
+			if slices.Contains(f.Names, tag) {
+				continue
+			}
+			if len(res) > 0 && !slices.ContainsFunc(res, func(re *regexp.Regexp) bool { return re.MatchString(tag) }) {
+				continue
+			}
+			if constraint != nil {
+				v, err := semver.NewVersion(tag)
+				if err != nil || !constraint.Check(v) {
+					continue
+				}
+			}
+			selected = append(selected, tag)
+		}
+	}
+
+	if f.Latest > 0 {
+		sortTagsByVersion(selected)
+		if len(selected) > f.Latest {
+			selected = selected[:f.Latest]
+		}
+	} else {
+		slices.Sort(selected)
+	}
+	out := slices.Clone(f.Names)
+	for _, tag := range selected {
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+// sortTagsByVersion sorts tags from the highest to the lowest semantic
+// version. Tags that are not versions are sorted last.
+func sortTagsByVersion(tags []string) {
+	versions := make(map[string]*semver.Version, len(tags))
+	for _, tag := range tags {
+		if v, err := semver.NewVersion(tag); err == nil {
+			versions[tag] = v
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b string) int {
+		va, vb := versions[a], versions[b]
+		switch {
+		case va != nil && vb != nil:
+			if c := vb.Compare(va); c != 0 {
+				return c
+			}
+			return cmp.Compare(a, b)
+		case va != nil:
+			return -1
+		case vb != nil:
+			return 1
+		default:
+			return cmp.Compare(b, a)
+		}
+	})
+}
+
+// PlanSync resolves the tags selected for each repository of the config and
+// returns the list of copies to perform.
+func (r *Resolver) PlanSync(ctx context.Context, cfg *SyncConfig) ([]SyncJob, error) {
+	var jobs []SyncJob
+	for _, repo := range cfg.Repositories {
+		src, err := ParseLocation(repo.Source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid source %q", repo.Source)
+		}
+		if src.IsRegistry() {
+			if n, err := reference.ParseNormalizedNamed(repo.Source); err == nil && !reference.IsNameOnly(n) {
+				return nil, errors.Errorf("source %q must be a repository without tag or digest", repo.Source)
+			}
+		}
+		dests := make([]*Location, 0, len(repo.Destinations))
+		for _, d := range repo.Destinations {
+			dest, err := ParseLocation(d)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid destination %q", d)
+			}
+			dests = append(dests, dest)
+		}
+		plats := make([]ocispecs.Platform, 0, len(repo.Platforms))
+		for _, p := range repo.Platforms {
+			plat, err := platforms.Parse(p)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid platform %q", p)
+			}
+			plats = append(plats, plat)
+		}
+
+		var tags []string
+		if repo.Tags.listAll() {
+			tags, err = r.Tags(ctx, src)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list tags for %s", repo.Source)
+			}
+		}
+		tags, err = repo.Tags.Apply(tags)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			srcTag, err := src.WithTag(tag)
+			if err != nil {
+				return nil, err
+			}
+			for _, dest := range dests {
+				destTag, err := dest.WithTag(tag)
+				if err != nil {
+					return nil, err
+				}
+				jobs = append(jobs, SyncJob{
+					Source:      srcTag,
+					Destination: destTag,
+					Platforms:   plats,
+				})
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// Syncer runs sync jobs. Copies to the same destination repository share a
+// single ingester so concurrent pushes of the same blob do not race.
+type Syncer struct {
+	Resolver *Resolver
+	DryRun   bool
+
+	mu        sync.Mutex
+	ingesters map[string]content.Ingester
+}
+
+// Sync copies the manifests, blobs and referrers of a job that are missing
+// in its destination, and tags the result.
+func (s *Syncer) Sync(ctx context.Context, job SyncJob, logf func(string)) (res SyncResult) {
+	res = SyncResult{
+		Source:      job.Source.String(),
+		Destination: job.Destination.String(),
+	}
+	defer func() {
+		if res.Error != "" {
+			res.Status = SyncStatusFailed
+		}
+	}()
+	r := s.Resolver
+
+	_, desc, err := r.Resolve(ctx, job.Source.String())
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	src := &Source{Ref: job.Source, Desc: desc}
+	dt, desc, manifests, err := r.Combine(ctx, []*Source{src}, nil, false, job.Platforms)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Digest = desc.Digest
+
+	// referrers of the source index only apply to the copy if the index
+	// itself is unchanged
+	indexReferrers := desc.Digest == src.Desc.Digest && manifests != nil
+	var subjects []digest.Digest
+	if indexReferrers || manifests == nil {
+		subjects = append(subjects, desc.Digest)
+	}
+	for _, m := range manifests {
+		subjects = append(subjects, m.Digest)
+	}
+	if _, existing, err := r.Resolve(ctx, job.Destination.String()); err == nil && existing.Digest == desc.Digest {
+		if !r.referrersMissing(ctx, job.Source, job.Destination, subjects) {
+			res.Status = SyncStatusUpToDate
+			return res
+		}
+	}
+	if s.DryRun {
+		res.Status = SyncStatusPending
+		return res
+	}
+
+	ingester, err := s.ingester(ctx, job.Destination)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if manifests == nil {
+		manifests = []DescWithSource{{Descriptor: desc, Source: src}}
+	}
+	for _, m := range manifests {
+		logf("copying " + m.Digest.String() + " from " + m.Source.Ref.String() + " to " + job.Destination.Name())
+		if err := r.CopyWithIngester(ctx, &Source{Ref: m.Source.Ref, Desc: m.Descriptor}, job.Destination, ingester); err != nil {
+			res.Error = errors.Wrapf(err, "copy %s from %s to %s", m.Digest, m.Source.Ref, job.Destination).Error()
+			return res
+		}
+	}
+	if indexReferrers && job.Destination.IsOCILayout() {
+		// referrers of an OCI layout are indexed when the subject is pushed
+		if err := s.copyReferrers(ctx, src, job.Destination, ingester, logf); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+	logf("pushing " + desc.Digest.String() + " to " + job.Destination.String())
+	if err := r.Push(ctx, job.Destination, desc, dt); err != nil {
+		res.Error = errors.Wrapf(err, "publish %s to %s", desc.Digest, job.Destination).Error()
+		return res
+	}
+	if indexReferrers && job.Destination.IsRegistry() {
+		if err := s.copyReferrers(ctx, src, job.Destination, ingester, logf); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+	res.Status = SyncStatusCopied
+	return res
+}
+
+// copyReferrers copies the referrers of a source index, such as signatures
+// and attestations of the whole index, to the destination.
+func (s *Syncer) copyReferrers(ctx context.Context, src *Source, dest *Location, ingester content.Ingester, logf func(string)) error {
+	r := s.Resolver
+	refs, err := r.supportedReferrers(ctx, src.Ref, src.Desc.Digest)
+	if err != nil {
+		// not every registry supports referrers
+		return nil
+	}
+	for _, ref := range refs {
+		logf("copying referrer " + ref.Digest.String() + " of " + src.Desc.Digest.String() + " to " + dest.Name())
+		if err := r.CopyWithIngester(ctx, &Source{Ref: src.Ref, Desc: ref}, dest, ingester); err != nil {
+			return errors.Wrapf(err, "copy referrer %s from %s to %s", ref.Digest, src.Ref, dest)
+		}
+	}
+	if dest.IsOCILayout() && len(refs) > 0 {
+		r.ociReferrers.record(dest.OCILayout().Path, src.Desc.Digest, refs)
+	}
+	return nil
+}
+
+// referrersMissing reports whether the source has referrers for one of the
+// subjects that the destination doesn't have.
+func (r *Resolver) referrersMissing(ctx context.Context, src, dest *Location, subjects []digest.Digest) bool {
+	for _, subject := range subjects {
+		srcRefs, err := r.supportedReferrers(ctx, src, subject)
+		if err != nil || len(srcRefs) == 0 {
+			continue
+		}
+		destRefs, err := r.FetchReferrers(ctx, dest, subject)
+		if err != nil {
+			return true
+		}
+		for _, ref := range srcRefs {
+			if !slices.ContainsFunc(destRefs, func(d ocispecs.Descriptor) bool { return d.Digest == ref.Digest }) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Syncer) ingester(ctx context.Context, loc *Location) (content.Ingester, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ing, ok := s.ingesters[loc.Name()]; ok {
+		return ing, nil
+	}
+	ing, err := s.Resolver.IngesterForLocation(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	if s.ingesters == nil {
+		s.ingesters = map[string]content.Ingester{}
+	}
+	s.ingesters[loc.Name()] = ing
+	return ing, nil
+}
@@ -0,0 +1,163 @@
+package imagetools
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/moby/buildkit/client/ociindex"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyncConfig(t *testing.T) {
+	cfg, err := ParseSyncConfig([]byte(`
+repositories:
+  - source: docker.io/library/alpine
+    destinations:
+      - registry.example.com/mirror/alpine
+      - oci-layout:///tmp/alpine
+    tags:
+      names: [latest]
+      regex: ['^3\.\d+$']
+      semver: ">=3.18"
+      latest: 2
+    platforms: [linux/amd64, linux/arm64]
+`))
+	require.NoError(t, err)
+	require.Len(t, cfg.Repositories, 1)
+	repo := cfg.Repositories[0]
+	require.Equal(t, "docker.io/library/alpine", repo.Source)
+	require.Equal(t, []string{"registry.example.com/mirror/alpine", "oci-layout:///tmp/alpine"}, repo.Destinations)
+	require.Equal(t, TagFilter{
+		Names:  []string{"latest"},
+		Regex:  []string{`^3\.\d+$`},
+		Semver: ">=3.18",
+		Latest: 2,
+	}, repo.Tags)
+	require.Equal(t, []string{"linux/amd64", "linux/arm64"}, repo.Platforms)
+
+	for _, tc := range []struct {
+		name string
+		in   string
+	}{
+		{name: "empty", in: `repositories: []`},
+		{name: "no source", in: "repositories:\n  - destinations: [foo]\n"},
+		{name: "no destination", in: "repositories:\n  - source: foo\n"},
+		{name: "invalid regex", in: "repositories:\n  - source: foo\n    destinations: [bar]\n    tags:\n      regex: ['(']\n"},
+		{name: "invalid semver", in: "repositories:\n  - source: foo\n    destinations: [bar]\n    tags:\n      semver: 'foo'\n"},
+		{name: "unknown field", in: "repositories:\n  - source: foo\n    destinations: [bar]\n    unknown: true\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseSyncConfig([]byte(tc.in))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestTagFilterApply(t *testing.T) {
+	tags := []string{"3.17", "3.18", "3.19", "3.20", "3.20.1", "edge", "latest", "3.19-rc1"}
+	for _, tc := range []struct {
+		name     string
+		filter   TagFilter
+		expected []string
+	}{
+		{
+			name:     "all",
+			filter:   TagFilter{},
+			expected: []string{"3.17", "3.18", "3.19", "3.19-rc1", "3.20", "3.20.1", "edge", "latest"},
+		},
+		{
+			name:     "names",
+			filter:   TagFilter{Names: []string{"latest", "edge"}},
+			expected: []string{"latest", "edge"},
+		},
+		{
+			name:     "regex",
+			filter:   TagFilter{Regex: []string{`^3\.\d+$`}},
+			expected: []string{"3.17", "3.18", "3.19", "3.20"},
+		},
+		{
+			name:     "semver",
+			filter:   TagFilter{Semver: ">=3.19 <3.20.1"},
+			expected: []string{"3.19", "3.20"},
+		},
+		{
+			name:     "latest",
+			filter:   TagFilter{Regex: []string{`^3\.\d+$`}, Latest: 2},
+			expected: []string{"3.20", "3.19"},
+		},
+		{
+			name:     "names and latest",
+			filter:   TagFilter{Names: []string{"edge"}, Semver: ">=3", Latest: 1},
+			expected: []string{"edge", "3.20.1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := tc.filter.Apply(tags)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestOCILayoutTags(t *testing.T) {
+	dir := t.TempDir()
+	idx := ociindex.NewStoreIndex(dir)
+	desc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageManifest,
+		Digest:    digest.FromString("foo"),
+		Size:      3,
+	}
+	require.NoError(t, idx.Put(desc, ociindex.Tag("v2")))
+	require.NoError(t, idx.Put(desc, ociindex.Tag("v1")))
+
+	tags, err := ociLayoutTags(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1", "v2"}, tags)
+
+	_, err = ociLayoutTags(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
+func TestReferrersMissing(t *testing.T) {
+	subject := digest.FromString("index")
+	signature := ocispecs.Descriptor{
+		MediaType:    ocispecs.MediaTypeImageManifest,
+		ArtifactType: artifactTypeSigstoreBundle,
+		Digest:       digest.FromString("signature"),
+		Size:         456,
+		Annotations: map[string]string{
+			images.AnnotationManifestSubject: subject.String(),
+		},
+	}
+
+	srcDir, destDir := t.TempDir(), t.TempDir()
+	require.NoError(t, ociindex.NewStoreIndex(srcDir).Put(signature))
+
+	src, err := ParseLocation("oci-layout://" + srcDir + ":latest")
+	require.NoError(t, err)
+	dest, err := ParseLocation("oci-layout://" + destDir + ":latest")
+	require.NoError(t, err)
+
+	r := New(Opt{})
+	require.True(t, r.referrersMissing(t.Context(), src, dest, []digest.Digest{subject}))
+	require.False(t, r.referrersMissing(t.Context(), src, dest, []digest.Digest{digest.FromString("other")}))
+
+	require.NoError(t, ociindex.NewStoreIndex(destDir).Put(signature))
+	require.False(t, r.referrersMissing(t.Context(), src, dest, []digest.Digest{subject}))
+}
+
+func TestNextLink(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	next, err := nextLink(resp, "https://registry.example.com/v2/foo/tags/list")
+	require.NoError(t, err)
+	require.Empty(t, next)
+
+	resp.Header.Set("Link", `</v2/foo/tags/list?last=b&n=2>; rel="next"`)
+	next, err = nextLink(resp, "https://registry.example.com/v2/foo/tags/list")
+	require.NoError(t, err)
+	require.Equal(t, "https://registry.example.com/v2/foo/tags/list?last=b&n=2", next)
+}
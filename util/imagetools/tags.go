@@ -0,0 +1,174 @@
+package imagetools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/client/ociindex"
+	"github.com/moby/buildkit/util/tracing"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// Tags returns the tags available in the repository of loc.
+func (r *Resolver) Tags(ctx context.Context, loc *Location) ([]string, error) {
+	if loc.IsOCILayout() {
+		return ociLayoutTags(loc.OCILayout().Path)
+	}
+	return r.registryTags(ctx, loc.Named())
+}
+
+func ociLayoutTags(path string) ([]string, error) {
+	idx, err := ociindex.NewStoreIndex(path).Read()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	var tags []string
+	for _, m := range idx.Manifests {
+		tag, ok := m.Annotations[ocispecs.AnnotationRefName]
+		if !ok {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (r *Resolver) registryTags(ctx context.Context, named reference.Named) ([]string, error) {
+	domain := reference.Domain(named)
+	repo := reference.Path(named)
+	hosts, err := r.hosts(domain)
+	if err != nil {
+		return nil, err
+	}
+	ctx = docker.ContextWithAppendPullRepositoryScope(ctx, repo)
+
+	var lastErr error
+	for _, host := range hosts {
+		if !host.Capabilities.Has(docker.HostCapabilityPull) {
+			continue
+		}
+		u := url.URL{
+			Scheme: host.Scheme,
+			Host:   host.Host,
+			Path:   host.Path + "/" + repo + "/tags/list",
+		}
+		tags, err := r.fetchTags(ctx, host, u.String())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		sort.Strings(tags)
+		return tags, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.Errorf("no registry host available to list tags for %s", named.Name())
+	}
+	return nil, lastErr
+}
+
+func (r *Resolver) fetchTags(ctx context.Context, host docker.RegistryHost, u string) ([]string, error) {
+	client := host.Client
+	if client == nil {
+		client = tracing.DefaultClient
+	}
+	var tags []string
+	for u != "" {
+		resp, err := r.doTagsRequest(ctx, client, host, u)
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode tags list from %s", u)
+		}
+		tags = append(tags, list.Tags...)
+
+		next, err := nextLink(resp, u)
+		if err != nil {
+			return nil, err
+		}
+		u = next
+	}
+	return tags, nil
+}
+
+func (r *Resolver) doTagsRequest(ctx context.Context, client *http.Client, host docker.RegistryHost, u string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range host.Header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Accept", "application/json")
+		if err := r.auth.Authorize(ctx, req); err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			err := r.auth.AddResponses(ctx, []*http.Response{resp})
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			continue
+		case resp.StatusCode == http.StatusNotFound:
+			resp.Body.Close()
+			return nil, errors.Wrapf(errdefs.ErrNotFound, "repository not found: %s", u)
+		default:
+			dt, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+			resp.Body.Close()
+			return nil, errors.Errorf("failed to list tags from %s: %s %s", u, resp.Status, strings.TrimSpace(string(dt)))
+		}
+	}
+}
+
+// nextLink returns the URL of the next page of a paginated registry
+// response from its Link header.
+func nextLink(resp *http.Response, current string) (string, error) {
+	for _, link := range resp.Header.Values("Link") {
+		for part := range strings.SplitSeq(link, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+			if !ok || !strings.Contains(params, `rel="next"`) {
+				continue
+			}
+			target = strings.Trim(strings.TrimSpace(target), "<>")
+			base, err := url.Parse(current)
+			if err != nil {
+				return "", err
+			}
+			next, err := base.Parse(target)
+			if err != nil {
+				return "", err
+			}
+			return next.String(), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,124 @@
+package lintreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the check results of targets as a JUnit XML report with
+// a test suite per target. Each warning is a failed test case and build
+// errors are reported as errors. Targets without any issue have a single
+// passing test case.
+func WriteJUnit(w io.Writer, targets []Target) error {
+	out := junitTestSuites{
+		Name: "buildx check",
+	}
+	byTarget := map[string][]*Issue{}
+	for _, issue := range Issues(targets) {
+		for _, t := range issue.Targets {
+			byTarget[t] = append(byTarget[t], issue)
+		}
+	}
+
+	for _, t := range targets {
+		suite := junitTestSuite{Name: t.Name}
+		for _, issue := range byTarget[t.Name] {
+			tc := junitTestCase{
+				Name:      issue.Rule,
+				ClassName: t.Name,
+				File:      issue.File,
+			}
+			if len(issue.Ranges) > 0 {
+				tc.Line = issue.Ranges[0].StartLine
+				tc.Name = fmt.Sprintf("%s:%d %s", issue.File, tc.Line, issue.Rule)
+			}
+			f := &junitFailure{
+				Message: issue.Message,
+				Type:    issue.Rule,
+				Text:    junitText(issue),
+			}
+			if issue.Level == LevelError {
+				tc.Error = f
+				suite.Errors++
+			} else {
+				tc.Failure = f
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+		if len(suite.TestCases) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "check",
+				ClassName: t.Name,
+			})
+		}
+		suite.Tests = len(suite.TestCases)
+		out.Tests += suite.Tests
+		out.Failures += suite.Failures
+		out.Errors += suite.Errors
+		out.Suites = append(out.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitText(issue *Issue) string {
+	var lines []string
+	if issue.Description != "" {
+		lines = append(lines, issue.Description)
+	}
+	if issue.File != "" {
+		loc := issue.File
+		if len(issue.Ranges) > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, issue.Ranges[0].StartLine)
+		}
+		lines = append(lines, loc)
+	}
+	if issue.URL != "" {
+		lines = append(lines, "More info: "+issue.URL)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,184 @@
+// Package lintreport writes the results of build checks as SARIF or JUnit
+// reports that can be consumed by code review and CI tooling.
+package lintreport
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/subrequests/lint"
+	"github.com/moby/buildkit/solver/pb"
+)
+
+const (
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
+
+	LevelWarning = "warning"
+	LevelError   = "error"
+
+	// buildErrorRule is the rule name used for build errors, e.g. a
+	// Dockerfile that can't be parsed.
+	buildErrorRule = "BuildError"
+)
+
+// IsFormat reports whether format is a report format.
+func IsFormat(format string) bool {
+	return format == FormatSARIF || format == FormatJUnit
+}
+
+// Target holds the check results of a build target.
+type Target struct {
+	Name    string
+	Results *lint.LintResults
+	// SourceInfoMap maps the source files reported by the frontend to their
+	// location on the client.
+	SourceInfoMap lint.SourceInfoMap
+}
+
+// Issue is a check warning or build error aggregated across targets.
+type Issue struct {
+	Rule        string
+	Description string
+	URL         string
+	Message     string
+	Level       string
+	File        string
+	Ranges      []Range
+	Targets     []string
+}
+
+// Range is a range of a source file. Lines and columns start at 1. Columns
+// are 0 if the range covers whole lines.
+type Range struct {
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.StartLine, r.StartColumn, r.EndLine, r.EndColumn)
+}
+
+// Issues returns the issues of all targets. Identical issues reported by
+// several targets, e.g. targets sharing a Dockerfile, are only returned once.
+func Issues(targets []Target) []*Issue {
+	var issues []*Issue
+	seen := map[string]*Issue{}
+	add := func(target string, issue *Issue) {
+		key := issueKey(issue)
+		if prev, ok := seen[key]; ok {
+			if !slices.Contains(prev.Targets, target) {
+				prev.Targets = append(prev.Targets, target)
+			}
+			return
+		}
+		issue.Targets = []string{target}
+		seen[key] = issue
+		issues = append(issues, issue)
+	}
+
+	for _, t := range targets {
+		if t.Results == nil {
+			continue
+		}
+		for _, w := range t.Results.Warnings {
+			issue := &Issue{
+				Rule:        w.RuleName,
+				Description: w.Description,
+				URL:         w.URL,
+				Message:     w.Detail,
+				Level:       LevelWarning,
+			}
+			if w.Location != nil {
+				issue.File, issue.Ranges = location(t, w.Location)
+			}
+			add(t.Name, issue)
+		}
+		if e := t.Results.Error; e != nil {
+			issue := &Issue{
+				Rule:    buildErrorRule,
+				Message: e.Message,
+				Level:   LevelError,
+			}
+			issue.File, issue.Ranges = location(t, &e.Location)
+			add(t.Name, issue)
+		}
+	}
+
+	slices.SortStableFunc(issues, func(a, b *Issue) int {
+		if c := cmp.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(firstLine(a), firstLine(b)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Rule, b.Rule)
+	})
+	return issues
+}
+
+func location(t Target, loc *pb.Location) (string, []Range) {
+	if loc.SourceIndex < 0 || int(loc.SourceIndex) >= len(t.Results.Sources) {
+		return "", nil
+	}
+	src := t.Results.Sources[loc.SourceIndex]
+	if t.SourceInfoMap != nil {
+		src = t.SourceInfoMap(src)
+	}
+	var ranges []Range
+	for _, r := range loc.Ranges {
+		if r.Start == nil || r.End == nil {
+			continue
+		}
+		rng := Range{
+			StartLine: int(r.Start.Line),
+			EndLine:   int(r.End.Line),
+		}
+		if r.Start.Character > 0 || r.End.Character > 0 {
+			rng.StartColumn = int(r.Start.Character) + 1
+			rng.EndColumn = int(r.End.Character) + 1
+		}
+		ranges = append(ranges, rng)
+	}
+	return relPath(src.Filename), ranges
+}
+
+// relPath returns the path of a source file relative to the working
+// directory with forward slashes, so reports can be matched against
+// repository paths.
+func relPath(fname string) string {
+	if fname == "" {
+		return ""
+	}
+	if filepath.IsAbs(fname) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, fname); err == nil && !strings.HasPrefix(rel, "..") {
+				fname = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(fname))
+}
+
+func issueKey(issue *Issue) string {
+	var sb strings.Builder
+	sb.WriteString(issue.Rule + "\x00" + issue.Message + "\x00" + issue.File)
+	for _, r := range issue.Ranges {
+		sb.WriteString("\x00")
+		sb.WriteString(r.String())
+	}
+	return sb.String()
+}
+
+func firstLine(issue *Issue) int {
+	if len(issue.Ranges) == 0 {
+		return 0
+	}
+	return issue.Ranges[0].StartLine
+}
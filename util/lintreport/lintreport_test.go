@@ -0,0 +1,148 @@
+package lintreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/subrequests/lint"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func testTargets() []Target {
+	sources := []*pb.SourceInfo{{Filename: "Dockerfile"}}
+	warning := lint.Warning{
+		RuleName:    "StageNameCasing",
+		Description: "Stage names should be lowercase",
+		URL:         "https://docs.docker.com/go/dockerfile/rule/stage-name-casing/",
+		Detail:      "Stage name 'Build' should be lowercase",
+		Location: &pb.Location{
+			SourceIndex: 0,
+			Ranges: []*pb.Range{{
+				Start: &pb.Position{Line: 3},
+				End:   &pb.Position{Line: 3},
+			}},
+		},
+	}
+	return []Target{
+		{
+			Name: "app",
+			Results: &lint.LintResults{
+				Sources:  sources,
+				Warnings: []lint.Warning{warning},
+			},
+		},
+		{
+			Name: "app-debug",
+			Results: &lint.LintResults{
+				Sources: sources,
+				Warnings: []lint.Warning{warning, {
+					RuleName: "JSONArgsRecommended",
+					Detail:   "JSON arguments recommended for CMD",
+					Location: &pb.Location{
+						SourceIndex: 0,
+						Ranges: []*pb.Range{{
+							Start: &pb.Position{Line: 10, Character: 0},
+							End:   &pb.Position{Line: 10, Character: 12},
+						}},
+					},
+				}},
+			},
+		},
+		{
+			Name: "broken",
+			Results: &lint.LintResults{
+				Sources: []*pb.SourceInfo{{Filename: "broken.Dockerfile"}},
+				Error: &lint.BuildError{
+					Message: "unknown instruction: RUNN",
+					Location: pb.Location{
+						Ranges: []*pb.Range{{
+							Start: &pb.Position{Line: 2},
+							End:   &pb.Position{Line: 2},
+						}},
+					},
+				},
+			},
+		},
+		{
+			Name:    "clean",
+			Results: &lint.LintResults{},
+		},
+	}
+}
+
+func TestIssues(t *testing.T) {
+	issues := Issues(testTargets())
+	require.Len(t, issues, 3)
+
+	require.Equal(t, "StageNameCasing", issues[0].Rule)
+	require.Equal(t, "Dockerfile", issues[0].File)
+	require.Equal(t, []Range{{StartLine: 3, EndLine: 3}}, issues[0].Ranges)
+	require.Equal(t, []string{"app", "app-debug"}, issues[0].Targets)
+
+	require.Equal(t, "JSONArgsRecommended", issues[1].Rule)
+	require.Equal(t, []Range{{StartLine: 10, StartColumn: 1, EndLine: 10, EndColumn: 13}}, issues[1].Ranges)
+	require.Equal(t, []string{"app-debug"}, issues[1].Targets)
+
+	require.Equal(t, buildErrorRule, issues[2].Rule)
+	require.Equal(t, LevelError, issues[2].Level)
+	require.Equal(t, "broken.Dockerfile", issues[2].File)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteSARIF(buf, testTargets()))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, sarifVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	require.Len(t, run.Tool.Driver.Rules, 3)
+	require.Len(t, run.Results, 3)
+
+	res := run.Results[0]
+	require.Equal(t, "StageNameCasing", res.RuleID)
+	require.Equal(t, "StageNameCasing", run.Tool.Driver.Rules[res.RuleIndex].ID)
+	require.Equal(t, "https://docs.docker.com/go/dockerfile/rule/stage-name-casing/", run.Tool.Driver.Rules[res.RuleIndex].HelpURI)
+	require.Equal(t, LevelWarning, res.Level)
+	require.Equal(t, "Dockerfile", res.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, &sarifRegion{StartLine: 3, EndLine: 3}, res.Locations[0].PhysicalLocation.Region)
+	require.Equal(t, []any{"app", "app-debug"}, res.Properties["targets"])
+
+	require.Equal(t, LevelError, run.Results[2].Level)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJUnit(buf, testTargets()))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Equal(t, 5, suites.Tests)
+	require.Equal(t, 3, suites.Failures)
+	require.Equal(t, 1, suites.Errors)
+	require.Len(t, suites.Suites, 4)
+
+	app := suites.Suites[0]
+	require.Equal(t, "app", app.Name)
+	require.Len(t, app.TestCases, 1)
+	require.Equal(t, "Dockerfile:3 StageNameCasing", app.TestCases[0].Name)
+	require.Equal(t, 3, app.TestCases[0].Line)
+	require.NotNil(t, app.TestCases[0].Failure)
+	require.Equal(t, "Stage name 'Build' should be lowercase", app.TestCases[0].Failure.Message)
+
+	require.Len(t, suites.Suites[1].TestCases, 2)
+
+	broken := suites.Suites[2]
+	require.Equal(t, 1, broken.Errors)
+	require.NotNil(t, broken.TestCases[0].Error)
+
+	clean := suites.Suites[3]
+	require.Equal(t, 1, clean.Tests)
+	require.Equal(t, 0, clean.Failures)
+	require.Nil(t, clean.TestCases[0].Failure)
+}
@@ -0,0 +1,145 @@
+package lintreport
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+
+	"github.com/docker/buildx/version"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+	HelpURI          string        `json:"helpUri,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// WriteSARIF writes the check results of targets as a SARIF 2.1.0 log. The
+// targets reporting an issue are listed in the "targets" property of its
+// result.
+func WriteSARIF(w io.Writer, targets []Target) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "buildx",
+				InformationURI: "https://docs.docker.com/go/build-checks/",
+				Version:        version.Version,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	rules := map[string]int{}
+	for _, issue := range Issues(targets) {
+		idx, ok := rules[issue.Rule]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			rules[issue.Rule] = idx
+			rule := sarifRule{
+				ID:      issue.Rule,
+				HelpURI: issue.URL,
+			}
+			if issue.Description != "" {
+				rule.ShortDescription = &sarifMessage{Text: issue.Description}
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+
+		res := sarifResult{
+			RuleID:    issue.Rule,
+			RuleIndex: idx,
+			Level:     issue.Level,
+			Message:   sarifMessage{Text: issue.Message},
+			Properties: map[string]any{
+				"targets": slices.Clone(issue.Targets),
+			},
+		}
+		if issue.File != "" {
+			loc := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: issue.File},
+				},
+			}
+			if len(issue.Ranges) > 0 {
+				r := issue.Ranges[0]
+				loc.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   r.StartLine,
+					StartColumn: r.StartColumn,
+					EndLine:     r.EndLine,
+					EndColumn:   r.EndColumn,
+				}
+			}
+			res.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}